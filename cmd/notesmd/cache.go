@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Version of the cache files, older caches are dropped
+const cacheVersion = 1
+
+// headingCache keeps the headings of the notes of a vault between runs, so
+// the heading search only rereads notes that changed
+type headingCache struct {
+	path  string
+	dirty bool
+
+	Version int                   `json:"version"`
+	Notes   map[string]cachedNote `json:"notes"` // note path → headings
+}
+
+type cachedNote struct {
+	ModTime  int64           `json:"mod_time"`
+	Size     int64           `json:"size"`
+	Headings []cachedHeading `json:"headings"`
+}
+
+type cachedHeading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	Line  int    `json:"line"`
+}
+
+// headingCachePath returns the cache file of the vault at rootDir
+func headingCachePath(rootDir string) string {
+	sum := sha256.Sum256([]byte(rootDir))
+	return filepath.Join(getCacheDir(), "headings", hex.EncodeToString(sum[:8])+".json")
+}
+
+// loadHeadingCache reads the heading cache of a vault, empty when missing or
+// outdated
+func loadHeadingCache(rootDir string) *headingCache {
+	c := &headingCache{path: headingCachePath(rootDir)}
+	if data, err := os.ReadFile(c.path); err == nil {
+		json.Unmarshal(data, c)
+	}
+	if c.Version != cacheVersion || c.Notes == nil {
+		c.Version = cacheVersion
+		c.Notes = map[string]cachedNote{}
+	}
+	return c
+}
+
+// headings returns the headings of a note, parsing it again only when it
+// changed since it was cached. Encrypted notes are never cached.
+func (c *headingCache) headings(path string) []noteHeading {
+	info, err := os.Stat(path)
+	if err != nil || isEncryptedNote(path) {
+		return parseHeadings(loadMarkdownRaw(path))
+	}
+
+	if cached, ok := c.Notes[path]; ok && cached.ModTime == info.ModTime().UnixNano() && cached.Size == info.Size() {
+		headings := make([]noteHeading, len(cached.Headings))
+		for i, h := range cached.Headings {
+			headings[i] = noteHeading{level: h.Level, text: h.Text, line: h.Line}
+		}
+		return headings
+	}
+
+	headings := parseHeadings(loadMarkdownRaw(path))
+	cached := cachedNote{ModTime: info.ModTime().UnixNano(), Size: info.Size()}
+	for _, h := range headings {
+		cached.Headings = append(cached.Headings, cachedHeading{Level: h.level, Text: h.text, Line: h.line})
+	}
+	c.Notes[path] = cached
+	c.dirty = true
+	return headings
+}
+
+// save writes the cache when it changed, forgetting notes not in keep
+func (c *headingCache) save(keep []string) error {
+	kept := make(map[string]bool, len(keep))
+	for _, path := range keep {
+		kept[path] = true
+	}
+	for path := range c.Notes {
+		if !kept[path] {
+			delete(c.Notes, path)
+			c.dirty = true
+		}
+	}
+	if !c.dirty {
+		return nil
+	}
+	return c.write()
+}
+
+// forgetHeadings drops a saved note from the heading cache of its vault, as
+// an edit that keeps its size can also keep its modification time on
+// filesystems with a coarse clock
+func forgetHeadings(rootDir, path string) error {
+	c := loadHeadingCache(rootDir)
+	if _, ok := c.Notes[path]; !ok {
+		return nil
+	}
+	delete(c.Notes, path)
+	return c.write()
+}
+
+func (c *headingCache) write() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0644)
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Lock settings for concurrent captures
+const (
+	captureLockTimeout = 5 * time.Second
+	captureLockStale   = 30 * time.Second
+	captureLockRetry   = 10 * time.Millisecond
+)
+
+// withDefaults fills the capture settings missing from older config files
+func (c CaptureConfig) withDefaults() CaptureConfig {
+	def := DefaultConfig().Capture
+	if c.Inbox == "" {
+		c.Inbox = def.Inbox
+	}
+	if c.DailyFolder == "" {
+		c.DailyFolder = def.DailyFolder
+	}
+	if c.DailyFormat == "" {
+		c.DailyFormat = def.DailyFormat
+	}
+	if c.TimeFormat == "" {
+		c.TimeFormat = def.TimeFormat
+	}
+	return c
+}
+
+// captureTarget returns the note receiving a capture and the title used
+// when it has to be created
+func captureTarget(rootDir string, c CaptureConfig, daily bool, now time.Time) (path, title string) {
+	if daily {
+		name := now.Format(c.DailyFormat)
+		return filepath.Join(rootDir, c.DailyFolder, name+".md"), name
+	}
+
+	path = c.Inbox
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(rootDir, path)
+	}
+	if filepath.Ext(path) == "" {
+		path += ".md"
+	}
+	return path, noteLinkName(path)
+}
+
+// formatCaptureEntry turns captured text into a timestamped list item.
+// Continuation lines are indented so they stay part of the item.
+func formatCaptureEntry(text string, task bool, timestamp string) string {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	lines := strings.Split(text, "\n")
+
+	marker := "- "
+	if task {
+		marker = "- [ ] "
+	}
+
+	var sb strings.Builder
+	sb.WriteString(marker)
+	if timestamp != "" {
+		sb.WriteString(timestamp + " ")
+	}
+	sb.WriteString(lines[0])
+	for _, line := range lines[1:] {
+		sb.WriteString("\n")
+		if strings.TrimSpace(line) != "" {
+			sb.WriteString(strings.Repeat(" ", len(marker)) + line)
+		}
+	}
+	return sb.String()
+}
+
+// insertCapture adds entry at the end of the section under heading, or at
+// the end of content when heading is empty. A missing heading is created.
+func insertCapture(content, heading, entry string) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	if heading == "" {
+		return joinCapture(lines, len(lines), entry)
+	}
+
+	text := strings.TrimSpace(strings.TrimLeft(heading, "#"))
+	var fence fenceState
+	start, level := -1, 0
+	for i, line := range lines {
+		inFence := fence.open || isFenceLine(line)
+		fence = fence.next(line)
+		if inFence {
+			continue
+		}
+
+		lvl := headingLevel(line)
+		if lvl == 0 {
+			continue
+		}
+		if start >= 0 && lvl <= level {
+			return joinCapture(lines, i, entry)
+		}
+		if start < 0 && strings.EqualFold(strings.TrimSpace(line[lvl:]), text) {
+			start, level = i, lvl
+		}
+	}
+
+	if start >= 0 {
+		return joinCapture(lines, len(lines), entry)
+	}
+
+	// Create the heading at the end of the note
+	if len(lines) > 0 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, "## "+text)
+	return joinCapture(lines, len(lines), entry)
+}
+
+// joinCapture inserts entry before line end, after the last non-blank line
+func joinCapture(lines []string, end int, entry string) string {
+	at := end
+	for at > 0 && strings.TrimSpace(lines[at-1]) == "" {
+		at--
+	}
+
+	var out []string
+	out = append(out, lines[:at]...)
+	// Separate the entry from a heading or paragraph, not from a list
+	if at > 0 && !isCaptureItem(lines[at-1]) {
+		out = append(out, "")
+	}
+	out = append(out, entry)
+	if end < len(lines) {
+		out = append(out, "")
+		out = append(out, lines[end:]...)
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// isCaptureItem reports whether line belongs to a list (item or continuation)
+func isCaptureItem(line string) bool {
+	if _, ok := parseListItem(line); ok {
+		return true
+	}
+	return strings.HasPrefix(line, "  ")
+}
+
+// appendCapture writes entry into the note at path, creating it with title
+// if needed. Concurrent captures are serialized with a lock file.
+func appendCapture(path, title, heading, entry string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	content := string(data)
+	mode := os.FileMode(0644)
+	if os.IsNotExist(err) {
+		content = "# " + title + "\n"
+	} else if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	crlf := strings.Contains(content, "\r\n")
+	updated := insertCapture(strings.ReplaceAll(content, "\r\n", "\n"), heading, entry)
+	if crlf {
+		updated = strings.ReplaceAll(updated, "\n", "\r\n")
+	}
+
+	// Write to a temporary file first so readers never see a partial note
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(updated), mode); err != nil {
+		return err
+	}
+	// The rename replaces the note, keep its permissions despite the umask
+	if err := os.Chmod(tmp, mode); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// lockFile takes an exclusive lock on path using a sibling ".lock" file.
+// Locks older than captureLockStale are considered abandoned.
+func lockFile(path string) (unlock func(), err error) {
+	lock := path + ".lock"
+	deadline := time.Now().Add(captureLockTimeout)
+
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(lock) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if lockIsStale(lock) {
+			takeOverLock(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("note verrouillée : %s", lock)
+		}
+		time.Sleep(captureLockRetry)
+	}
+}
+
+func lockIsStale(lock string) bool {
+	info, err := os.Stat(lock)
+	return err == nil && time.Since(info.ModTime()) > captureLockStale
+}
+
+// takeOverLock removes an abandoned lock. The lock is first moved aside,
+// which only one of several processes taking it over can do; if a fresh
+// lock was moved instead, the stale one being gone already, it is put back.
+func takeOverLock(lock string) {
+	aside := fmt.Sprintf("%s.%d-%d.stale", lock, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(lock, aside); err != nil {
+		return
+	}
+	if !lockIsStale(aside) {
+		os.Link(aside, lock)
+	}
+	os.Remove(aside)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInsertCapture(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		heading string
+		want    string
+	}{
+		{
+			name:    "end of note",
+			content: "# Inbox\n\n- 09:00 first\n",
+			want:    "# Inbox\n\n- 09:00 first\n- entry\n",
+		},
+		{
+			name:    "after paragraph",
+			content: "# Inbox\n",
+			want:    "# Inbox\n\n- entry\n",
+		},
+		{
+			name:    "end of section",
+			content: "# Day\n\n## Todo\n\n- a\n\n## Notes\n\ntext\n",
+			heading: "Todo",
+			want:    "# Day\n\n## Todo\n\n- a\n- entry\n\n## Notes\n\ntext\n",
+		},
+		{
+			name:    "subsections stay in section",
+			content: "## Todo\n\n### Later\n\n- b\n## Notes\n",
+			heading: "## todo",
+			want:    "## Todo\n\n### Later\n\n- b\n- entry\n\n## Notes\n",
+		},
+		{
+			name:    "heading inside code is ignored",
+			content: "# Day\n\n```\n## Todo\n```\n",
+			heading: "Todo",
+			want:    "# Day\n\n```\n## Todo\n```\n\n## Todo\n\n- entry\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := insertCapture(tt.content, tt.heading, "- entry")
+			if got != tt.want {
+				t.Errorf("insertCapture() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatCaptureEntry(t *testing.T) {
+	got := formatCaptureEntry("buy milk\nand bread\n", true, "10:30")
+	want := "- [ ] 10:30 buy milk\n      and bread"
+	if got != want {
+		t.Errorf("formatCaptureEntry() = %q, want %q", got, want)
+	}
+}
+
+func TestAppendCaptureConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Inbox.md")
+
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- appendCapture(path, "Inbox", "", fmt.Sprintf("- entry %d", i))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range n {
+		if !strings.Contains(string(data), fmt.Sprintf("- entry %d\n", i)) {
+			t.Errorf("entry %d missing from:\n%s", i, data)
+		}
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Error("lock file left behind")
+	}
+}
+
+func TestStaleLockTakenOverOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Inbox.md")
+	writeTestFile(t, path+".lock", "999999\n")
+	old := time.Now().Add(-2 * captureLockStale)
+	os.Chtimes(path+".lock", old, old)
+
+	var holders atomic.Int32
+	var overlap atomic.Bool
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := lockFile(path)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if holders.Add(1) > 1 {
+				overlap.Store(true)
+			}
+			time.Sleep(5 * time.Millisecond)
+			holders.Add(-1)
+			unlock()
+		}()
+	}
+	wg.Wait()
+	if overlap.Load() {
+		t.Error("the stale lock was taken over twice")
+	}
+}
+
+func TestAppendCaptureKeepsMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Inbox.md")
+	writeTestFile(t, path, "# Inbox\n")
+	os.Chmod(path, 0600)
+
+	if err := appendCapture(path, "Inbox", "", "- entrée"); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("mode after capture = %v", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,907 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// errUsage is returned by commands invoked with invalid arguments
+var errUsage = errors.New("usage")
+
+// cliContext holds what every subcommand needs
+type cliContext struct {
+	config *Config
+	global *Config // configuration without the vault layer
+
+	configErr error // invalid settings of config.json
+	vaultErr  error // invalid settings of the vault configuration
+	rootDir   string
+	json      bool
+	stdin     io.Reader
+	stdout    io.Writer
+	stderr    io.Writer
+}
+
+// cliCommand is a non-interactive subcommand
+type cliCommand struct {
+	name    string
+	args    string
+	summary string
+	flags   func(fs *flag.FlagSet) // registers command-specific flags
+	run     func(ctx *cliContext, args []string) error
+}
+
+// cliCommands returns the available subcommands
+func cliCommands() []cliCommand {
+	return []cliCommand{
+		{name: "new", args: "<nom>", summary: "Créer une note (contenu via --content ou stdin)", flags: newCmdFlags, run: runNew},
+		{name: "capture", args: "[texte]", summary: "Ajouter une entrée horodatée à l'inbox ou à la note du jour", flags: captureCmdFlags, run: runCapture},
+		{name: "ls", args: "[dossier]", summary: "Lister les notes du vault", run: runLs},
+		{name: "search", args: "<texte>", summary: "Rechercher dans le contenu des notes", run: runSearch},
+		{name: "open", args: "<note>", summary: "Ouvrir l'interface sur une note", run: runOpen},
+		{name: "cat", args: "<note>", summary: "Afficher le rendu d'une note", flags: catCmdFlags, run: runCat},
+		{name: "view", args: "<note|->", summary: "Afficher le rendu dans $PAGER (stdin avec -)", flags: viewCmdFlags, run: runView},
+		{name: "export", args: "html <dossier>", summary: "Exporter le vault en site HTML statique", flags: exportCmdFlags, run: runExport},
+		{name: "import", args: "obsidian <vault> | enex|jex <fichier> <dest>", summary: "Importer un vault Obsidian ou un export Evernote/Joplin", flags: importCmdFlags, run: runImport},
+		{name: "links", args: "<note>", summary: "Lister les liens wiki d'une note", run: runLinks},
+		{name: "backlinks", args: "<note>", summary: "Lister les notes qui pointent vers une note", run: runBacklinks},
+		{name: "tags", args: "[note]", summary: "Lister les tags du vault ou d'une note", run: runTags},
+		{name: "config", args: "check", summary: "Vérifier config.json et la configuration du vault", run: runConfig},
+		{name: "version", summary: "Afficher la version", run: runVersion},
+	}
+}
+
+// runCLI runs a subcommand if args start with one. It reports false when
+// args should be handled by the TUI (no arguments or a start directory).
+func runCLI(args []string) (code int, handled bool) {
+	ctx := &cliContext{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	return ctx.main(args)
+}
+
+// main runs the subcommand of args with the streams of ctx and returns the
+// exit code: 2 for invalid arguments, 1 when the command fails
+func (ctx *cliContext) main(args []string) (code int, handled bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+
+	switch args[0] {
+	case "help", "-h", "--help":
+		printUsage(ctx.stdout)
+		return 0, true
+	case "-v", "--version":
+		args = []string{"version"}
+	}
+
+	var cmd *cliCommand
+	for _, c := range cliCommands() {
+		if c.name == args[0] {
+			cmd = &c
+			break
+		}
+	}
+	if cmd == nil {
+		return 0, false
+	}
+
+	if err := ctx.execute(*cmd, args[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(ctx.stderr, "Usage : notesmd %s %s\n", cmd.name, cmd.args)
+			return 2, true
+		}
+		fmt.Fprintf(ctx.stderr, "notesmd %s : %v\n", cmd.name, err)
+		return 1, true
+	}
+	return 0, true
+}
+
+// execute parses the common and command flags, loads the config and runs cmd
+func (ctx *cliContext) execute(cmd cliCommand, args []string) error {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(ctx.stderr)
+	dir := fs.String("dir", "", "dossier du vault (défaut : default_dir de la config)")
+	vault := fs.String("vault", "", "nom d'un vault de la config")
+	fs.BoolVar(&ctx.json, "json", false, "sortie JSON")
+
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return errUsage
+	}
+
+	config, configErr := LoadConfig()
+	if *vault != "" {
+		v, ok := config.findVault(*vault)
+		if !ok {
+			return fmt.Errorf("vault inconnu : %s", *vault)
+		}
+		*dir = v.dir()
+	}
+	ctx.global = config
+	ctx.rootDir, err = cliVaultDir(config, *dir)
+	if err != nil {
+		return err
+	}
+	ctx.config, _, ctx.vaultErr = loadVaultConfig(config, ctx.rootDir)
+	ctx.configErr = configErr
+	if cmd.name != "config" {
+		// config check lists them itself
+		for _, err := range []error{ctx.configErr, ctx.vaultErr} {
+			if err != nil {
+				fmt.Fprintln(ctx.stderr, "Réglages ignorés :", err)
+			}
+		}
+	}
+	setMarkdownTheme(ctx.config.MarkdownTheme)
+	setVaultIgnore(ctx.rootDir, ctx.config)
+
+	return cmd.run(ctx, positional)
+}
+
+// parseInterspersed parses flags placed before or after positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// cliVaultDir resolves the vault used by subcommands
+func cliVaultDir(config *Config, dir string) (string, error) {
+	if dir == "" {
+		dir = "."
+		if info, err := os.Stat(config.DefaultDir); err == nil && info.IsDir() {
+			dir = config.DefaultDir
+		}
+	}
+	return filepath.Abs(dir)
+}
+
+// printUsage writes the CLI help
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "NotesMD — navigateur de notes Markdown")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Usage :")
+	fmt.Fprintln(w, "  notesmd [dossier]              Lancer l'interface")
+	fmt.Fprintln(w, "  notesmd --vault <nom>          Lancer l'interface sur un vault nommé")
+	fmt.Fprintln(w, "  notesmd <commande> [options]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commandes :")
+	for _, c := range cliCommands() {
+		fmt.Fprintf(w, "  %-24s %s\n", strings.TrimSpace(c.name+" "+c.args), c.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Options communes :")
+	fmt.Fprintln(w, "  --dir <dossier>     vault à utiliser (défaut : default_dir de la config)")
+	fmt.Fprintln(w, "  --vault <nom>       vault nommé de la config")
+	fmt.Fprintln(w, "  --config <fichier>  fichier de configuration (défaut : $XDG_CONFIG_HOME/notesmd/config.json)")
+	fmt.Fprintln(w, "  --json              sortie JSON")
+}
+
+// writeJSON prints v as indented JSON
+func (ctx *cliContext) writeJSON(v any) error {
+	enc := json.NewEncoder(ctx.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// rel returns path relative to the vault root
+func (ctx *cliContext) rel(path string) string {
+	if rel, err := filepath.Rel(ctx.rootDir, path); err == nil {
+		return rel
+	}
+	return path
+}
+
+// resolveNote finds a note given as a path or as a wiki-link name
+func (ctx *cliContext) resolveNote(arg string) (string, error) {
+	candidates := []string{arg, filepath.Join(ctx.rootDir, arg)}
+	if filepath.Ext(arg) == "" {
+		candidates = append(candidates, arg+".md", filepath.Join(ctx.rootDir, arg+".md"))
+	}
+	for _, path := range candidates {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return filepath.Abs(path)
+		}
+	}
+
+	if path := findNoteByName(wikiLinkTarget(arg), ctx.rootDir); path != "" {
+		return path, nil
+	}
+	return "", fmt.Errorf("note introuvable : %s", arg)
+}
+
+// ========== Commands ==========
+
+var newContent, newFolder, newTemplate string
+
+func newCmdFlags(fs *flag.FlagSet) {
+	fs.StringVar(&newContent, "content", "", "contenu de la note")
+	fs.StringVar(&newFolder, "folder", "", "dossier de destination, relatif au vault")
+	fs.StringVar(&newTemplate, "template", "", "modèle du dossier templates_folder")
+}
+
+func runNew(ctx *cliContext, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	content := newContent
+	if content == "" && ctx.stdinIsPiped() {
+		data, err := io.ReadAll(ctx.stdin)
+		if err != nil {
+			return err
+		}
+		content = strings.TrimSpace(string(data))
+	}
+	if content == "" && newTemplate != "" {
+		tmpl, err := loadTemplate(ctx.rootDir, ctx.config.Notes, newTemplate, args[0], time.Now())
+		if err != nil {
+			return err
+		}
+		content = tmpl
+	}
+
+	dir := ctx.config.Notes.newNoteDir(ctx.rootDir, ctx.rootDir)
+	if newFolder != "" {
+		dir = filepath.Join(ctx.rootDir, newFolder)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	path, err := createNote(dir, args[0], content)
+	if err != nil {
+		return err
+	}
+
+	if ctx.json {
+		return ctx.writeJSON(map[string]string{"path": path})
+	}
+	fmt.Fprintln(ctx.stdout, path)
+	return nil
+}
+
+var captureDaily, captureTask bool
+var captureHeading, captureTo string
+
+func captureCmdFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&captureDaily, "daily", false, "ajouter à la note du jour")
+	fs.BoolVar(&captureTask, "task", false, "ajouter une tâche - [ ]")
+	fs.StringVar(&captureHeading, "heading", "", "titre sous lequel ajouter l'entrée")
+	fs.StringVar(&captureTo, "to", "", "note de destination, relative au vault")
+}
+
+func runCapture(ctx *cliContext, args []string) error {
+	text := strings.Join(args, " ")
+	if text == "" && ctx.stdinIsPiped() {
+		data, err := io.ReadAll(ctx.stdin)
+		if err != nil {
+			return err
+		}
+		text = string(data)
+	}
+	if strings.TrimSpace(text) == "" {
+		return errUsage
+	}
+
+	c := ctx.config.Capture.withDefaults()
+	if captureTo != "" {
+		c.Inbox = captureTo
+	}
+	heading := c.Heading
+	if captureHeading != "" {
+		heading = captureHeading
+	}
+
+	now := time.Now()
+	path, title := captureTarget(ctx.rootDir, c, (c.Daily || captureDaily) && captureTo == "", now)
+	entry := formatCaptureEntry(text, captureTask, now.Format(c.TimeFormat))
+	if err := appendCapture(path, title, heading, entry); err != nil {
+		return err
+	}
+
+	if ctx.json {
+		return ctx.writeJSON(map[string]string{"path": path, "entry": entry})
+	}
+	fmt.Fprintln(ctx.stdout, ctx.rel(path))
+	return nil
+}
+
+type noteInfo struct {
+	Path     string    `json:"path"`
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+func runLs(ctx *cliContext, args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+
+	dir := ctx.rootDir
+	if len(args) == 1 {
+		dir = filepath.Join(ctx.rootDir, args[0])
+	}
+
+	var notes []noteInfo
+	for _, path := range walkNotes(dir) {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		notes = append(notes, noteInfo{
+			Path:     ctx.rel(path),
+			Name:     noteLinkName(path),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+		})
+	}
+
+	if ctx.json {
+		if notes == nil {
+			notes = []noteInfo{}
+		}
+		return ctx.writeJSON(notes)
+	}
+	for _, n := range notes {
+		fmt.Fprintln(ctx.stdout, n.Path)
+	}
+	return nil
+}
+
+type searchMatch struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+func runSearch(ctx *cliContext, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	query := strings.Join(args, " ")
+
+	results := searchFiles(ctx.rootDir, query)
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].FilePath != results[j].FilePath {
+			return results[i].FilePath < results[j].FilePath
+		}
+		return results[i].LineNum < results[j].LineNum
+	})
+
+	matches := make([]searchMatch, 0, len(results))
+	for _, r := range results {
+		matches = append(matches, searchMatch{Path: ctx.rel(r.FilePath), Line: r.LineNum, Text: r.Line})
+	}
+
+	if ctx.json {
+		return ctx.writeJSON(matches)
+	}
+	for _, m := range matches {
+		fmt.Fprintf(ctx.stdout, "%s:%d: %s\n", m.Path, m.Line, m.Text)
+	}
+	return nil
+}
+
+func runOpen(ctx *cliContext, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	path, err := ctx.resolveNote(args[0])
+	if err != nil {
+		return err
+	}
+
+	state, err := LoadState()
+	if err != nil {
+		state = &SessionState{}
+	}
+	return runTUI(ctx.rootDir, ctx.global, ctx.configErr, state, path)
+}
+
+var catWidth int
+var catRaw bool
+
+func catCmdFlags(fs *flag.FlagSet) {
+	fs.IntVar(&catWidth, "width", 80, "largeur du rendu")
+	fs.BoolVar(&catRaw, "raw", false, "afficher le Markdown brut")
+}
+
+func runCat(ctx *cliContext, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	path, err := ctx.resolveNote(args[0])
+	if err != nil {
+		return err
+	}
+
+	if catRaw {
+		data, err := readCLINote(path)
+		if err != nil {
+			return err
+		}
+		_, err = ctx.stdout.Write(data)
+		return err
+	}
+
+	if _, err := readCLINote(path); err != nil {
+		return err
+	}
+	fmt.Fprint(ctx.stdout, loadMarkdownWithLinks(path, ctx.rootDir, catWidth))
+	return nil
+}
+
+// readCLINote reads a note for the subcommands, which have no passphrase
+// prompt: encrypted notes are refused instead of printing their ciphertext
+func readCLINote(path string) ([]byte, error) {
+	data, err := readNote(path)
+	if err == errNoteLocked {
+		return nil, fmt.Errorf("%s est chiffrée : ouvrez-la avec notesmd open", filepath.Base(path))
+	}
+	return data, err
+}
+
+var viewWidth int
+var viewNoColor bool
+
+func viewCmdFlags(fs *flag.FlagSet) {
+	fs.IntVar(&viewWidth, "width", 0, "largeur du rendu (défaut : largeur du terminal)")
+	fs.BoolVar(&viewNoColor, "no-color", false, "rendu sans couleurs")
+}
+
+func runView(ctx *cliContext, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	var content string
+	isMarkdown := true
+	if args[0] == "-" {
+		data, err := io.ReadAll(ctx.stdin)
+		if err != nil {
+			return err
+		}
+		content = string(data)
+	} else {
+		path, err := ctx.resolveNote(args[0])
+		if err != nil {
+			return err
+		}
+		data, err := readCLINote(path)
+		if err != nil {
+			return err
+		}
+		content = string(data)
+		isMarkdown = isMarkdownNote(path)
+	}
+
+	tty := isTerminal(os.Stdout)
+	width := viewWidth
+	if width <= 0 {
+		width = 80
+		if w, _, err := term.GetSize(int(os.Stdout.Fd())); tty && err == nil && w > 0 {
+			width = w
+		}
+	}
+
+	style := markdownTheme
+	if viewNoColor || os.Getenv("NO_COLOR") != "" {
+		style = "notty"
+	}
+
+	out := content
+	if isMarkdown {
+		rendered, err := renderMarkdownWithLinks(content, ctx.rootDir, width, style)
+		if err != nil {
+			return err
+		}
+		out = rendered
+	}
+
+	if tty {
+		return pageOutput(out, ctx.stdout)
+	}
+	_, err := io.WriteString(ctx.stdout, out)
+	return err
+}
+
+// pageOutput pipes out through $PAGER (less by default), falling back to w
+func pageOutput(out string, w io.Writer) error {
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		if _, err := exec.LookPath("less"); err == nil {
+			pager = "less"
+		}
+	}
+	fields := strings.Fields(pager)
+	if len(fields) == 0 {
+		_, err := io.WriteString(w, out)
+		return err
+	}
+
+	cmd := exec.Command(fields[0], fields[1:]...)
+	cmd.Stdin = strings.NewReader(out)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// Let less pass colors through and quit when the note fits on screen
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	return cmd.Run()
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+var exportOpts htmlExportOptions
+
+func exportCmdFlags(fs *flag.FlagSet) {
+	fs.StringVar(&exportOpts.folder, "folder", "", "exporter uniquement ce dossier du vault")
+	fs.StringVar(&exportOpts.tag, "tag", "", "exporter uniquement les notes portant ce tag")
+	fs.BoolVar(&exportOpts.published, "published", false, "exporter uniquement les notes avec publish: true")
+	fs.BoolVar(&exportOpts.rawHTML, "raw-html", false, "garder le HTML écrit dans les notes")
+}
+
+func runExport(ctx *cliContext, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	if args[0] != "html" {
+		return fmt.Errorf("format inconnu : %s", args[0])
+	}
+
+	outDir, err := filepath.Abs(args[1])
+	if err != nil {
+		return err
+	}
+	count, err := exportHTML(ctx.rootDir, outDir, exportOpts)
+	if err != nil {
+		return err
+	}
+
+	if ctx.json {
+		return ctx.writeJSON(map[string]any{"dir": outDir, "notes": count})
+	}
+	fmt.Fprintf(ctx.stdout, "%d note(s) exportée(s) dans %s\n", count, outDir)
+	return nil
+}
+
+var importDryRun bool
+var importDefaultDir bool
+
+func importCmdFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&importDryRun, "dry-run", false, "afficher le rapport sans rien enregistrer")
+	fs.BoolVar(&importDefaultDir, "default-dir", false, "faire du vault Obsidian le dossier par défaut")
+}
+
+func runImport(ctx *cliContext, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "obsidian":
+		if len(args) != 2 {
+			return errUsage
+		}
+		return runImportObsidian(ctx, args[1])
+	case "enex", "jex":
+		if len(args) != 3 {
+			return errUsage
+		}
+		return runImportNotes(ctx, args[0], args[1], args[2])
+	}
+	return fmt.Errorf("format inconnu : %s", args[0])
+}
+
+func runImportObsidian(ctx *cliContext, vault string) error {
+	vaultDir, err := filepath.Abs(vault)
+	if err != nil {
+		return err
+	}
+	state, err := LoadState()
+	if err != nil {
+		return err
+	}
+
+	// Imported settings go to the global configuration, without the
+	// overrides of the current vault. Only the changed keys are written, the
+	// settings skipped while loading config.json stay in it.
+	loaded := ctx.global
+	config, err := cloneConfig(loaded)
+	if err != nil {
+		return err
+	}
+	report, err := importObsidian(vaultDir, config, state, importDefaultDir)
+	if err != nil {
+		return err
+	}
+	saved := !importDryRun
+	if !importDryRun {
+		if err := config.SaveChanges(loaded); err != nil {
+			// Invalid JSON, the bookmarks are imported all the same
+			report.unsupported("réglages non enregistrés : %v", err)
+			saved = false
+		}
+		if err := SaveState(state); err != nil {
+			return err
+		}
+	}
+
+	if ctx.json {
+		return ctx.writeJSON(report)
+	}
+	printImportReport(ctx.stdout, report, importDryRun)
+	if saved && len(report.Settings) > 0 {
+		fmt.Fprintf(ctx.stdout, "\nConfiguration enregistrée dans %s\n", getConfigPath())
+	}
+	return nil
+}
+
+// runImportNotes converts an Evernote or Joplin export into dest
+func runImportNotes(ctx *cliContext, format, file, dest string) error {
+	destDir, err := filepath.Abs(dest)
+	if err != nil {
+		return err
+	}
+
+	// A dry run converts into a scratch folder to build the report
+	outDir := destDir
+	if importDryRun {
+		if outDir, err = os.MkdirTemp("", "notesmd-import-"); err != nil {
+			return err
+		}
+		defer os.RemoveAll(outDir)
+	}
+
+	importer := importENEX
+	if format == "jex" {
+		importer = importJEX
+	}
+	report, err := importer(file, outDir, ctx.config)
+	if err != nil {
+		return err
+	}
+
+	if ctx.json {
+		return ctx.writeJSON(report)
+	}
+	printImportReport(ctx.stdout, report, importDryRun)
+	if !importDryRun {
+		fmt.Fprintf(ctx.stdout, "Notes écrites dans %s\n", destDir)
+	}
+	return nil
+}
+
+// printImportReport writes a human-readable import report
+func printImportReport(w io.Writer, r importReport, dryRun bool) {
+	fmt.Fprintf(w, "Import de %s\n", r.Source)
+	if r.Notes > 0 {
+		fmt.Fprintf(w, "Notes importées : %d\n", r.Notes)
+	}
+	if len(r.Settings) > 0 {
+		fmt.Fprintln(w, "\nRéglages :")
+		for _, s := range r.Settings {
+			fmt.Fprintln(w, "  "+s)
+		}
+	}
+	if len(r.Settings) > 0 || r.Bookmarks > 0 {
+		fmt.Fprintf(w, "\nSignets importés : %d\n", r.Bookmarks)
+	}
+	if len(r.Unsupported) > 0 {
+		fmt.Fprintln(w, "\nNon supporté :")
+		for _, u := range r.Unsupported {
+			fmt.Fprintln(w, "  - "+u)
+		}
+	}
+	if dryRun {
+		fmt.Fprintln(w, "\n(simulation : rien n'a été enregistré)")
+	}
+}
+
+type linkInfo struct {
+	Link   string `json:"link"`
+	Path   string `json:"path,omitempty"`
+	Exists bool   `json:"exists"`
+}
+
+func runLinks(ctx *cliContext, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	path, err := ctx.resolveNote(args[0])
+	if err != nil {
+		return err
+	}
+
+	links := []linkInfo{}
+	for _, link := range parseWikiLinks(loadMarkdownRaw(path)) {
+		info := linkInfo{Link: link}
+		if target := findNoteByName(wikiLinkTarget(link), ctx.rootDir); target != "" {
+			info.Path = ctx.rel(target)
+			info.Exists = true
+		}
+		links = append(links, info)
+	}
+
+	if ctx.json {
+		return ctx.writeJSON(links)
+	}
+	for _, l := range links {
+		target := l.Path
+		if !l.Exists {
+			target = "(n'existe pas)"
+		}
+		fmt.Fprintf(ctx.stdout, "%s\t%s\n", l.Link, target)
+	}
+	return nil
+}
+
+func runBacklinks(ctx *cliContext, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	path, err := ctx.resolveNote(args[0])
+	if err != nil {
+		return err
+	}
+
+	backlinks := []string{}
+	for _, source := range findBacklinks(path, walkNotes(ctx.rootDir)) {
+		backlinks = append(backlinks, ctx.rel(source))
+	}
+
+	if ctx.json {
+		return ctx.writeJSON(backlinks)
+	}
+	for _, b := range backlinks {
+		fmt.Fprintln(ctx.stdout, b)
+	}
+	return nil
+}
+
+type tagCount struct {
+	Tag   string   `json:"tag"`
+	Count int      `json:"count"`
+	Notes []string `json:"notes"`
+}
+
+func runTags(ctx *cliContext, args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+
+	notes := walkNotes(ctx.rootDir)
+	if len(args) == 1 {
+		path, err := ctx.resolveNote(args[0])
+		if err != nil {
+			return err
+		}
+		notes = []string{path}
+	}
+
+	byTag := make(map[string]*tagCount)
+	for _, path := range notes {
+		for _, tag := range parseTags(loadMarkdownRaw(path)) {
+			key := strings.ToLower(tag)
+			if byTag[key] == nil {
+				byTag[key] = &tagCount{Tag: tag}
+			}
+			byTag[key].Count++
+			byTag[key].Notes = append(byTag[key].Notes, ctx.rel(path))
+		}
+	}
+
+	tags := make([]tagCount, 0, len(byTag))
+	for _, t := range byTag {
+		tags = append(tags, *t)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	if ctx.json {
+		return ctx.writeJSON(tags)
+	}
+	for _, t := range tags {
+		fmt.Fprintf(ctx.stdout, "#%s\t%d\n", t.Tag, t.Count)
+	}
+	return nil
+}
+
+// configCheckIssue is a configuration problem in the JSON output
+type configCheckIssue struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Key     string `json:"key,omitempty"`
+	Message string `json:"message"`
+}
+
+func runConfig(ctx *cliContext, args []string) error {
+	if len(args) != 1 || args[0] != "check" {
+		return errUsage
+	}
+
+	vaultPath := vaultConfigPath(ctx.rootDir)
+	issues := append(configIssues(ctx.configErr, getConfigPath()), configIssues(ctx.vaultErr, vaultPath)...)
+
+	if ctx.json {
+		out := make([]configCheckIssue, 0, len(issues))
+		for _, i := range issues {
+			out = append(out, configCheckIssue{File: i.file, Line: i.line, Key: i.key, Message: i.msg})
+		}
+		if err := ctx.writeJSON(out); err != nil {
+			return err
+		}
+	} else {
+		for _, i := range issues {
+			fmt.Fprintln(ctx.stdout, i)
+		}
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%d réglage(s) ignoré(s)", len(issues))
+	}
+	if !ctx.json {
+		files := []string{getConfigPath()}
+		if _, err := os.Stat(vaultPath); err == nil {
+			files = append(files, vaultPath)
+		}
+		fmt.Fprintln(ctx.stdout, "Configuration valide :", strings.Join(files, ", "))
+	}
+	return nil
+}
+
+func runVersion(ctx *cliContext, args []string) error {
+	if ctx.json {
+		return ctx.writeJSON(map[string]string{
+			"version": version,
+			"commit":  commit,
+			"date":    date,
+			"builtBy": builtBy,
+		})
+	}
+	fmt.Fprintln(ctx.stdout, FullVersion())
+	return nil
+}
+
+// stdinIsPiped reports whether stdin is a pipe or file rather than a terminal
+func (ctx *cliContext) stdinIsPiped() bool {
+	f, ok := ctx.stdin.(*os.File)
+	if !ok {
+		return ctx.stdin != nil
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice == 0
+}
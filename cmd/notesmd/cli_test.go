@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// runTestCLI runs a subcommand against a temporary configuration
+func runTestCLI(t *testing.T, stdin string, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	ctx := &cliContext{stdin: strings.NewReader(stdin), stdout: &out, stderr: &errOut}
+	code, handled := ctx.main(args)
+	if !handled {
+		t.Fatalf("%q not handled as a command", args)
+	}
+	return code, out.String(), errOut.String()
+}
+
+func testVault(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	vault := t.TempDir()
+	writeTestFile(t, filepath.Join(vault, "projet.md"), "# Projet\n\nVoir [[idées]] et [[absente]]. #travail\n")
+	writeTestFile(t, filepath.Join(vault, "notes", "idées.md"), "# Idées\n\nUne idée de projet.\n")
+	return vault
+}
+
+func TestTakeFlag(t *testing.T) {
+	tests := []struct {
+		args  []string
+		value string
+		rest  []string
+		err   bool
+	}{
+		{args: []string{"--config", "a.json", "ls"}, value: "a.json", rest: []string{"ls"}},
+		{args: []string{"ls", "--config=b.json", "-x"}, value: "b.json", rest: []string{"ls", "-x"}},
+		{args: []string{"-config", "c.json"}, value: "c.json"},
+		{args: []string{"--configs", "d"}, rest: []string{"--configs", "d"}},
+		{args: []string{"ls", "--config"}, err: true},
+	}
+	for _, tt := range tests {
+		value, rest, err := takeFlag(tt.args, "config")
+		if (err != nil) != tt.err || value != tt.value || !slices.Equal(rest, tt.rest) {
+			t.Errorf("takeFlag(%q) = %q, %q, %v", tt.args, value, rest, err)
+		}
+	}
+}
+
+func TestCLIExitCodes(t *testing.T) {
+	vault := testVault(t)
+	dir := "--dir=" + vault
+
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"ls", dir}, 0},
+		{[]string{"ls", dir, "a", "b"}, 2},
+		{[]string{"search", dir}, 2},
+		{[]string{"search", dir, "idée"}, 0},
+		{[]string{"cat", dir, "projet"}, 0},
+		{[]string{"cat", dir, "inconnue"}, 1},
+		{[]string{"cat", dir}, 2},
+		{[]string{"new", dir}, 2},
+		{[]string{"links", dir, "projet"}, 0},
+		{[]string{"backlinks", dir, "idées"}, 0},
+		{[]string{"tags", dir}, 0},
+		{[]string{"ls", "--inconnu"}, 2},
+		{[]string{"ls", "--vault", "absent"}, 1},
+		{[]string{"export", dir, "pdf", t.TempDir()}, 1},
+		{[]string{"export", dir, "html"}, 2},
+		{[]string{"import", dir}, 2},
+		{[]string{"import", dir, "obsidian", filepath.Join(vault, "absent")}, 1},
+		{[]string{"import", dir, "enex", "absent.enex", t.TempDir()}, 1},
+		{[]string{"import", dir, "rtf", "a"}, 1},
+		{[]string{"config", dir, "check"}, 0},
+		{[]string{"version"}, 0},
+		{[]string{"help"}, 0},
+	}
+	for _, tt := range tests {
+		if code, _, stderr := runTestCLI(t, "", tt.args...); code != tt.code {
+			t.Errorf("%q exited with %d, want %d (%s)", tt.args, code, tt.code, stderr)
+		}
+	}
+
+	ctx := &cliContext{}
+	if _, handled := ctx.main([]string{vault}); handled {
+		t.Error("a start directory was handled as a command")
+	}
+}
+
+func TestCLINewCatListSearch(t *testing.T) {
+	vault := testVault(t)
+	dir := "--dir=" + vault
+
+	code, out, _ := runTestCLI(t, "", "new", dir, "--folder", "inbox", "--content", "# Brouillon\n\nprojet", "brouillon")
+	path := filepath.Join(vault, "inbox", "brouillon.md")
+	if code != 0 || strings.TrimSpace(out) != path {
+		t.Fatalf("new printed %q (code %d)", out, code)
+	}
+	if code, _, _ := runTestCLI(t, "", "new", dir, "--folder", "inbox", "--content", "x", "brouillon"); code != 1 {
+		t.Errorf("new over an existing note exited with %d", code)
+	}
+
+	// Content is read from stdin when --content is missing
+	if code, _, _ := runTestCLI(t, "depuis stdin\n", "new", dir, "piped"); code != 0 {
+		t.Fatal("new from stdin failed")
+	}
+	if data, _ := os.ReadFile(filepath.Join(vault, "piped.md")); !strings.HasSuffix(string(data), "\ndepuis stdin\n") {
+		t.Errorf("piped note = %q", data)
+	}
+
+	if _, out, _ := runTestCLI(t, "", "cat", dir, "--raw", "brouillon"); out != "# Brouillon\n\nprojet\n" {
+		t.Errorf("cat --raw = %q", out)
+	}
+	if _, out, _ := runTestCLI(t, "", "cat", dir, "--width", "40", "projet"); !strings.Contains(out, "Projet") {
+		t.Errorf("cat = %q", out)
+	}
+
+	_, out, _ = runTestCLI(t, "", "ls", dir, "--json")
+	var notes []noteInfo
+	if err := json.Unmarshal([]byte(out), &notes); err != nil || len(notes) != 4 {
+		t.Fatalf("ls --json = %s (%v)", out, err)
+	}
+	if _, out, _ := runTestCLI(t, "", "ls", dir, "notes"); strings.TrimSpace(out) != filepath.Join("notes", "idées.md") {
+		t.Errorf("ls notes = %q", out)
+	}
+
+	_, out, _ = runTestCLI(t, "", "search", dir, "projet")
+	want := "inbox/brouillon.md:3: projet\nnotes/idées.md:3: Une idée de projet.\nprojet.md:1: # Projet\n"
+	if out != filepath.FromSlash(want) {
+		t.Errorf("search = %q, want %q", out, want)
+	}
+}
+
+func TestCLIExportImport(t *testing.T) {
+	vault := testVault(t)
+	dir := "--dir=" + vault
+
+	out := t.TempDir()
+	code, stdout, _ := runTestCLI(t, "", "export", dir, "--json", "html", out)
+	var res struct{ Notes int }
+	if code != 0 || json.Unmarshal([]byte(stdout), &res) != nil || res.Notes != 2 {
+		t.Fatalf("export printed %q (code %d)", stdout, code)
+	}
+
+	dest := t.TempDir()
+	code, stdout, _ = runTestCLI(t, "", "import", dir, "--dry-run", "enex", filepath.Join("testdata", "sample.enex"), dest)
+	if code != 0 || !strings.Contains(stdout, "Notes importées : 2") {
+		t.Errorf("import --dry-run printed %q (code %d)", stdout, code)
+	}
+	if entries, _ := os.ReadDir(dest); len(entries) != 0 {
+		t.Errorf("dry run wrote %d files", len(entries))
+	}
+}
+
+func TestCLIRefusesEncryptedNotes(t *testing.T) {
+	vault := testVault(t)
+	defer sessionKeys.lock()
+	sessionKeys.set("secret")
+	if err := writeEncryptedNote(filepath.Join(vault, "coffre.md.enc"), []byte("# Coffre\n\nmot de passe\n")); err != nil {
+		t.Fatal(err)
+	}
+	sessionKeys.lock()
+
+	for _, args := range [][]string{
+		{"cat", "coffre.md.enc"},
+		{"cat", "--raw", "coffre.md.enc"},
+		{"view", "coffre.md.enc"},
+	} {
+		code, out, stderr := runTestCLI(t, "", append(args, "--dir="+vault)...)
+		if code == 0 || out != "" || !strings.Contains(stderr, "chiffrée") {
+			t.Errorf("%q: exit %d, stdout %q, stderr %q", args, code, out, stderr)
+		}
+	}
+}
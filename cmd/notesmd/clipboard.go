@@ -1,29 +1,163 @@
 package main
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 type clipboardCopiedMsg struct {
 	message string
 }
 
-func copyFilePath(path string) clipboardCopiedMsg {
-	return clipboardCopiedMsg{message: "Path: " + path}
+// clipboardOSC52Msg hands an OSC 52 sequence to the model, which writes it
+// through the renderer with the next frames
+type clipboardOSC52Msg struct {
+	seq     string
+	message string
 }
 
-func copyFileContent(path string) clipboardCopiedMsg {
-	content, err := readFileContent(path)
-	if err != nil {
-		return clipboardCopiedMsg{message: "Error: " + err.Error()}
+// osc52SentMsg ends the frames carrying seq
+type osc52SentMsg struct {
+	seq string
+}
+
+// osc52Hold is how long a sequence stays in the frames, enough for the
+// renderer to flush one of them
+const osc52Hold = 200 * time.Millisecond
+
+var errNoClipboardTool = errors.New("aucun outil de presse-papiers")
+
+// clipboardTool is a command line program writing its stdin to the system
+// clipboard
+type clipboardTool struct {
+	name string
+	args []string
+	env  string // variable that must be set for the tool to reach a display
+}
+
+var clipboardTools = []clipboardTool{
+	{name: "wl-copy", env: "WAYLAND_DISPLAY"},
+	{name: "xclip", args: []string{"-selection", "clipboard"}, env: "DISPLAY"},
+	{name: "xsel", args: []string{"--clipboard", "--input"}, env: "DISPLAY"},
+	{name: "pbcopy"},
+	{name: "clip.exe"},
+}
+
+// writeClipboard copies text with the first clipboard tool that works and
+// returns its name, errNoClipboardTool when none does
+func writeClipboard(text string) (string, error) {
+	for _, tool := range clipboardTools {
+		if tool.name == "pbcopy" && runtime.GOOS != "darwin" {
+			continue
+		}
+		if tool.env != "" && os.Getenv(tool.env) == "" {
+			continue
+		}
+		if _, err := exec.LookPath(tool.name); err != nil {
+			continue
+		}
+		cmd := exec.Command(tool.name, tool.args...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err == nil {
+			return tool.name, nil
+		}
+	}
+
+	return "", errNoClipboardTool
+}
+
+// osc52Sequence returns the escape sequence setting the clipboard to text.
+// Inside tmux it is wrapped in a passthrough sequence.
+func osc52Sequence(text string, tmux bool) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+	if tmux {
+		seq = "\x1bPtmux;\x1b" + seq + "\x1b\\"
 	}
-	return clipboardCopiedMsg{message: "Content copied (" + string(rune(len(content))) + " bytes)"}
+	return seq
 }
 
-func readFileContent(path string) (string, error) {
-	data, err := os.ReadFile(path)
+// copyToClipboard copies text in the background and reports it as label.
+// Without a clipboard tool it falls back to the OSC 52 escape sequence, which
+// the terminal forwards to the local clipboard even over SSH.
+func copyToClipboard(text, label string) tea.Cmd {
+	return func() tea.Msg {
+		via, err := writeClipboard(text)
+		if err == errNoClipboardTool {
+			return clipboardOSC52Msg{
+				seq:     osc52Sequence(text, os.Getenv("TMUX") != ""),
+				message: fmt.Sprintf("✓ %s copié (OSC 52)", label),
+			}
+		}
+		if err != nil {
+			return clipboardCopiedMsg{message: "Erreur: " + err.Error()}
+		}
+		return clipboardCopiedMsg{message: fmt.Sprintf("✓ %s copié (%s)", label, via)}
+	}
+}
+
+// copyFormat is a way of copying the selected note
+type copyFormat struct {
+	label    string
+	dirs     bool // also available on folders
+	text     func(path, rootDir string) (string, error)
+	describe func(text string) string
+}
+
+var (
+	copyPath = copyFormat{label: "Chemin", dirs: true, text: func(path, _ string) (string, error) {
+		return path, nil
+	}}
+	copyRelativePath = copyFormat{label: "Chemin relatif", dirs: true, text: relativeNotePath}
+	copyWikiLink     = copyFormat{label: "Lien wiki", text: func(path, _ string) (string, error) {
+		return "[[" + noteLinkName(strings.TrimSuffix(path, encryptedExt)) + "]]", nil
+	}}
+	copyContent = copyFormat{label: "Contenu", text: func(path, _ string) (string, error) {
+		data, err := readNote(path)
+		return string(data), err
+	}, describe: byteCount}
+	copyRenderedText = copyFormat{label: "Texte rendu", text: func(path, rootDir string) (string, error) {
+		return exportNoteText(path, rootDir, filepath.Dir(path))
+	}, describe: byteCount}
+)
+
+// copyFormats lists the formats of the copy menu, in order
+var copyFormats = []copyFormat{copyPath, copyRelativePath, copyWikiLink, copyContent, copyRenderedText}
+
+func byteCount(text string) string {
+	return fmt.Sprintf("%d octets", len(text))
+}
+
+// relativeNotePath returns path relative to the vault root
+func relativeNotePath(path, rootDir string) (string, error) {
+	rel, err := filepath.Rel(rootDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path, nil
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// copyNote copies path to the clipboard in format f
+func copyNote(path, rootDir string, f copyFormat) tea.Cmd {
+	text, err := f.text(path, rootDir)
 	if err != nil {
-		return "", err
+		return func() tea.Msg {
+			return clipboardCopiedMsg{message: "Erreur: " + err.Error()}
+		}
+	}
+	label := f.label
+	if f.describe != nil {
+		label += " (" + f.describe(text) + ")"
+	} else {
+		label += " " + text
 	}
-	return string(data), nil
+	return copyToClipboard(text, label)
 }
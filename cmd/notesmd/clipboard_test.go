@@ -0,0 +1,79 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOSC52Sequence(t *testing.T) {
+	if got, want := osc52Sequence("hé", false), "\x1b]52;c;aMOp\x07"; got != want {
+		t.Errorf("osc52Sequence = %q, want %q", got, want)
+	}
+	if got, want := osc52Sequence("hé", true), "\x1bPtmux;\x1b\x1b]52;c;aMOp\x07\x1b\\"; got != want {
+		t.Errorf("osc52Sequence in tmux = %q, want %q", got, want)
+	}
+}
+
+func TestCopyFormats(t *testing.T) {
+	root := "vault"
+	path := filepath.Join(root, "Projets", "Plan.md")
+	for _, tt := range []struct {
+		label, want string
+	}{
+		{"Chemin relatif", "Projets/Plan.md"},
+		{"Lien wiki", "[[Plan]]"},
+	} {
+		for _, f := range copyFormats {
+			if f.label != tt.label {
+				continue
+			}
+			got, err := f.text(path, root)
+			if err != nil || got != tt.want {
+				t.Errorf("%s = %q, %v, want %q", tt.label, got, err, tt.want)
+			}
+		}
+	}
+}
+
+func TestOSC52GoesThroughView(t *testing.T) {
+	m := initialModel(t.TempDir(), DefaultConfig(), &SessionState{})
+	seq := osc52Sequence("texte", false)
+
+	updated, _ := m.Update(clipboardOSC52Msg{seq: seq, message: "✓ copié"})
+	m = updated.(model)
+	if !strings.HasPrefix(m.View(), seq) {
+		t.Fatal("pending sequence not in the frame")
+	}
+
+	// A clear meant for an older sequence keeps the current one
+	updated, _ = m.Update(osc52SentMsg{seq: "ancienne"})
+	if m = updated.(model); m.osc52 != seq {
+		t.Fatal("sequence cleared by an older copy")
+	}
+	updated, _ = m.Update(osc52SentMsg{seq: seq})
+	if m = updated.(model); strings.Contains(m.View(), "\x1b]52") {
+		t.Error("sequence still written after it was sent")
+	}
+}
+
+func TestWikiLinkToEncryptedNote(t *testing.T) {
+	root := t.TempDir()
+	secret := filepath.Join(root, "privé", "Coffre.md.enc")
+	writeTestFile(t, secret, "")
+
+	link, _ := copyWikiLink.text(secret, root)
+	if link != "[[Coffre]]" {
+		t.Fatalf("wiki link = %q", link)
+	}
+	if got := findNoteByName(wikiLinkTarget("Coffre"), root); got != secret {
+		t.Errorf("findNoteByName = %q, want the encrypted note", got)
+	}
+
+	// A plain note of the same name wins
+	plain := filepath.Join(root, "Coffre.md")
+	writeTestFile(t, plain, "# Coffre")
+	if got := findNoteByName("Coffre", root); got != plain {
+		t.Errorf("findNoteByName = %q, want the plain note", got)
+	}
+}
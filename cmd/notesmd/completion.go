@@ -0,0 +1,263 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	fuzzy "github.com/sahilm/fuzzy"
+)
+
+// Maximum number of suggestions shown in the completion popup
+const maxCompletions = 6
+
+// completionItem is a single wiki-link suggestion
+type completionItem struct {
+	label  string // text shown in the popup
+	detail string // secondary text (relative path, heading level)
+	insert string // canonical link text inserted between [[ and ]]
+}
+
+// linkCompleter suggests notes and headings while typing [[ in an editor
+type linkCompleter struct {
+	rootDir string
+	notes   []string // absolute paths of the vault notes
+
+	active    bool
+	row       int // line of the link being typed
+	start     int // column right after [[
+	query     string
+	items     []completionItem
+	selected  int
+	dismissed bool
+
+	headingCache map[string][]noteHeading
+}
+
+// linkContext finds an unclosed [[ before col and returns the text typed after it
+func linkContext(line []rune, col int) (start int, query string, ok bool) {
+	if col > len(line) {
+		col = len(line)
+	}
+	for i := col - 1; i >= 1; i-- {
+		switch line[i] {
+		case ']', '|', '\n':
+			return 0, "", false
+		case '[':
+			if line[i-1] == '[' {
+				return i + 1, string(line[i+1 : col]), true
+			}
+		}
+	}
+	return 0, "", false
+}
+
+// noteLinkName returns the canonical wiki-link name of a note path
+func noteLinkName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// update refreshes the suggestions for the cursor position
+func (c *linkCompleter) update(line []rune, row, col int, buffer func() string) {
+	start, query, ok := linkContext(line, col)
+	if !ok || len(c.notes) == 0 && !strings.Contains(query, "#") {
+		c.active = false
+		c.dismissed = false
+		return
+	}
+
+	// Keep the popup closed after Esc until the user leaves the link
+	if c.dismissed && c.row == row && c.start == start {
+		c.active = false
+		return
+	}
+
+	if !c.active || c.row != row || c.start != start || c.query != query {
+		c.selected = 0
+	}
+	c.active = true
+	c.dismissed = false
+	c.row = row
+	c.start = start
+	c.query = query
+
+	if note, heading, found := strings.Cut(query, "#"); found {
+		c.items = c.headingItems(note, heading, buffer)
+	} else {
+		c.items = c.noteItems(query)
+	}
+
+	if len(c.items) == 0 {
+		c.active = false
+	}
+	if c.selected >= len(c.items) {
+		c.selected = 0
+	}
+}
+
+// noteItems fuzzy-matches vault notes against the query
+func (c *linkCompleter) noteItems(query string) []completionItem {
+	candidates := make([]string, len(c.notes))
+	for i, path := range c.notes {
+		rel, err := filepath.Rel(c.rootDir, path)
+		if err != nil {
+			rel = filepath.Base(path)
+		}
+		candidates[i] = strings.TrimSuffix(rel, filepath.Ext(rel))
+	}
+
+	var items []completionItem
+	add := func(i int) {
+		items = append(items, completionItem{
+			label:  noteLinkName(c.notes[i]),
+			detail: candidates[i],
+			insert: noteLinkName(c.notes[i]),
+		})
+	}
+
+	if query == "" {
+		for i := range c.notes {
+			if len(items) >= maxCompletions {
+				break
+			}
+			add(i)
+		}
+		return items
+	}
+
+	for _, match := range fuzzy.Find(query, candidates) {
+		if len(items) >= maxCompletions {
+			break
+		}
+		add(match.Index)
+	}
+	return items
+}
+
+// headingItems fuzzy-matches headings of a note (or the buffer when note is empty)
+func (c *linkCompleter) headingItems(note, query string, buffer func() string) []completionItem {
+	var headings []noteHeading
+	if note == "" {
+		headings = parseHeadings(buffer())
+	} else {
+		note, headings = c.noteHeadings(note)
+	}
+
+	texts := make([]string, len(headings))
+	for i, h := range headings {
+		texts[i] = h.text
+	}
+
+	var indexes []int
+	if query == "" {
+		for i := range headings {
+			indexes = append(indexes, i)
+		}
+	} else {
+		for _, match := range fuzzy.Find(query, texts) {
+			indexes = append(indexes, match.Index)
+		}
+	}
+
+	var items []completionItem
+	for _, i := range indexes {
+		if len(items) >= maxCompletions {
+			break
+		}
+		h := headings[i]
+		items = append(items, completionItem{
+			label:  h.text,
+			detail: strings.Repeat("#", h.level),
+			insert: note + "#" + h.text,
+		})
+	}
+	return items
+}
+
+// noteHeadings resolves a (possibly partial) note name and returns its
+// canonical name with its cached headings
+func (c *linkCompleter) noteHeadings(name string) (string, []noteHeading) {
+	path := c.resolveNote(name)
+	if path == "" {
+		return name, nil
+	}
+
+	if c.headingCache == nil {
+		c.headingCache = make(map[string][]noteHeading)
+	}
+	headings, ok := c.headingCache[path]
+	if !ok {
+		headings = parseHeadings(loadMarkdownRaw(path))
+		c.headingCache[path] = headings
+	}
+	return noteLinkName(path), headings
+}
+
+// resolveNote finds a note by exact name, falling back to the best fuzzy match
+func (c *linkCompleter) resolveNote(name string) string {
+	names := make([]string, len(c.notes))
+	for i, path := range c.notes {
+		if strings.EqualFold(noteLinkName(path), name) {
+			return path
+		}
+		names[i] = noteLinkName(path)
+	}
+
+	if matches := fuzzy.Find(name, names); len(matches) > 0 {
+		return c.notes[matches[0].Index]
+	}
+	return ""
+}
+
+func (c *linkCompleter) moveSelection(delta int) {
+	if len(c.items) == 0 {
+		return
+	}
+	c.selected = (c.selected + delta + len(c.items)) % len(c.items)
+}
+
+func (c *linkCompleter) dismiss() {
+	c.active = false
+	c.dismissed = true
+}
+
+// selectedItem returns the highlighted suggestion
+func (c linkCompleter) selectedItem() (completionItem, bool) {
+	if !c.active || c.selected >= len(c.items) {
+		return completionItem{}, false
+	}
+	return c.items[c.selected], true
+}
+
+// View renders the suggestion popup
+func (c linkCompleter) View(width int) string {
+	if !c.active {
+		return ""
+	}
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("0")).
+		Background(lipgloss.Color("81")).
+		Bold(true)
+	detailStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+	var rows []string
+	for i, item := range c.items {
+		label := "🔗 " + item.label
+		if item.detail != "" && item.detail != item.label {
+			label += "  " + detailStyle.Render(item.detail)
+		}
+		if i == c.selected {
+			label = selectedStyle.Render("🔗 " + item.label)
+		}
+		rows = append(rows, label)
+	}
+	rows = append(rows, helpStyle.Render("↑/↓: choisir • Enter/Tab: insérer • Esc: fermer"))
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("81")).
+		Padding(0, 1).
+		MaxWidth(width).
+		Render(strings.Join(rows, "\n"))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestLinkContext(t *testing.T) {
+	tests := []struct {
+		line  string
+		col   int
+		ok    bool
+		start int
+		query string
+	}{
+		{line: "voir [[", col: 7, ok: true, start: 7, query: ""},
+		{line: "voir [[pro", col: 10, ok: true, start: 7, query: "pro"},
+		{line: "voir [[projet#Intro", col: 19, ok: true, start: 7, query: "projet#Intro"},
+		{line: "[[#Titre", col: 8, ok: true, start: 2, query: "#Titre"},
+		{line: "[[pro]] et", col: 10, ok: false},
+		{line: "[[pro|alias", col: 11, ok: false},
+		{line: "[pas un lien", col: 12, ok: false},
+		{line: "[[été", col: 99, ok: true, start: 2, query: "été"},
+		{line: "[[projet]]", col: 4, ok: true, start: 2, query: "pr"},
+	}
+	for _, tt := range tests {
+		start, query, ok := linkContext([]rune(tt.line), tt.col)
+		if ok != tt.ok || ok && (start != tt.start || query != tt.query) {
+			t.Errorf("linkContext(%q, %d) = %d, %q, %v", tt.line, tt.col, start, query, ok)
+		}
+	}
+}
+
+func TestAcceptCompletion(t *testing.T) {
+	root := t.TempDir()
+	projet := filepath.Join(root, "projets", "Projet.md")
+	writeTestFile(t, projet, "# Projet\n\n## Étapes\n")
+	writeTestFile(t, filepath.Join(root, "autre.md"), "# Autre")
+
+	tests := []struct {
+		name, value string
+		col         int
+		want        string
+	}{
+		{"note", "voir [[proj", 11, "voir [[Projet]]"},
+		{"closing brackets kept", "voir [[pr]] fin", 9, "voir [[Projet]] fin"},
+		{"heading", "voir [[Projet#tap", 17, "voir [[Projet#Étapes]]"},
+		{"heading of the buffer", "# Haut\n[[#ha", 5, "# Haut\n[[#Haut]]"},
+	}
+	for _, tt := range tests {
+		e := newMarkdownEditor(80, 10)
+		e.SetLinkTargets(root, []string{projet, filepath.Join(root, "autre.md")})
+		e.SetValue(tt.value)
+		e.Focus()
+		e.buf.SetCursor(e.buf.LineCount()-1, tt.col)
+		e.refreshCompletion()
+		if !e.Completing() {
+			t.Errorf("%s: no completion for %q", tt.name, tt.value)
+			continue
+		}
+		e, _ = e.Update(tea.KeyMsg{Type: tea.KeyTab})
+		if got := e.Value(); got != tt.want {
+			t.Errorf("%s: completed to %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
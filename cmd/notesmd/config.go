@@ -1,23 +1,38 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
 )
 
 type Config struct {
-	Editor     string       `json:"editor"`
-	Theme      int          `json:"theme"`
-	DefaultDir string       `json:"default_dir"`
-	Filters    FilterConfig `json:"filters"`
-	Search     SearchConfig `json:"search"`
+	Version       int                 `json:"version"` // schema version, see configMigrations
+	Editor        string              `json:"editor"`
+	Theme         int                 `json:"theme"`
+	MarkdownTheme string              `json:"markdown_theme"` // Glamour style name or JSON path
+	DefaultDir    string              `json:"default_dir"`
+	Filters       FilterConfig        `json:"filters"`
+	Search        SearchConfig        `json:"search"`
+	Capture       CaptureConfig       `json:"capture"`
+	Notes         NotesConfig         `json:"notes"`
+	Git           GitConfig           `json:"git"`
+	Encryption    EncryptConfig       `json:"encryption"`
+	Keys          map[string][]string `json:"keys"` // action → key sequences, overriding the defaults
+	Vaults        []VaultConfig       `json:"vaults"`
 }
 
 type FilterConfig struct {
-	MdOnly     bool `json:"md_only"`
-	ShowHidden bool `json:"show_hidden"`
-	SortMode   int  `json:"sort_mode"`
+	MdOnly     bool     `json:"md_only"`
+	ShowHidden bool     `json:"show_hidden"`
+	SortMode   int      `json:"sort_mode"`
+	Ignore     []string `json:"ignore"` // gitignore patterns of files hidden from the list, the search and link resolution
 }
 
 type SearchConfig struct {
@@ -26,50 +41,221 @@ type SearchConfig struct {
 	MaxRecentFiles   int  `json:"max_recent_files"`
 }
 
+type CaptureConfig struct {
+	Inbox       string `json:"inbox"`        // note receiving captures, relative to the vault
+	Daily       bool   `json:"daily"`        // capture into today's daily note instead
+	DailyFolder string `json:"daily_folder"` // folder of daily notes, relative to the vault
+	DailyFormat string `json:"daily_format"` // Go time layout of daily note names
+	Heading     string `json:"heading"`      // heading to append under, empty for end of note
+	TimeFormat  string `json:"time_format"`  // Go time layout of the entry timestamp
+}
+
+type NotesConfig struct {
+	NewNoteLocation  string `json:"new_note_location"` // "current", "root" or "folder"
+	NewNoteFolder    string `json:"new_note_folder"`   // folder used by "folder", relative to the vault
+	AttachmentFolder string `json:"attachment_folder"` // relative to the vault, "./" for the note folder
+	TemplatesFolder  string `json:"templates_folder"`  // relative to the vault
+}
+
+type GitConfig struct {
+	AutoCommit      bool `json:"auto_commit"`       // commit the vault after saves
+	AutoCommitDelay int  `json:"auto_commit_delay"` // seconds without saves before committing
+}
+
+// autoCommitDelay returns the auto-commit debounce, 30s by default
+func (c GitConfig) autoCommitDelay() time.Duration {
+	if c.AutoCommitDelay <= 0 {
+		return 30 * time.Second
+	}
+	return time.Duration(c.AutoCommitDelay) * time.Second
+}
+
+type EncryptConfig struct {
+	PassphraseTimeout int `json:"passphrase_timeout"` // minutes an unused passphrase stays cached
+}
+
+// passphraseTimeout returns how long the passphrase stays cached, 10 minutes
+// by default
+func (c EncryptConfig) passphraseTimeout() time.Duration {
+	if c.PassphraseTimeout <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(c.PassphraseTimeout) * time.Minute
+}
+
 type SessionState struct {
+	Version       int      `json:"version"` // schema version, see stateMigrations
 	LastDirectory string   `json:"last_directory"`
 	LastTheme     int      `json:"last_theme"`
 	RecentFiles   []string `json:"recent_files"`
 	Bookmarks     []string `json:"bookmarks"`
-}
 
-func getConfigDir() string {
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".config", "notesmd")
+	LastVault string                 `json:"last_vault"`
+	Vaults    map[string]*VaultState `json:"vaults"` // session of each named vault
 }
 
 func getConfigPath() string {
+	if configFileFlag != "" {
+		return configFileFlag
+	}
 	return filepath.Join(getConfigDir(), "config.json")
 }
 
 func getStatePath() string {
-	return filepath.Join(getConfigDir(), "state.json")
+	return filepath.Join(getStateDir(), "state.json")
 }
 
 func ensureConfigDir() error {
-	return os.MkdirAll(getConfigDir(), 0755)
+	return os.MkdirAll(filepath.Dir(getConfigPath()), 0755)
+}
+
+func ensureStateDir() error {
+	return os.MkdirAll(getStateDir(), 0755)
 }
 
+// LoadConfig reads config.json over the defaults. The config is never nil:
+// invalid settings are skipped and reported in a configError while the
+// others still apply.
 func LoadConfig() (*Config, error) {
+	config := DefaultConfig()
 	if err := ensureConfigDir(); err != nil {
-		return nil, err
+		return config, err
 	}
 
 	configPath := getConfigPath()
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return DefaultConfig(), nil
+			return config, nil
 		}
-		return nil, err
+		return config, err
 	}
 
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, err
+	if migrated, err := migrateJSON(data, configMigrations); err == nil {
+		data = migrated
+	}
+
+	// Settings missing from the file keep their default
+	if issues := decodeConfig(data, configPath, config); len(issues) > 0 {
+		return config, issues
+	}
+	return config, nil
+}
+
+// Origins of a setting of the effective configuration
+const (
+	originDefault = "défaut"
+	originGlobal  = "global"
+	originVault   = "vault"
+)
+
+// vaultConfigPath returns the configuration file of a vault
+func vaultConfigPath(rootDir string) string {
+	return filepath.Join(rootDir, vaultDataDir, "config")
+}
+
+// loadVaultConfig layers the configuration of the vault at rootDir over the
+// global one. The result merges, in order, the defaults, the global
+// config.json and <vault>/.notesmd/config, each file only overriding the
+// settings it contains. origins maps every setting, as a dotted key like
+// "filters.md_only", to the layer it comes from. Invalid settings of the
+// vault file are skipped and reported in a configError.
+func loadVaultConfig(global *Config, rootDir string) (*Config, map[string]string, error) {
+	origins := make(map[string]string)
+	for key := range flattenConfig(global) {
+		origins[key] = originDefault
+	}
+	if data, err := os.ReadFile(getConfigPath()); err == nil {
+		markOrigins(origins, data, originGlobal, decodeConfig(data, getConfigPath(), DefaultConfig()))
+	}
+
+	data, err := os.ReadFile(vaultConfigPath(rootDir))
+	if os.IsNotExist(err) {
+		return global, origins, nil
+	}
+	if err != nil {
+		return global, origins, err
+	}
+
+	// Decode the vault file over a copy of the global configuration
+	base, err := json.Marshal(global)
+	if err != nil {
+		return global, origins, err
+	}
+	config := &Config{}
+	if err := json.Unmarshal(base, config); err != nil {
+		return global, origins, err
+	}
+	if migrated, err := migrateJSON(data, configMigrations); err == nil {
+		data = migrated
+	}
+	issues := decodeVaultConfig(data, vaultConfigPath(rootDir), config)
+	markOrigins(origins, data, originVault, issues)
+	if len(issues) > 0 {
+		return config, origins, issues
+	}
+	return config, origins, nil
+}
+
+// configSetting is a setting of the effective configuration
+type configSetting struct {
+	key    string
+	value  string // JSON encoded
+	origin string
+}
+
+// effectiveSettings lists the settings of c sorted by key, with their origin
+func effectiveSettings(c *Config, origins map[string]string) []configSetting {
+	flat := flattenConfig(c)
+	settings := make([]configSetting, 0, len(flat))
+	for _, key := range sortedKeys(flat) {
+		value, _ := json.Marshal(flat[key])
+		origin := origins[key]
+		if origin == "" {
+			origin = originDefault
+		}
+		settings = append(settings, configSetting{key: key, value: string(value), origin: origin})
 	}
+	return settings
+}
 
-	return &config, nil
+// markOrigins records origin for every setting present in the JSON data
+func markOrigins(origins map[string]string, data []byte, origin string, rejected configError) {
+	var raw map[string]any
+	if json.Unmarshal(data, &raw) != nil {
+		return
+	}
+	for key := range flattenJSON("", raw, nil) {
+		if !rejected.covers(key) {
+			origins[key] = origin
+		}
+	}
+}
+
+// flattenConfig returns the settings of c by dotted key
+func flattenConfig(c *Config) map[string]any {
+	data, _ := json.Marshal(c)
+	var raw map[string]any
+	json.Unmarshal(data, &raw)
+	return flattenJSON("", raw, nil)
+}
+
+// flattenJSON flattens nested objects into dotted keys
+func flattenJSON(prefix string, value map[string]any, out map[string]any) map[string]any {
+	if out == nil {
+		out = make(map[string]any)
+	}
+	for key, v := range value {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := v.(map[string]any); ok && len(nested) > 0 {
+			flattenJSON(key, nested, out)
+			continue
+		}
+		out[key] = v
+	}
+	return out
 }
 
 func DefaultConfig() *Config {
@@ -82,9 +268,11 @@ func DefaultConfig() *Config {
 	defaultDir := filepath.Join(home, "notes")
 
 	return &Config{
-		Editor:     editor,
-		Theme:      0,
-		DefaultDir: defaultDir,
+		Version:       configVersion,
+		Editor:        editor,
+		Theme:         0,
+		MarkdownTheme: "dark",
+		DefaultDir:    defaultDir,
 		Filters: FilterConfig{
 			MdOnly:     false,
 			ShowHidden: false,
@@ -95,6 +283,22 @@ func DefaultConfig() *Config {
 			RespectGitignore: true,
 			MaxRecentFiles:   10,
 		},
+		Capture: CaptureConfig{
+			Inbox:       "Inbox.md",
+			DailyFolder: "daily",
+			DailyFormat: "2006-01-02",
+			TimeFormat:  "15:04",
+		},
+		Notes: NotesConfig{
+			NewNoteLocation: "current",
+			TemplatesFolder: "templates",
+		},
+		Git: GitConfig{
+			AutoCommitDelay: 30,
+		},
+		Encryption: EncryptConfig{
+			PassphraseTimeout: 10,
+		},
 	}
 }
 
@@ -102,6 +306,7 @@ func (c *Config) Save() error {
 	if err := ensureConfigDir(); err != nil {
 		return err
 	}
+	c.Version = configVersion
 
 	data, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
@@ -111,8 +316,147 @@ func (c *Config) Save() error {
 	return os.WriteFile(getConfigPath(), data, 0644)
 }
 
+// SaveChanges writes to config.json the settings of c that differ from
+// loaded, the configuration read from it. The other keys of the file, the
+// ones skipped while loading included, are kept as written.
+func (c *Config) SaveChanges(loaded *Config) error {
+	path := getConfigPath()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c.Save()
+	}
+	if err != nil {
+		return err
+	}
+	if !json.Valid(data) {
+		return fmt.Errorf("%s n'est pas du JSON valide, corrigez-le avant d'enregistrer", path)
+	}
+	if migrated, err := migrateJSON(data, configMigrations); err == nil {
+		data = migrated
+	}
+
+	before, err := configTree(loaded)
+	if err != nil {
+		return err
+	}
+	after, err := configTree(c)
+	if err != nil {
+		return err
+	}
+	for _, change := range configChanges(before, after, nil) {
+		value, err := json.Marshal(change.value)
+		if err != nil {
+			return err
+		}
+		if data, err = setJSONKey(data, change.path, value); err != nil {
+			return fmt.Errorf("%s n'est pas un objet JSON, corrigez-le avant d'enregistrer", path)
+		}
+	}
+
+	var compact, out bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return err
+	}
+	json.Indent(&out, compact.Bytes(), "", "  ")
+	return os.WriteFile(path, out.Bytes(), 0644)
+}
+
+// configTree returns c as decoded JSON
+func configTree(c *Config) (map[string]any, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var tree map[string]any
+	return tree, json.Unmarshal(data, &tree)
+}
+
+type configChange struct {
+	path  []string
+	value any
+}
+
+// configChanges lists the values of after that differ from before, by key
+// path, descending into objects
+func configChanges(before, after map[string]any, prefix []string) []configChange {
+	keys := make([]string, 0, len(after))
+	for key := range after {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var changes []configChange
+	for _, key := range keys {
+		path := append(slices.Clone(prefix), key)
+		sub, isObject := after[key].(map[string]any)
+		prev, wasObject := before[key].(map[string]any)
+		if isObject && wasObject {
+			changes = append(changes, configChanges(prev, sub, path)...)
+		} else if !reflect.DeepEqual(before[key], after[key]) {
+			changes = append(changes, configChange{path: path, value: after[key]})
+		}
+	}
+	return changes
+}
+
+// setJSONKey sets the value at path in the JSON object data, keeping the
+// other keys in their order. The result is compact.
+func setJSONKey(data []byte, path []string, value json.RawMessage) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("objet JSON attendu")
+	}
+	var keys []string
+	values := make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		if _, seen := values[key]; !seen {
+			keys = append(keys, key)
+		}
+		values[key] = raw
+	}
+
+	key := path[0]
+	if len(path) > 1 {
+		inner := values[key]
+		if !bytes.HasPrefix(bytes.TrimSpace(inner), []byte("{")) {
+			inner = json.RawMessage("{}")
+		}
+		var err error
+		if value, err = setJSONKey(inner, path[1:], value); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := values[key]; !ok {
+		keys = append(keys, key)
+	}
+	values[key] = value
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(k)
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(values[k])
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
 func LoadState() (*SessionState, error) {
-	if err := ensureConfigDir(); err != nil {
+	if err := ensureStateDir(); err != nil {
 		return nil, err
 	}
 
@@ -128,6 +472,11 @@ func LoadState() (*SessionState, error) {
 		return nil, err
 	}
 
+	data, err = migrateJSON(data, stateMigrations)
+	if err != nil {
+		return nil, err
+	}
+
 	var state SessionState
 	if err := json.Unmarshal(data, &state); err != nil {
 		return nil, err
@@ -137,9 +486,10 @@ func LoadState() (*SessionState, error) {
 }
 
 func SaveState(s *SessionState) error {
-	if err := ensureConfigDir(); err != nil {
+	if err := ensureStateDir(); err != nil {
 		return err
 	}
+	s.Version = stateVersion
 
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadVaultConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", "")
+	vault := t.TempDir()
+	writeTestFile(t, getConfigPath(), `{"theme": 2, "filters": {"md_only": true}, "capture": {"daily_folder": "journal"}}`)
+	writeTestFile(t, vaultConfigPath(vault), `{"filters": {"ignore": ["*.tmp"]}, "capture": {"daily_format": "2006/01/02"}}`)
+
+	global, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, origins, err := loadVaultConfig(global, vault)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Theme != 2 || !config.Filters.MdOnly || config.Capture.DailyFolder != "journal" {
+		t.Errorf("global settings lost: %+v", config)
+	}
+	if config.Capture.DailyFormat != "2006/01/02" || len(config.Filters.Ignore) != 1 {
+		t.Errorf("vault settings not applied: %+v", config)
+	}
+	if config.Capture.TimeFormat != "15:04" {
+		t.Errorf("default time_format lost: %q", config.Capture.TimeFormat)
+	}
+	if global.Capture.DailyFormat != "2006-01-02" {
+		t.Errorf("vault settings leaked into the global config")
+	}
+
+	for key, want := range map[string]string{
+		"theme":                originGlobal,
+		"capture.daily_format": originVault,
+		"filters.ignore":       originVault,
+		"capture.time_format":  originDefault,
+	} {
+		if origins[key] != want {
+			t.Errorf("origin of %s = %q, want %q", key, origins[key], want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+// Files follow the XDG base directory specification: settings in
+// $XDG_CONFIG_HOME, the session in $XDG_STATE_HOME and data that can be
+// rebuilt in $XDG_CACHE_HOME.
+
+// Name of the NotesMD folder in each base directory
+const appDirName = "notesmd"
+
+// Schema versions written in config.json and state.json
+const (
+	configVersion = 1
+	stateVersion  = 1
+)
+
+// configFileFlag is the configuration file given with --config
+var configFileFlag string
+
+// xdgDir returns the NotesMD folder of a base directory, fallback being
+// relative to the home directory. Relative values are ignored, as the
+// specification requires.
+func xdgDir(env, fallback string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, appDirName)
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, filepath.FromSlash(fallback), appDirName)
+}
+
+func getConfigDir() string {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+func getStateDir() string {
+	return xdgDir("XDG_STATE_HOME", ".local/state")
+}
+
+func getCacheDir() string {
+	return xdgDir("XDG_CACHE_HOME", ".cache")
+}
+
+// legacyConfigDir is where every file lived before the XDG layout
+func legacyConfigDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", appDirName)
+}
+
+// takeFlag removes "--name value" or "--name=value" from args
+func takeFlag(args []string, name string) (value string, rest []string, err error) {
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--"+name || arg == "-"+name:
+			if i+1 >= len(args) {
+				return "", nil, fmt.Errorf("--%s attend une valeur", name)
+			}
+			i++
+			value = args[i]
+		case len(arg) > len(name)+3 && arg[:len(name)+3] == "--"+name+"=":
+			value = arg[len(name)+3:]
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return value, rest, nil
+}
+
+// migrateLegacyFiles moves the files of older versions to their XDG place:
+// config.json out of ~/.config/notesmd when $XDG_CONFIG_HOME points
+// elsewhere, state.json out of the config folder. It returns what it moved.
+func migrateLegacyFiles() ([]string, error) {
+	var moved []string
+	move := func(src, dst string) error {
+		if _, err := os.Stat(dst); err == nil {
+			return nil
+		}
+		if _, err := os.Stat(src); err != nil {
+			return nil
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := moveFile(src, dst); err != nil {
+			return err
+		}
+		moved = append(moved, src+" → "+dst)
+		return nil
+	}
+
+	legacy := legacyConfigDir()
+	for _, dir := range []string{getConfigDir(), legacy} {
+		if err := move(filepath.Join(dir, "state.json"), getStatePath()); err != nil {
+			return moved, err
+		}
+	}
+	if legacy != getConfigDir() {
+		if configFileFlag == "" {
+			if err := move(filepath.Join(legacy, "config.json"), getConfigPath()); err != nil {
+				return moved, err
+			}
+		}
+		// Only removed once empty
+		os.Remove(legacy)
+	}
+	return moved, nil
+}
+
+// Migrations of config.json and state.json: entry i upgrades a file of
+// version i to version i+1. Files without a version are version 0.
+var (
+	configMigrations = []func(map[string]any){
+		func(map[string]any) {}, // 1: version field introduced
+	}
+	stateMigrations = []func(map[string]any){
+		func(map[string]any) {}, // 1: state moved to $XDG_STATE_HOME
+	}
+)
+
+// migrateJSON upgrades data to the current schema version with migrations
+func migrateJSON(data []byte, migrations []func(map[string]any)) ([]byte, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	version := 0
+	if v, ok := raw["version"].(float64); ok {
+		version = int(v)
+	}
+	if version >= len(migrations) {
+		return data, nil
+	}
+	var before map[string]any
+	json.Unmarshal(data, &before)
+	for _, migrate := range migrations[max(version, 0):] {
+		migrate(raw)
+	}
+	if reflect.DeepEqual(raw, before) {
+		// Keep the file as written, so errors point to its real lines
+		return data, nil
+	}
+	raw["version"] = len(migrations)
+	return json.Marshal(raw)
+}
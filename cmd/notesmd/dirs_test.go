@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestXDGDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_STATE_HOME", "relatif/ignoré")
+	t.Setenv("XDG_CACHE_HOME", "/tmp/cache")
+
+	for got, want := range map[string]string{
+		getConfigPath(): filepath.Join(home, ".config", "notesmd", "config.json"),
+		getStatePath():  filepath.Join(home, ".local", "state", "notesmd", "state.json"),
+		getCacheDir():   filepath.Join("/tmp/cache", "notesmd"),
+	} {
+		if got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+
+	configFileFlag = "/etc/notesmd.json"
+	defer func() { configFileFlag = "" }()
+	if got := getConfigPath(); got != configFileFlag {
+		t.Errorf("--config ignored: %q", got)
+	}
+}
+
+func TestMigrateLegacyFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg-config"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "xdg-state"))
+	legacy := filepath.Join(home, ".config", "notesmd")
+	writeTestFile(t, filepath.Join(legacy, "config.json"), `{"theme": 3}`)
+	writeTestFile(t, filepath.Join(legacy, "state.json"), `{"last_theme": 2, "recent_files": ["/a.md"]}`)
+
+	moved, err := migrateLegacyFiles()
+	if err != nil || len(moved) != 2 {
+		t.Fatalf("moved %v, err %v", moved, err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Error("empty legacy folder kept")
+	}
+
+	config, err := LoadConfig()
+	if err != nil || config.Theme != 3 || config.Version != configVersion {
+		t.Errorf("migrated config = %+v, %v", config, err)
+	}
+	state, err := LoadState()
+	if err != nil || state.LastTheme != 2 || len(state.RecentFiles) != 1 {
+		t.Errorf("migrated state = %+v, %v", state, err)
+	}
+
+	if moved, _ := migrateLegacyFiles(); len(moved) != 0 {
+		t.Errorf("second run moved %v", moved)
+	}
+}
+
+func TestSchemaVersions(t *testing.T) {
+	if len(configMigrations) != configVersion || len(stateMigrations) != stateVersion {
+		t.Fatal("schema versions and migration lists disagree")
+	}
+	// Migrations changing nothing keep the file as written
+	original := []byte("{\n  \"theme\": 1\n}")
+	if data, err := migrateJSON(original, configMigrations); err != nil || string(data) != string(original) {
+		t.Errorf("migrateJSON = %s, %v", data, err)
+	}
+
+	rename := []func(map[string]any){
+		func(raw map[string]any) {
+			raw["markdown_theme"] = raw["glamour_style"]
+			delete(raw, "glamour_style")
+		},
+	}
+	data, err := migrateJSON([]byte(`{"glamour_style": "dark"}`), rename)
+	if err != nil || string(data) != `{"markdown_theme":"dark","version":1}` {
+		t.Errorf("migrateJSON = %s, %v", data, err)
+	}
+	future := []byte(`{"version": 99}`)
+	if data, _ := migrateJSON(future, configMigrations); string(data) != string(future) {
+		t.Errorf("newer file rewritten: %s", data)
+	}
+}
+
+func TestHeadingCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	note := filepath.Join(root, "a.md")
+	writeTestFile(t, note, "# Titre\n## Partie\n")
+
+	if got := buildHeadingIndex([]string{note}, root); len(got) != 2 {
+		t.Fatalf("index = %v", got)
+	}
+	if c := loadHeadingCache(root); len(c.Notes[note].Headings) != 2 {
+		t.Fatalf("headings not cached: %+v", c.Notes)
+	}
+
+	writeTestFile(t, note, "# Titre\n")
+	os.Chtimes(note, time.Now(), time.Now().Add(time.Second))
+	if got := buildHeadingIndex([]string{note}, root); len(got) != 1 {
+		t.Errorf("stale headings after an edit: %v", got)
+	}
+}
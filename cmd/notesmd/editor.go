@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"os/exec"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -11,15 +12,24 @@ import (
 type editorDoneMsg struct{}
 type editorErrorMsg string
 
+// editorCommand builds the command opening path in editor, the "editor"
+// setting, falling back to $EDITOR then nvim. The setting may hold
+// arguments, like "code --wait".
+func editorCommand(editor, path string) *exec.Cmd {
+	fields := strings.Fields(editor)
+	if len(fields) == 0 {
+		fields = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(fields) == 0 {
+		fields = []string{"nvim"}
+	}
+	return exec.Command(fields[0], append(fields[1:], path)...)
+}
+
 // openInEditor opens a file in the external editor
-func openInEditor(path string) tea.Cmd {
+func openInEditor(editor, path string) tea.Cmd {
 	return func() tea.Msg {
-		editor := os.Getenv("EDITOR")
-		if editor == "" {
-			editor = "nvim"
-		}
-
-		cmd := exec.Command(editor, path)
+		cmd := editorCommand(editor, path)
 		cmd.Stdin = os.Stdin
 		cmd.Stdout = os.Stdout
 		cmd.Stderr = os.Stderr
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// textBuffer is a line-based text buffer used by the inline editor.
+// Unlike bubbles' textarea it has no character or line limit, and edits
+// only touch the affected lines so multi-megabyte notes stay responsive.
+type textBuffer struct {
+	lines [][]rune
+	row   int
+	col   int
+	crlf  bool // restore Windows line endings on save
+}
+
+// newTextBuffer creates a buffer holding s with the cursor at the start
+func newTextBuffer(s string) textBuffer {
+	b := textBuffer{crlf: strings.Contains(s, "\r\n")}
+	if b.crlf {
+		s = strings.ReplaceAll(s, "\r\n", "\n")
+	}
+
+	parts := strings.Split(s, "\n")
+	b.lines = make([][]rune, len(parts))
+	for i, part := range parts {
+		b.lines[i] = []rune(part)
+	}
+	return b
+}
+
+// String returns the buffer content
+func (b textBuffer) String() string {
+	sep := "\n"
+	if b.crlf {
+		sep = "\r\n"
+	}
+
+	size := 0
+	for _, line := range b.lines {
+		size += len(line) + len(sep)
+	}
+
+	var sb strings.Builder
+	sb.Grow(size)
+	for i, line := range b.lines {
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteString(string(line))
+	}
+	return sb.String()
+}
+
+// LineCount returns the number of lines
+func (b textBuffer) LineCount() int {
+	return len(b.lines)
+}
+
+// Line returns the runes of line i, or nil if out of range
+func (b textBuffer) Line(i int) []rune {
+	if i < 0 || i >= len(b.lines) {
+		return nil
+	}
+	return b.lines[i]
+}
+
+// SetCursor moves the cursor, clamping it to the buffer
+func (b *textBuffer) SetCursor(row, col int) {
+	b.row = min(max(row, 0), len(b.lines)-1)
+	b.col = min(max(col, 0), len(b.lines[b.row]))
+}
+
+// Insert inserts s at the cursor and moves the cursor after it
+func (b *textBuffer) Insert(s string) {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	parts := strings.Split(s, "\n")
+
+	line := b.lines[b.row]
+	tail := slices.Clone(line[b.col:])
+	head := line[:b.col]
+
+	if len(parts) == 1 {
+		inserted := []rune(parts[0])
+		b.lines[b.row] = append(append(head, inserted...), tail...)
+		b.col += len(inserted)
+		return
+	}
+
+	newLines := make([][]rune, len(parts))
+	newLines[0] = append(head, []rune(parts[0])...)
+	for i := 1; i < len(parts)-1; i++ {
+		newLines[i] = []rune(parts[i])
+	}
+	last := []rune(parts[len(parts)-1])
+	newLines[len(parts)-1] = append(last, tail...)
+
+	b.lines = slices.Replace(b.lines, b.row, b.row+1, newLines...)
+	b.row += len(parts) - 1
+	b.col = len(last)
+}
+
+// DeleteBefore removes n runes before the cursor, joining lines as needed
+func (b *textBuffer) DeleteBefore(n int) {
+	for ; n > 0; n-- {
+		if b.col > 0 {
+			b.lines[b.row] = slices.Delete(b.lines[b.row], b.col-1, b.col)
+			b.col--
+			continue
+		}
+		if b.row == 0 {
+			return
+		}
+		prev := b.lines[b.row-1]
+		b.col = len(prev)
+		b.lines[b.row-1] = append(prev, b.lines[b.row]...)
+		b.lines = slices.Delete(b.lines, b.row, b.row+1)
+		b.row--
+	}
+}
+
+// DeleteAfter removes n runes after the cursor, joining lines as needed
+func (b *textBuffer) DeleteAfter(n int) {
+	for ; n > 0; n-- {
+		line := b.lines[b.row]
+		if b.col < len(line) {
+			b.lines[b.row] = slices.Delete(line, b.col, b.col+1)
+			continue
+		}
+		if b.row == len(b.lines)-1 {
+			return
+		}
+		b.lines[b.row] = append(line, b.lines[b.row+1]...)
+		b.lines = slices.Delete(b.lines, b.row+1, b.row+2)
+	}
+}
+
+// WordLeft moves the cursor to the start of the previous word
+func (b *textBuffer) WordLeft() {
+	if b.col == 0 {
+		if b.row > 0 {
+			b.row--
+			b.col = len(b.lines[b.row])
+		}
+		return
+	}
+	line := b.lines[b.row]
+	for b.col > 0 && unicode.IsSpace(line[b.col-1]) {
+		b.col--
+	}
+	for b.col > 0 && !unicode.IsSpace(line[b.col-1]) {
+		b.col--
+	}
+}
+
+// WordRight moves the cursor to the end of the next word
+func (b *textBuffer) WordRight() {
+	line := b.lines[b.row]
+	if b.col == len(line) {
+		if b.row < len(b.lines)-1 {
+			b.row++
+			b.col = 0
+		}
+		return
+	}
+	for b.col < len(line) && unicode.IsSpace(line[b.col]) {
+		b.col++
+	}
+	for b.col < len(line) && !unicode.IsSpace(line[b.col]) {
+		b.col++
+	}
+}
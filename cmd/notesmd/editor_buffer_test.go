@@ -0,0 +1,154 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestTextBufferEdits(t *testing.T) {
+	b := newTextBuffer("un\ndeux")
+	b.SetCursor(0, 2)
+	b.Insert(" a\nb\r\nc ")
+	if got := b.String(); got != "un a\nb\nc \ndeux" || b.row != 2 || b.col != 2 {
+		t.Fatalf("Insert = %q, cursor %d:%d", got, b.row, b.col)
+	}
+
+	// Deleting at the start of a line joins it with the previous one
+	b.SetCursor(1, 0)
+	b.DeleteBefore(2)
+	if got := b.String(); got != "un b\nc \ndeux" || b.row != 0 || b.col != 3 {
+		t.Fatalf("DeleteBefore = %q, cursor %d:%d", got, b.row, b.col)
+	}
+	b.SetCursor(1, 2)
+	b.DeleteAfter(3)
+	if got := b.String(); got != "un b\nc ux" || b.row != 1 || b.col != 2 {
+		t.Fatalf("DeleteAfter = %q, cursor %d:%d", got, b.row, b.col)
+	}
+
+	// Deleting past the buffer bounds stops there
+	b.SetCursor(0, 0)
+	b.DeleteBefore(5)
+	b.SetCursor(1, 99)
+	b.DeleteAfter(5)
+	if got := b.String(); got != "un b\nc ux" {
+		t.Errorf("out of bounds deletes changed the buffer: %q", got)
+	}
+}
+
+func TestTextBufferWords(t *testing.T) {
+	b := newTextBuffer("un  deux trois\nfin")
+	b.SetCursor(0, 0)
+	var stops []int
+	for range 3 {
+		b.WordRight()
+		stops = append(stops, b.col)
+	}
+	b.WordRight()
+	if want := []int{2, 8, 14}; !slices.Equal(stops, want) || b.row != 1 || b.col != 0 {
+		t.Errorf("WordRight stops %v then %d:%d", stops, b.row, b.col)
+	}
+
+	b.WordLeft()
+	if b.row != 0 || b.col != 14 {
+		t.Fatalf("WordLeft across lines to %d:%d", b.row, b.col)
+	}
+	stops = nil
+	for range 3 {
+		b.WordLeft()
+		stops = append(stops, b.col)
+	}
+	if want := []int{9, 4, 0}; !slices.Equal(stops, want) {
+		t.Errorf("WordLeft stops %v, want %v", stops, want)
+	}
+}
+
+func TestTextBufferCRLF(t *testing.T) {
+	b := newTextBuffer("a\r\nb\r\n")
+	if b.LineCount() != 3 || string(b.Line(0)) != "a" {
+		t.Fatalf("lines = %q", b.lines)
+	}
+	b.SetCursor(1, 1)
+	b.Insert("\nc")
+	if got := b.String(); got != "a\r\nb\r\nc\r\n" {
+		t.Errorf("String = %q, want CRLF kept", got)
+	}
+}
+
+func TestFenceCheckpointsInvalidated(t *testing.T) {
+	lines := make([]string, 3*fenceCheckpointStride)
+	for i := range lines {
+		lines[i] = "texte"
+	}
+	e := newMarkdownEditor(80, 10)
+	e.SetValue(strings.Join(lines, "\n"))
+	e.Focus()
+	e, _ = e.Update(tea.KeyMsg{Type: tea.KeyCtrlEnd})
+	if len(e.fenceCheckpoints) < 3 {
+		t.Fatalf("%d checkpoints after scrolling to the end", len(e.fenceCheckpoints))
+	}
+
+	// Opening a fence near the top changes the state of every later line
+	e.buf.SetCursor(10, 0)
+	e, _ = e.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("```"), Paste: true})
+	if !e.fenceBefore(2*fenceCheckpointStride + 10).open {
+		t.Error("stale fence checkpoint used after an edit")
+	}
+}
+
+func TestWrapLineTabs(t *testing.T) {
+	line := []rune("\tab\tc")
+	rows := wrapLine(line, 6)
+	if len(rows) != 2 || rows[0] != [2]int{0, 3} || rows[1] != [2]int{3, 5} {
+		t.Fatalf("wrapLine = %v", rows)
+	}
+
+	// Moving down keeps the screen column, not the rune index
+	e := newMarkdownEditor(80, 10)
+	e.SetValue("\t\tx\nabcdefghij")
+	e.Focus()
+	e.buf.SetCursor(0, 2)
+	e, _ = e.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if row, col := e.cursor(); row != 1 || col != 2*editorTabWidth {
+		t.Errorf("cursor moved to %d:%d, want 1:%d", row, col, 2*editorTabWidth)
+	}
+}
+
+func TestWrapLineWideRunes(t *testing.T) {
+	line := []rune("日本語ab🙂")
+	rows := wrapLine(line, 6)
+	if len(rows) != 2 || rows[0] != [2]int{0, 3} || rows[1] != [2]int{3, 6} {
+		t.Fatalf("wrapLine = %v", rows)
+	}
+	if got := columnsOf([]rune("e\u0301t\t")); got != 2+editorTabWidth {
+		t.Errorf("combining mark and tab take %d columns", got)
+	}
+
+	// Moving down lands under the same screen column
+	e := newMarkdownEditor(80, 10)
+	e.SetValue("日本x\nabcdefgh")
+	e.Focus()
+	e.buf.SetCursor(0, 2)
+	e, _ = e.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if row, col := e.cursor(); row != 1 || col != 4 {
+		t.Errorf("cursor moved to %d:%d, want 1:4", row, col)
+	}
+}
+
+// BenchmarkEditLargeNote types into the middle of a 5 MB note
+func BenchmarkEditLargeNote(b *testing.B) {
+	note := strings.Repeat("Une ligne de texte assez ordinaire, avec des **mots** en gras.\n", 80000)
+	e := newMarkdownEditor(100, 40)
+	e.SetValue(note)
+	e.Focus()
+	e.buf.SetCursor(40000, 10)
+	key := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")}
+
+	b.ResetTimer()
+	for range b.N {
+		e, _ = e.Update(key)
+		e.View()
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("EDITOR", "vim")
+	for _, tt := range []struct {
+		editor string
+		want   []string
+	}{
+		{"nano", []string{"nano", "note.md"}},
+		{"code --wait", []string{"code", "--wait", "note.md"}},
+		{"", []string{"vim", "note.md"}},
+	} {
+		if got := editorCommand(tt.editor, "note.md").Args; !slices.Equal(got, tt.want) {
+			t.Errorf("editorCommand(%q) = %q, want %q", tt.editor, got, tt.want)
+		}
+	}
+	t.Setenv("EDITOR", "")
+	if got := editorCommand("", "note.md").Args[0]; got != "nvim" {
+		t.Errorf("fallback editor = %q", got)
+	}
+}
+
+func TestExternalEditorUsesSetting(t *testing.T) {
+	t.Setenv("EDITOR", "false")
+	root := t.TempDir()
+	note := filepath.Join(root, "a.md")
+	writeTestFile(t, note, "# A")
+
+	// The configured editor records the file it was given
+	editor := filepath.Join(t.TempDir(), "editeur")
+	writeTestFile(t, editor, "#!/bin/sh\necho \"$1\" > \"$0.log\"\n")
+	os.Chmod(editor, 0755)
+
+	config := DefaultConfig()
+	config.Editor = editor
+	m := initialModel(root, config, &SessionState{})
+	m.applyVaultConfig()
+	m.enterBrowser()
+	cmd := m.runAction(actEditExternal)
+	if cmd == nil {
+		t.Fatal("no editor command")
+	}
+	if msg, ok := cmd().(editorErrorMsg); ok {
+		t.Fatalf("editor failed: %s", msg)
+	}
+	if got := string(mustRead(t, editor+".log")); got != note+"\n" {
+		t.Errorf("editor opened %q, want %q", got, note)
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Encrypted notes are "name.md.enc" files holding an AES-256-GCM container:
+//
+//	magic "NMDENC" | version | log2 N | r | p | salt (16) | nonce (12) | ciphertext
+//
+// The key is derived from the passphrase with scrypt and the header is
+// authenticated as additional data.
+const (
+	encryptedExt   = ".enc"
+	encryptMagic   = "NMDENC"
+	encryptVersion = 2
+	saltSize       = 16
+	headerSize     = len(encryptMagic) + 1 + 3 + saltSize
+)
+
+// kdfParams are the scrypt parameters of a note, N being 1 << logN
+type kdfParams struct {
+	logN, r, p int
+}
+
+// defaultKDF costs about 32 MiB and 100 ms per derivation. Headers may ask
+// for up to maxKDFLogN, which bounds the memory a crafted note can use.
+var defaultKDF = kdfParams{logN: 15, r: 8, p: 1}
+
+const maxKDFLogN = 18
+
+var (
+	errNoteLocked       = errors.New("note chiffrée verrouillée")
+	errWrongPassphrase  = errors.New("phrase secrète incorrecte")
+	errNotEncryptedNote = errors.New("fichier chiffré invalide")
+)
+
+// isEncryptedNote reports whether path is an encrypted note
+func isEncryptedNote(path string) bool {
+	return strings.HasSuffix(path, ".md"+encryptedExt)
+}
+
+// isMarkdownNote reports whether path is a Markdown note, encrypted or not
+func isMarkdownNote(path string) bool {
+	return strings.HasSuffix(path, ".md") || isEncryptedNote(path)
+}
+
+func deriveKey(passphrase string, salt []byte, kdf kdfParams) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, 1<<kdf.logN, kdf.r, kdf.p, 32)
+}
+
+// sealNote encrypts plaintext with key, salt and kdf being stored in the
+// header
+func sealNote(plaintext, key, salt []byte, kdf kdfParams) ([]byte, error) {
+	header := make([]byte, 0, headerSize)
+	header = append(header, encryptMagic...)
+	header = append(header, encryptVersion, byte(kdf.logN), byte(kdf.r), byte(kdf.p))
+	header = append(header, salt...)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append(header, nonce...)
+	return gcm.Seal(out, nonce, plaintext, header), nil
+}
+
+// parseSealed splits an encrypted note into its header fields
+func parseSealed(data []byte) (header, salt []byte, kdf kdfParams, err error) {
+	if len(data) < headerSize || !bytes.HasPrefix(data, []byte(encryptMagic)) {
+		return nil, nil, kdf, errNotEncryptedNote
+	}
+	params := data[len(encryptMagic):]
+	if v := params[0]; v != encryptVersion {
+		return nil, nil, kdf, fmt.Errorf("version de chiffrement %d non supportée", v)
+	}
+	kdf = kdfParams{logN: int(params[1]), r: int(params[2]), p: int(params[3])}
+	if kdf.logN < defaultKDF.logN || kdf.logN > maxKDFLogN || kdf.r != defaultKDF.r || kdf.p != defaultKDF.p {
+		return nil, nil, kdf, fmt.Errorf("%w : paramètres scrypt %d/%d/%d", errNotEncryptedNote, kdf.logN, kdf.r, kdf.p)
+	}
+	header = data[:headerSize]
+	salt = data[headerSize-saltSize : headerSize]
+	return header, salt, kdf, nil
+}
+
+// openNote decrypts an encrypted note with key
+func openNote(data, key []byte) ([]byte, error) {
+	header, _, _, err := parseSealed(data)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	rest := data[headerSize:]
+	if len(rest) < gcm.NonceSize() {
+		return nil, errNotEncryptedNote
+	}
+	plaintext, err := gcm.Open(nil, rest[:gcm.NonceSize()], rest[gcm.NonceSize():], header)
+	if err != nil {
+		return nil, errWrongPassphrase
+	}
+	return plaintext, nil
+}
+
+// keyring caches the session passphrase until it stays unused for timeout,
+// along with the keys derived from it
+type keyring struct {
+	mu         sync.Mutex
+	passphrase string
+	lastUsed   time.Time
+	timeout    time.Duration
+	keys       map[string][]byte // derived keys by salt
+}
+
+// sessionKeys is the passphrase cache of the running session. It only
+// lives in memory.
+var sessionKeys = &keyring{timeout: 10 * time.Minute}
+
+// unlocked reports whether a passphrase is cached and not expired
+func (k *keyring) unlocked() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.valid(time.Now())
+}
+
+func (k *keyring) valid(now time.Time) bool {
+	if k.passphrase == "" {
+		return false
+	}
+	if k.timeout > 0 && now.Sub(k.lastUsed) > k.timeout {
+		k.lockLocked()
+		return false
+	}
+	return true
+}
+
+// expiresIn returns the time left before the passphrase is forgotten
+func (k *keyring) expiresIn() time.Duration {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.timeout - time.Since(k.lastUsed)
+}
+
+// unlock checks passphrase against an encrypted note and caches it
+func (k *keyring) unlock(passphrase string, data []byte) error {
+	_, salt, kdf, err := parseSealed(data)
+	if err != nil {
+		return err
+	}
+	key, err := deriveKey(passphrase, salt, kdf)
+	if err != nil {
+		return err
+	}
+	if _, err := openNote(data, key); err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.passphrase != passphrase {
+		k.keys = nil
+	}
+	k.setLocked(passphrase)
+	k.keys[string(salt)] = key
+	return nil
+}
+
+// set caches a new passphrase, used before encrypting a first note
+func (k *keyring) set(passphrase string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.keys = nil
+	k.setLocked(passphrase)
+}
+
+func (k *keyring) setLocked(passphrase string) {
+	k.passphrase = passphrase
+	k.lastUsed = time.Now()
+	if k.keys == nil {
+		k.keys = make(map[string][]byte)
+	}
+}
+
+// lock forgets the passphrase and the derived keys
+func (k *keyring) lock() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.lockLocked()
+}
+
+func (k *keyring) lockLocked() {
+	k.passphrase = ""
+	k.keys = nil
+}
+
+// key returns the key for salt, deriving it from the cached passphrase
+func (k *keyring) key(salt []byte, kdf kdfParams) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if !k.valid(time.Now()) {
+		return nil, errNoteLocked
+	}
+	k.lastUsed = time.Now()
+	if key, ok := k.keys[string(salt)]; ok {
+		return key, nil
+	}
+	key, err := deriveKey(k.passphrase, salt, kdf)
+	if err != nil {
+		return nil, err
+	}
+	k.keys[string(salt)] = key
+	return key, nil
+}
+
+// decrypt opens an encrypted note with the cached passphrase
+func (k *keyring) decrypt(data []byte) ([]byte, error) {
+	_, salt, kdf, err := parseSealed(data)
+	if err != nil {
+		return nil, err
+	}
+	key, err := k.key(salt, kdf)
+	if err != nil {
+		return nil, err
+	}
+	return openNote(data, key)
+}
+
+// encrypt seals plaintext with the cached passphrase. The salt of previous,
+// the current content of the note, is reused so saves skip the key
+// derivation; a fresh nonce is drawn for every save. previous must open
+// with the cached passphrase, so a note is never re-keyed to the passphrase
+// of another note.
+func (k *keyring) encrypt(plaintext, previous []byte) ([]byte, error) {
+	_, salt, kdf, err := parseSealed(previous)
+	sealed := err == nil
+	if !sealed {
+		salt = make([]byte, saltSize)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		kdf = defaultKDF
+	}
+	key, err := k.key(salt, kdf)
+	if err != nil {
+		return nil, err
+	}
+	if sealed {
+		if _, err := openNote(previous, key); err != nil {
+			return nil, err
+		}
+	}
+	return sealNote(plaintext, key, salt, kdf)
+}
+
+// readNote returns the content of a note, decrypting encrypted notes with
+// the session passphrase
+func readNote(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil || !isEncryptedNote(path) {
+		return data, err
+	}
+	return sessionKeys.decrypt(data)
+}
+
+// writeEncryptedNote encrypts content and replaces the note atomically, so
+// the plaintext never reaches the disk
+func writeEncryptedNote(path string, content []byte) error {
+	previous, _ := os.ReadFile(path)
+	sealed, err := sessionKeys.encrypt(content, previous)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, sealed, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// encryptNoteFile encrypts a plain note into path.enc and removes the
+// original along with its local snapshots
+func encryptNoteFile(rootDir, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	dst := path + encryptedExt
+	if _, err := os.Stat(dst); err == nil {
+		return "", fmt.Errorf("%s existe déjà", dst)
+	}
+	if err := writeEncryptedNote(dst, data); err != nil {
+		return "", err
+	}
+	if err := os.Remove(path); err != nil {
+		return dst, err
+	}
+	if dir, err := snapshotDir(rootDir, path); err == nil {
+		os.RemoveAll(dir)
+	}
+	return dst, nil
+}
+
+// decryptNoteFile writes an encrypted note back in clear and removes the
+// encrypted file
+func decryptNoteFile(path string) (string, error) {
+	data, err := readNote(path)
+	if err != nil {
+		return "", err
+	}
+	dst := strings.TrimSuffix(path, encryptedExt)
+	if _, err := os.Stat(dst); err == nil {
+		return "", fmt.Errorf("%s existe déjà", dst)
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return "", err
+	}
+	return dst, os.Remove(path)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEncryptedNoteRoundTrip(t *testing.T) {
+	defer sessionKeys.lock()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret.md")
+	if err := os.WriteFile(path, []byte("# Secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sessionKeys.set("correct horse")
+	dst, err := encryptNoteFile(dir, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dst != path+".enc" {
+		t.Fatalf("dst = %q", dst)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatal("plaintext note still on disk")
+	}
+
+	if err := writeEncryptedNote(dst, []byte("# Secret\nmodifié\n")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readNote(dst)
+	if err != nil || string(got) != "# Secret\nmodifié\n" {
+		t.Fatalf("readNote = %q, %v", got, err)
+	}
+
+	sessionKeys.lock()
+	if _, err := readNote(dst); err != errNoteLocked {
+		t.Fatalf("locked readNote err = %v", err)
+	}
+	data, _ := os.ReadFile(dst)
+	if err := sessionKeys.unlock("wrong", data); err != errWrongPassphrase {
+		t.Fatalf("unlock with wrong passphrase err = %v", err)
+	}
+	if err := sessionKeys.unlock("correct horse", data); err != nil {
+		t.Fatal(err)
+	}
+
+	plain, err := decryptNoteFile(dst)
+	if err != nil || plain != path {
+		t.Fatalf("decryptNoteFile = %q, %v", plain, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "# Secret\nmodifié\n" {
+		t.Fatalf("decrypted content = %q", data)
+	}
+}
+
+func TestKeyringTimeout(t *testing.T) {
+	k := &keyring{timeout: time.Minute}
+	k.set("pass")
+	if !k.unlocked() {
+		t.Fatal("keyring should be unlocked")
+	}
+	k.lastUsed = time.Now().Add(-2 * time.Minute)
+	if k.unlocked() {
+		t.Fatal("keyring should have expired")
+	}
+}
+
+func TestParseSealedKDF(t *testing.T) {
+	salt := make([]byte, saltSize)
+	key := make([]byte, 32)
+	for _, tt := range []struct {
+		kdf kdfParams
+		ok  bool
+	}{
+		{defaultKDF, true},
+		{kdfParams{logN: maxKDFLogN, r: 8, p: 1}, true},
+		{kdfParams{logN: 1, r: 8, p: 1}, false},
+		{kdfParams{logN: maxKDFLogN + 1, r: 8, p: 1}, false},
+		{kdfParams{logN: 255, r: 8, p: 1}, false},
+		{kdfParams{logN: 15, r: 255, p: 255}, false},
+	} {
+		sealed, err := sealNote([]byte("x"), key, salt, tt.kdf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, _, err := parseSealed(sealed); (err == nil) != tt.ok {
+			t.Errorf("parseSealed with %+v: err = %v", tt.kdf, err)
+		}
+	}
+
+	// Containers of another version are refused, not misread
+	sealed, _ := sealNote([]byte("x"), key, salt, defaultKDF)
+	sealed[len(encryptMagic)] = 1
+	if _, _, _, err := parseSealed(sealed); err == nil {
+		t.Error("version 1 container accepted")
+	}
+}
+
+func TestEncryptKeepsNotePassphrase(t *testing.T) {
+	defer sessionKeys.lock()
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.md.enc"), filepath.Join(dir, "b.md.enc")
+
+	sessionKeys.set("première")
+	if err := writeEncryptedNote(a, []byte("A")); err != nil {
+		t.Fatal(err)
+	}
+	sessionKeys.set("seconde")
+	if err := writeEncryptedNote(b, []byte("B")); err != nil {
+		t.Fatal(err)
+	}
+
+	// b is open with "seconde", a is sealed with "première"
+	if err := writeEncryptedNote(a, []byte("A modifiée")); err != errWrongPassphrase {
+		t.Fatalf("save with another note's passphrase: err = %v", err)
+	}
+	data, _ := os.ReadFile(a)
+	if err := sessionKeys.unlock("première", data); err != nil {
+		t.Fatalf("note re-keyed: %v", err)
+	}
+	if err := writeEncryptedNote(a, []byte("A modifiée")); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := readNote(a); err != nil || string(got) != "A modifiée" {
+		t.Errorf("readNote = %q, %v", got, err)
+	}
+}
+
+func TestEditEncryptedNoteWithAnotherPassphrase(t *testing.T) {
+	defer sessionKeys.lock()
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.md.enc"), filepath.Join(dir, "b.md.enc")
+	sessionKeys.set("première")
+	writeEncryptedNote(a, []byte("# A\n"))
+	sessionKeys.set("seconde")
+	writeEncryptedNote(b, []byte("# B\n"))
+	sealed, _ := os.ReadFile(a)
+
+	// The cached passphrase is b's: a asks for its own instead of opening empty
+	m := initialModel(dir, DefaultConfig(), &SessionState{})
+	m.openEditModal(a)
+	if m.showEditModal || !m.showPassphraseModal || m.passphraseModal.action != unlockToEdit {
+		t.Fatalf("edit modal %v, passphrase modal %v", m.showEditModal, m.showPassphraseModal)
+	}
+
+	// A buffer that wasn't decrypted from the note never replaces it
+	m.editModal = newEditModal(a, "", 80, 24)
+	if _, err := m.saveEditModal(); err == nil {
+		t.Error("undecrypted buffer saved")
+	}
+	sessionKeys.unlock("première", sealed)
+	m.runPassphraseAction(a, unlockToSave)
+	if data, _ := os.ReadFile(a); string(data) != string(sealed) {
+		t.Error("note overwritten by an undecrypted buffer")
+	}
+
+	m.openEditModal(a)
+	if !m.showEditModal || m.editModal.GetContent() != "# A\n" {
+		t.Errorf("unlocked note opened with %q", m.editModal.GetContent())
+	}
+}
@@ -0,0 +1,716 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark"
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	ghtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+)
+
+// htmlExportOptions selects the notes published by the static site export.
+// Empty options export the whole vault.
+type htmlExportOptions struct {
+	folder    string // only notes under this vault-relative folder
+	tag       string // only notes carrying this tag
+	published bool   // only notes with "publish: true" frontmatter
+	rawHTML   bool   // keep the HTML written in notes, dropped by default
+}
+
+// Generated pages and files live in their own folder of the site, so they
+// never replace an exported note
+const (
+	siteDir     = "_notesmd"
+	siteHome    = siteDir + "/index.html"
+	siteTags    = siteDir + "/tags.html"
+	siteCSSFile = siteDir + "/style.css"
+	siteSearch  = siteDir + "/search.json"
+)
+
+// brokenLinkDest marks links to notes that are not exported
+const brokenLinkDest = "notesmd:broken"
+
+// exportedNote is a note of the static site
+type exportedNote struct {
+	path      string // absolute source path
+	url       string // slash-separated page path relative to the site root
+	title     string
+	tags      []string
+	body      string // Markdown without frontmatter
+	backlinks []*exportedNote
+}
+
+// htmlSite holds the state of a static site export
+type htmlSite struct {
+	rootDir string
+	outDir  string
+	rawHTML bool
+	notes   []*exportedNote
+	byName  map[string]*exportedNote // lowercase link name and relative path
+	assets  map[string]bool          // vault-relative files to copy
+}
+
+// exportHTML converts the selected notes of rootDir into a static site in
+// outDir and returns the number of exported notes
+func exportHTML(rootDir, outDir string, opts htmlExportOptions) (int, error) {
+	site := &htmlSite{
+		rootDir: rootDir,
+		outDir:  outDir,
+		rawHTML: opts.rawHTML,
+		byName:  make(map[string]*exportedNote),
+		assets:  make(map[string]bool),
+	}
+	if err := site.collect(opts); err != nil {
+		return 0, err
+	}
+	if len(site.notes) == 0 {
+		return 0, fmt.Errorf("aucune note ne correspond à la sélection")
+	}
+	site.linkBacklinks()
+
+	type page struct{ body, plain string }
+	pages := make([]page, len(site.notes))
+	for i, n := range site.notes {
+		pages[i].body, pages[i].plain = site.render(n)
+	}
+	if err := site.checkCollisions(); err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(filepath.Join(outDir, siteDir), 0755); err != nil {
+		return 0, err
+	}
+
+	var index []searchEntry
+	for i, n := range site.notes {
+		if err := site.writePage(n.url, n.title, n.tags, pages[i].body, n.backlinks); err != nil {
+			return 0, err
+		}
+		// URLs are relative to the home page, which runs the search
+		index = append(index, searchEntry{Title: n.title, URL: relURL(siteHome, n.url), Tags: n.tags, Text: pages[i].plain})
+	}
+
+	if err := site.writeIndex(); err != nil {
+		return 0, err
+	}
+	if err := site.writeTagIndex(); err != nil {
+		return 0, err
+	}
+	if err := site.writeJSON(siteSearch, index); err != nil {
+		return 0, err
+	}
+	if err := os.WriteFile(filepath.Join(outDir, filepath.FromSlash(siteCSSFile)), []byte(siteCSS), 0644); err != nil {
+		return 0, err
+	}
+	if err := site.writeRootRedirect(); err != nil {
+		return 0, err
+	}
+	return len(site.notes), site.copyAssets()
+}
+
+// checkCollisions fails when two files of the site would share a path:
+// notes or copied files in the generated folder, or a copied file that is
+// also the page of a note
+func (s *htmlSite) checkCollisions() error {
+	pages := make(map[string]string, len(s.notes))
+	for _, n := range s.notes {
+		rel, _ := filepath.Rel(s.rootDir, n.path)
+		if other, ok := pages[strings.ToLower(n.url)]; ok {
+			return fmt.Errorf("%s et %s seraient exportées sur la même page", other, rel)
+		}
+		pages[strings.ToLower(n.url)] = rel
+		if strings.HasPrefix(n.url, siteDir+"/") {
+			return fmt.Errorf("%s : le dossier %s est réservé aux pages générées", rel, siteDir)
+		}
+	}
+	for asset := range s.assets {
+		if strings.HasPrefix(asset, siteDir+"/") {
+			return fmt.Errorf("%s : le dossier %s est réservé aux pages générées", asset, siteDir)
+		}
+		if note, ok := pages[strings.ToLower(asset)]; ok {
+			return fmt.Errorf("%s : même chemin que la page de %s", asset, note)
+		}
+	}
+	return nil
+}
+
+// collect reads the vault notes matching opts
+func (s *htmlSite) collect(opts htmlExportOptions) error {
+	root := s.rootDir
+	if opts.folder != "" {
+		root = filepath.Join(s.rootDir, opts.folder)
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return fmt.Errorf("dossier introuvable : %s", opts.folder)
+		}
+	}
+
+	for _, p := range walkNotes(root) {
+		content := loadMarkdownRaw(p)
+		fm, body := parseFrontmatter(content)
+		if opts.published && !fm.Bool("publish") {
+			continue
+		}
+
+		tags := parseTags(content)
+		if opts.tag != "" && !containsFold(tags, strings.TrimPrefix(opts.tag, "#")) {
+			continue
+		}
+
+		rel, err := filepath.Rel(s.rootDir, p)
+		if err != nil {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+
+		n := &exportedNote{
+			path:  p,
+			url:   strings.TrimSuffix(rel, path.Ext(rel)) + ".html",
+			title: noteTitle(fm, body, p),
+			tags:  tags,
+			body:  body,
+		}
+		s.notes = append(s.notes, n)
+
+		// Exact relative paths win over duplicate basenames
+		s.byName[strings.ToLower(strings.TrimSuffix(rel, path.Ext(rel)))] = n
+		if _, ok := s.byName[strings.ToLower(noteLinkName(p))]; !ok {
+			s.byName[strings.ToLower(noteLinkName(p))] = n
+		}
+	}
+	return nil
+}
+
+// noteTitle returns the frontmatter title, the first H1 or the note name
+func noteTitle(fm frontmatter, body, path string) string {
+	if title := fm.Get("title"); title != "" {
+		return title
+	}
+	for _, h := range parseHeadings(body) {
+		if h.level == 1 {
+			return h.text
+		}
+	}
+	return noteLinkName(path)
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the exported note targeted by a wiki link
+func (s *htmlSite) resolve(link string) *exportedNote {
+	target := strings.ToLower(strings.TrimSuffix(filepath.ToSlash(wikiLinkTarget(link)), ".md"))
+	if n, ok := s.byName[target]; ok {
+		return n
+	}
+	return s.byName[path.Base(target)]
+}
+
+// linkBacklinks records for each note the exported notes linking to it
+func (s *htmlSite) linkBacklinks() {
+	for _, src := range s.notes {
+		seen := make(map[*exportedNote]bool)
+		for _, link := range parseWikiLinks(src.body) {
+			if dst := s.resolve(link); dst != nil && dst != src && !seen[dst] {
+				seen[dst] = true
+				dst.backlinks = append(dst.backlinks, src)
+			}
+		}
+	}
+}
+
+// render converts a note to HTML and returns it with its plain text
+func (s *htmlSite) render(n *exportedNote) (string, string) {
+	noteDir := filepath.Dir(n.path)
+
+	content := replaceWikiLinks(n.body, func(link string, embed bool) string {
+		label := markdownEscape(wikiLinkLabel(link))
+
+		if embed && isImageFile(wikiLinkTarget(link)) {
+			if img := findNoteByName(wikiLinkTarget(link), s.rootDir); img != "" {
+				if rel, err := filepath.Rel(s.rootDir, img); err == nil {
+					s.assets[filepath.ToSlash(rel)] = true
+					return fmt.Sprintf("![%s](<%s>)", label, relURL(n.url, filepath.ToSlash(rel)))
+				}
+			}
+		}
+
+		dst := s.resolve(link)
+		if dst == nil {
+			return fmt.Sprintf("[%s](<%s>)", label, brokenLinkDest)
+		}
+		href := relURL(n.url, dst.url)
+		target, _, _ := strings.Cut(link, "|")
+		if _, anchor, ok := strings.Cut(target, "#"); ok {
+			href += "#" + headingSlug(anchor)
+		}
+		return fmt.Sprintf("[%s](<%s>)", label, href)
+	})
+
+	md := newGoldmark(s.rawHTML)
+	source := []byte(content)
+	doc := parseGoldmark(md, source)
+
+	// Point relative Markdown links at pages and collect local images
+	var plain strings.Builder
+	gast.Walk(doc, func(node gast.Node, entering bool) (gast.WalkStatus, error) {
+		if !entering {
+			return gast.WalkContinue, nil
+		}
+		switch node := node.(type) {
+		case *gast.Link:
+			if string(node.Destination) == brokenLinkDest {
+				node.Destination = nil
+				node.SetAttributeString("class", []byte("broken-link"))
+				node.Title = []byte("Note non exportée")
+				break
+			}
+			node.Destination = []byte(s.rewriteLink(n, noteDir, string(node.Destination)))
+		case *gast.Image:
+			s.addAsset(noteDir, string(node.Destination))
+		case *gast.Text:
+			plain.Write(node.Segment.Value(source))
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				plain.WriteByte(' ')
+			}
+		case *gast.Paragraph, *gast.Heading, *gast.ListItem:
+			plain.WriteByte(' ')
+		}
+		return gast.WalkContinue, nil
+	})
+
+	var buf bytes.Buffer
+	if err := md.Renderer().Render(&buf, source, doc); err != nil {
+		return fmt.Sprintf("<p>%s</p>", html.EscapeString(err.Error())), ""
+	}
+	return buf.String(), strings.Join(strings.Fields(plain.String()), " ")
+}
+
+// newGoldmark returns the Markdown converter used by HTML exports. Without
+// rawHTML, HTML written in notes and javascript: links are dropped.
+func newGoldmark(rawHTML bool) goldmark.Markdown {
+	var opts []goldmark.Option
+	if rawHTML {
+		opts = append(opts, goldmark.WithRendererOptions(ghtml.WithUnsafe()))
+	}
+	return goldmark.New(append(opts,
+		goldmark.WithExtensions(extension.GFM, extension.Footnote),
+		goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	)...)
+}
+
+// parseGoldmark parses source with heading ids matching headingSlug
+func parseGoldmark(md goldmark.Markdown, source []byte) gast.Node {
+	ctx := parser.NewContext(parser.WithIDs(newSlugIDs()))
+	return md.Parser().Parse(text.NewReader(source), parser.WithContext(ctx))
+}
+
+// rewriteLink maps a relative link to a .md note onto its exported page
+func (s *htmlSite) rewriteLink(n *exportedNote, noteDir, dest string) string {
+	if dest == "" || strings.Contains(dest, "://") || strings.HasPrefix(dest, "#") || strings.HasPrefix(dest, "mailto:") {
+		return dest
+	}
+	target, fragment, _ := strings.Cut(dest, "#")
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		target = unescaped
+	}
+	switch strings.ToLower(filepath.Ext(target)) {
+	case ".md":
+	case ".html":
+		// Already a page, such as a resolved wiki link
+		return dest
+	default:
+		s.addAsset(noteDir, dest)
+		return dest
+	}
+
+	rel, err := filepath.Rel(s.rootDir, filepath.Join(noteDir, filepath.FromSlash(target)))
+	if err != nil {
+		return dest
+	}
+	dst := s.byName[strings.ToLower(strings.TrimSuffix(filepath.ToSlash(rel), path.Ext(rel)))]
+	if dst == nil {
+		return dest
+	}
+
+	href := relURL(n.url, dst.url)
+	if fragment != "" {
+		href += "#" + fragment
+	}
+	return href
+}
+
+// addAsset records a local file referenced by a note so it gets copied
+func (s *htmlSite) addAsset(noteDir, dest string) {
+	if dest == "" || strings.Contains(dest, "://") || strings.HasPrefix(dest, "data:") {
+		return
+	}
+	if unescaped, err := url.PathUnescape(dest); err == nil {
+		dest = unescaped
+	}
+	rel, err := filepath.Rel(s.rootDir, filepath.Join(noteDir, filepath.FromSlash(dest)))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return
+	}
+	s.assets[filepath.ToSlash(rel)] = true
+}
+
+// copyAssets copies the referenced local files into the site
+func (s *htmlSite) copyAssets() error {
+	for rel := range s.assets {
+		src := filepath.Join(s.rootDir, filepath.FromSlash(rel))
+		if info, err := os.Stat(src); err != nil || info.IsDir() {
+			continue
+		}
+		dst := filepath.Join(s.outDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return err
+		}
+		if err := copyFile(src, dst); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isImageFile reports whether name has a common image extension
+func isImageFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp", ".bmp":
+		return true
+	}
+	return false
+}
+
+// markdownEscape escapes the characters that would end a link label
+func markdownEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(s)
+}
+
+// relURL returns the URL of page "to" relative to page "from"
+func relURL(from, to string) string {
+	rel, err := filepath.Rel(filepath.Dir(filepath.FromSlash(from)), filepath.FromSlash(to))
+	if err != nil {
+		return to
+	}
+	return filepath.ToSlash(rel)
+}
+
+// headingSlug builds an HTML id from heading text, keeping accented letters
+func headingSlug(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.TrimSpace(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(unicode.ToLower(r))
+		case unicode.IsSpace(r) || r == '-' || r == '_':
+			sb.WriteRune('-')
+		}
+	}
+	return sb.String()
+}
+
+// slugIDs generates heading ids with headingSlug, deduplicating repeats
+type slugIDs struct {
+	used map[string]bool
+}
+
+func newSlugIDs() *slugIDs {
+	return &slugIDs{used: make(map[string]bool)}
+}
+
+func (s *slugIDs) Generate(value []byte, kind gast.NodeKind) []byte {
+	id := headingSlug(string(value))
+	if id == "" {
+		id = "heading"
+	}
+	base := id
+	for i := 1; s.used[id]; i++ {
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+	s.used[id] = true
+	return []byte(id)
+}
+
+func (s *slugIDs) Put(value []byte) {
+	s.used[string(value)] = true
+}
+
+// ========== Site pages ==========
+
+// searchEntry is a note in search.json
+type searchEntry struct {
+	Title string   `json:"title"`
+	URL   string   `json:"url"`
+	Tags  []string `json:"tags"`
+	Text  string   `json:"text"`
+}
+
+// siteLink is a link rendered by the page template
+type siteLink struct {
+	Label string
+	Href  string
+}
+
+// writePage renders a page at url with the site layout
+func (s *htmlSite) writePage(pageURL, title string, tags []string, body string, backlinks []*exportedNote) error {
+	data := struct {
+		Title     string
+		Root      string
+		CSS       string
+		Tree      template.HTML
+		Body      template.HTML
+		Tags      []siteLink
+		Backlinks []siteLink
+	}{
+		Title: title,
+		Root:  relURL(pageURL, siteHome),
+		CSS:   relURL(pageURL, siteCSSFile),
+		Tree:  template.HTML(s.treeHTML(pageURL)),
+		Body:  template.HTML(body),
+	}
+	for _, tag := range tags {
+		data.Tags = append(data.Tags, siteLink{Label: "#" + tag, Href: relURL(pageURL, siteTags) + "#tag-" + headingSlug(tag)})
+	}
+	for _, b := range backlinks {
+		data.Backlinks = append(data.Backlinks, siteLink{Label: b.title, Href: relURL(pageURL, b.url)})
+	}
+
+	var buf bytes.Buffer
+	if err := pageTemplate.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	dst := filepath.Join(s.outDir, filepath.FromSlash(pageURL))
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, buf.Bytes(), 0644)
+}
+
+// writeIndex writes the home page with the search box
+func (s *htmlSite) writeIndex() error {
+	body := `<h1>Notes</h1>
+<input id="search" type="search" placeholder="Rechercher…" autofocus>
+<ul id="results"></ul>
+<script>` + searchJS + `</script>
+<p>` + fmt.Sprintf("%d notes", len(s.notes)) + ` • <a href="` + relURL(siteHome, siteTags) + `">Tags</a></p>`
+	return s.writePage(siteHome, "Notes", nil, body, nil)
+}
+
+// writeRootRedirect sends the root of the site to the home page, unless a
+// note of the vault is exported there
+func (s *htmlSite) writeRootRedirect() error {
+	for _, n := range s.notes {
+		if strings.EqualFold(n.url, "index.html") {
+			return nil
+		}
+	}
+	page := `<!DOCTYPE html>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="0; url=` + siteHome + `">
+<a href="` + siteHome + `">Notes</a>
+`
+	return os.WriteFile(filepath.Join(s.outDir, "index.html"), []byte(page), 0644)
+}
+
+// writeTagIndex writes tags.html listing the notes of every tag
+func (s *htmlSite) writeTagIndex() error {
+	byTag := make(map[string][]*exportedNote)
+	labels := make(map[string]string)
+	for _, n := range s.notes {
+		for _, tag := range n.tags {
+			key := strings.ToLower(tag)
+			byTag[key] = append(byTag[key], n)
+			if _, ok := labels[key]; !ok {
+				labels[key] = tag
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(byTag))
+	for key := range byTag {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString("<h1>Tags</h1>\n")
+	if len(keys) == 0 {
+		sb.WriteString("<p>Aucun tag.</p>\n")
+	}
+	for _, key := range keys {
+		fmt.Fprintf(&sb, "<h2 id=\"tag-%s\">#%s <small>(%d)</small></h2>\n<ul>\n",
+			html.EscapeString(headingSlug(key)), html.EscapeString(labels[key]), len(byTag[key]))
+		for _, n := range byTag[key] {
+			fmt.Fprintf(&sb, "<li><a href=\"%s\">%s</a></li>\n", html.EscapeString(relURL(siteTags, n.url)), html.EscapeString(n.title))
+		}
+		sb.WriteString("</ul>\n")
+	}
+	return s.writePage(siteTags, "Tags", nil, sb.String(), nil)
+}
+
+// writeJSON writes v as JSON to a site file
+func (s *htmlSite) writeJSON(name string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(s.outDir, filepath.FromSlash(name)), data, 0644)
+}
+
+// siteTreeNode is a folder or page of the navigation tree
+type siteTreeNode struct {
+	name     string
+	note     *exportedNote
+	children map[string]*siteTreeNode
+}
+
+// treeHTML renders the folder tree with links relative to pageURL
+func (s *htmlSite) treeHTML(pageURL string) string {
+	root := &siteTreeNode{children: make(map[string]*siteTreeNode)}
+	for _, n := range s.notes {
+		node := root
+		parts := strings.Split(n.url, "/")
+		for _, dir := range parts[:len(parts)-1] {
+			child, ok := node.children[dir]
+			if !ok {
+				child = &siteTreeNode{name: dir, children: make(map[string]*siteTreeNode)}
+				node.children[dir] = child
+			}
+			node = child
+		}
+		node.children[parts[len(parts)-1]] = &siteTreeNode{name: n.title, note: n}
+	}
+
+	var sb strings.Builder
+	var walk func(node *siteTreeNode, prefix string)
+	walk = func(node *siteTreeNode, prefix string) {
+		names := make([]string, 0, len(node.children))
+		for name := range node.children {
+			names = append(names, name)
+		}
+		// Folders first, then pages, alphabetically
+		sort.Slice(names, func(i, j int) bool {
+			a, b := node.children[names[i]], node.children[names[j]]
+			if (a.note == nil) != (b.note == nil) {
+				return a.note == nil
+			}
+			return strings.ToLower(names[i]) < strings.ToLower(names[j])
+		})
+
+		sb.WriteString("<ul>")
+		for _, name := range names {
+			child := node.children[name]
+			if child.note != nil {
+				class := ""
+				if child.note.url == pageURL {
+					class = ` class="current"`
+				}
+				fmt.Fprintf(&sb, `<li><a href="%s"%s>%s</a></li>`,
+					html.EscapeString(relURL(pageURL, child.note.url)), class, html.EscapeString(child.name))
+				continue
+			}
+			dir := prefix + name + "/"
+			open := ""
+			if strings.HasPrefix(pageURL, dir) {
+				open = " open"
+			}
+			fmt.Fprintf(&sb, `<li><details%s><summary>📁 %s</summary>`, open, html.EscapeString(name))
+			walk(child, dir)
+			sb.WriteString("</details></li>")
+		}
+		sb.WriteString("</ul>")
+	}
+	walk(root, "")
+	return sb.String()
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<link rel="stylesheet" href="{{.CSS}}">
+</head>
+<body>
+<nav><a class="home" href="{{.Root}}">🏠 Notes</a>{{.Tree}}</nav>
+<main>
+{{- if .Tags}}
+<p class="tags">{{range .Tags}}<a href="{{.Href}}">{{.Label}}</a> {{end}}</p>
+{{- end}}
+{{.Body}}
+{{- if .Backlinks}}
+<section class="backlinks">
+<h2>Rétroliens</h2>
+<ul>{{range .Backlinks}}<li><a href="{{.Href}}">{{.Label}}</a></li>{{end}}</ul>
+</section>
+{{- end}}
+</main>
+</body>
+</html>
+`))
+
+const searchJS = `
+fetch("search.json").then(r => r.json()).then(notes => {
+  const input = document.getElementById("search");
+  const results = document.getElementById("results");
+  input.addEventListener("input", () => {
+    const q = input.value.trim().toLowerCase();
+    results.innerHTML = "";
+    if (!q) return;
+    for (const n of notes) {
+      const hay = (n.title + " " + n.tags.join(" ") + " " + n.text).toLowerCase();
+      if (!hay.includes(q)) continue;
+      const li = document.createElement("li");
+      const a = document.createElement("a");
+      a.href = n.url;
+      a.textContent = n.title;
+      li.appendChild(a);
+      results.appendChild(li);
+    }
+  });
+});
+`
+
+const siteCSS = `body { display: flex; margin: 0; font-family: system-ui, sans-serif; line-height: 1.6; color: #222; }
+nav { width: 18rem; min-height: 100vh; padding: 1rem; background: #f5f5f5; border-right: 1px solid #ddd; font-size: .9rem; }
+nav ul { list-style: none; padding-left: 1rem; margin: 0; }
+nav > ul { padding-left: 0; }
+nav a { color: #333; text-decoration: none; }
+nav a.current { font-weight: bold; color: #d75f00; }
+nav .home { display: block; margin-bottom: 1rem; font-weight: bold; }
+main { flex: 1; max-width: 50rem; padding: 1rem 2rem; }
+a { color: #0969da; }
+pre { background: #f6f8fa; padding: 1rem; overflow-x: auto; }
+code { background: #f6f8fa; padding: .1rem .3rem; }
+img { max-width: 100%; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ddd; padding: .3rem .6rem; }
+blockquote { border-left: 4px solid #ddd; margin-left: 0; padding-left: 1rem; color: #555; }
+.tags a { margin-right: .5rem; font-size: .9rem; }
+.broken-link { color: #b00; border-bottom: 1px dashed #b00; }
+.backlinks { margin-top: 3rem; border-top: 1px solid #ddd; font-size: .9rem; }
+#search { width: 100%; padding: .5rem; font-size: 1rem; }
+`
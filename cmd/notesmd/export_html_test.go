@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func exportTestVault(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "index.md"), "# Accueil\n\nVoir [[Projet#Étapes|le plan]] et [[privée]].\n")
+	writeTestFile(t, filepath.Join(root, "tags.md"), "# Mes tags\n\n#travail\n")
+	writeTestFile(t, filepath.Join(root, "projets", "Projet.md"), "---\npublish: true\n---\n# Projet\n\n## Étapes\n\n[retour](../index.md#accueil) ![schéma](img/plan.png) <b>gras</b> [js](javascript:alert(1)) [[privée]]\n\n#travail\n")
+	writeTestFile(t, filepath.Join(root, "projets", "img", "plan.png"), "png")
+	writeTestFile(t, filepath.Join(root, "privée.md"), "# Privée\n\n[[Projet]]\n")
+	return root
+}
+
+func readSite(t *testing.T, out, rel string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(out, filepath.FromSlash(rel)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestExportHTMLLinks(t *testing.T) {
+	root := exportTestVault(t)
+	out := t.TempDir()
+	count, err := exportHTML(root, out, htmlExportOptions{})
+	if err != nil || count != 4 {
+		t.Fatalf("exportHTML = %d, %v", count, err)
+	}
+
+	// Notes called index and tags keep their page
+	home := readSite(t, out, "index.html")
+	if !strings.Contains(home, "Accueil") || !strings.Contains(readSite(t, out, "tags.html"), "Mes tags") {
+		t.Error("generated pages replaced notes")
+	}
+	if !strings.Contains(home, `href="projets/Projet.html#%C3%A9tapes"`) || !strings.Contains(home, ">le plan</a>") {
+		t.Errorf("wiki link with anchor and alias not rewritten:\n%s", home)
+	}
+
+	projet := readSite(t, out, "projets/Projet.html")
+	for _, want := range []string{
+		`href="../index.html#accueil"`, // relative Markdown link to a note
+		`src="img/plan.png"`,           // image kept relative
+		`href="../_notesmd/style.css"`, // generated files in their folder
+		`href="../_notesmd/tags.html#tag-travail"`,
+	} {
+		if !strings.Contains(projet, want) {
+			t.Errorf("Projet.html lacks %s", want)
+		}
+	}
+	_, backlinks, _ := strings.Cut(projet, "Rétroliens")
+	if !strings.Contains(backlinks, `<a href="../index.html">Accueil</a>`) || !strings.Contains(backlinks, `<a href="../priv%c3%a9e.html">Privée</a>`) {
+		t.Errorf("backlinks = %s", backlinks)
+	}
+	if strings.Contains(projet, "<b>gras</b>") || strings.Contains(projet, "javascript:") {
+		t.Error("raw HTML or javascript link kept without --raw-html")
+	}
+	if _, err := os.Stat(filepath.Join(out, "projets", "img", "plan.png")); err != nil {
+		t.Error("image not copied")
+	}
+
+	var index []searchEntry
+	if err := json.Unmarshal([]byte(readSite(t, out, siteSearch)), &index); err != nil || len(index) != 4 {
+		t.Fatalf("search.json = %v, %v", index, err)
+	}
+	if _, err := os.Stat(filepath.Join(out, filepath.FromSlash(siteHome))); err != nil {
+		t.Error("home page missing")
+	}
+}
+
+func TestExportHTMLFilters(t *testing.T) {
+	root := exportTestVault(t)
+
+	tests := []struct {
+		name  string
+		opts  htmlExportOptions
+		pages []string
+	}{
+		{"folder", htmlExportOptions{folder: "projets"}, []string{"projets/Projet.html"}},
+		{"tag", htmlExportOptions{tag: "#travail"}, []string{"projets/Projet.html", "tags.html"}},
+		{"published", htmlExportOptions{published: true}, []string{"projets/Projet.html"}},
+	}
+	for _, tt := range tests {
+		out := t.TempDir()
+		count, err := exportHTML(root, out, tt.opts)
+		if err != nil || count != len(tt.pages) {
+			t.Errorf("%s: exported %d notes, %v", tt.name, count, err)
+			continue
+		}
+		for _, page := range tt.pages {
+			if _, err := os.Stat(filepath.Join(out, filepath.FromSlash(page))); err != nil {
+				t.Errorf("%s: %s missing", tt.name, page)
+			}
+		}
+		if _, err := os.Stat(filepath.Join(out, "privée.html")); err == nil {
+			t.Errorf("%s: unselected note exported", tt.name)
+		}
+	}
+
+	// Links to notes left out are broken, without their content
+	out := t.TempDir()
+	exportHTML(root, out, htmlExportOptions{published: true})
+	if home := readSite(t, out, "index.html"); !strings.Contains(home, "_notesmd/index.html") {
+		t.Error("root does not lead to the home page when no note is exported there")
+	}
+	out = t.TempDir()
+	exportHTML(root, out, htmlExportOptions{tag: "travail", rawHTML: true})
+	if page := readSite(t, out, "projets/Projet.html"); !strings.Contains(page, "<b>gras</b>") {
+		t.Error("raw HTML dropped with rawHTML")
+	}
+	out = t.TempDir()
+	exportHTML(root, out, htmlExportOptions{published: true})
+	if page := readSite(t, out, "projets/Projet.html"); !strings.Contains(page, `class="broken-link"`) || strings.Contains(page, "priv%C3%A9e.html") {
+		t.Error("link to a note left out not marked as broken")
+	}
+}
+
+func TestExportHTMLCollisions(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "_notesmd", "index.md"), "# Piège\n")
+	if _, err := exportHTML(root, t.TempDir(), htmlExportOptions{}); err == nil {
+		t.Error("note in the generated folder exported")
+	}
+
+	root = t.TempDir()
+	writeTestFile(t, filepath.Join(root, "page.md"), "# Page\n\n![copie](page.html)\n")
+	writeTestFile(t, filepath.Join(root, "page.html"), "<p>fichier</p>")
+	if _, err := exportHTML(root, t.TempDir(), htmlExportOptions{}); err == nil {
+		t.Error("copied file over a note page")
+	}
+}
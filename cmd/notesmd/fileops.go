@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -160,3 +161,29 @@ func pasteFile(clipboard *FileClipboard, destDir string) tea.Cmd {
 		}
 	}
 }
+
+// moveToFolder moves src into the folder dir and returns its new path
+func moveToFolder(src, dir string) (string, error) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("dossier introuvable : %s", dir)
+	}
+	dst := filepath.Join(dir, filepath.Base(src))
+	if dst == src {
+		return "", fmt.Errorf("déjà dans ce dossier")
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return "", fmt.Errorf("%s existe déjà", dst)
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		if rel, err := filepath.Rel(src, dir); err == nil && !strings.HasPrefix(rel, "..") {
+			return "", fmt.Errorf("impossible de déplacer un dossier dans lui-même")
+		}
+		return dst, moveDir(src, dst)
+	}
+	return dst, moveFile(src, dst)
+}
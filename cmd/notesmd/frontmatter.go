@@ -0,0 +1,133 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// frontmatter holds the YAML header of a note. Only the subset used by
+// notes is supported: "key: value", inline lists "key: [a, b]" and block
+// lists of "- item" lines. Every value is stored as a list of strings.
+type frontmatter map[string][]string
+
+// parseFrontmatter splits a note into its frontmatter and body.
+// Notes without a "---" header return an empty frontmatter and the full content.
+func parseFrontmatter(content string) (frontmatter, string) {
+	fm := frontmatter{}
+
+	normalized := strings.ReplaceAll(content, "\r\n", "\n")
+	if !strings.HasPrefix(normalized, "---\n") {
+		return fm, content
+	}
+
+	rest := normalized[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end == -1 {
+		return fm, content
+	}
+
+	header := rest[:end]
+	body := strings.TrimPrefix(rest[end+len("\n---"):], "\n")
+
+	var lastKey string
+	for _, line := range strings.Split(header, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// Block list item belonging to the previous key
+		if strings.HasPrefix(trimmed, "- ") && lastKey != "" {
+			fm[lastKey] = append(fm[lastKey], unquote(strings.TrimSpace(trimmed[2:])))
+			continue
+		}
+
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		lastKey = key
+
+		switch {
+		case value == "":
+			fm[key] = nil
+		case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+			var items []string
+			for _, item := range strings.Split(value[1:len(value)-1], ",") {
+				if item = unquote(strings.TrimSpace(item)); item != "" {
+					items = append(items, item)
+				}
+			}
+			fm[key] = items
+		default:
+			fm[key] = []string{unquote(value)}
+		}
+	}
+
+	return fm, body
+}
+
+// Get returns the first value of key, or ""
+func (fm frontmatter) Get(key string) string {
+	if values := fm[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Bool reports whether key is set to a true value
+func (fm frontmatter) Bool(key string) bool {
+	switch strings.ToLower(fm.Get(key)) {
+	case "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+// unquote strips matching single or double quotes around a value
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// frontmatterField is a key of a generated frontmatter
+type frontmatterField struct {
+	key    string
+	values []string
+	list   bool // always written as an inline list
+}
+
+// formatFrontmatter renders fields as a "---" header, skipping empty ones
+func formatFrontmatter(fields []frontmatterField) string {
+	var sb strings.Builder
+	for _, f := range fields {
+		if len(f.values) == 0 || !f.list && f.values[0] == "" {
+			continue
+		}
+		if f.list {
+			quoted := make([]string, len(f.values))
+			for i, v := range f.values {
+				quoted[i] = yamlQuote(v)
+			}
+			sb.WriteString(f.key + ": [" + strings.Join(quoted, ", ") + "]\n")
+		} else {
+			sb.WriteString(f.key + ": " + yamlQuote(f.values[0]) + "\n")
+		}
+	}
+	if sb.Len() == 0 {
+		return ""
+	}
+	return "---\n" + sb.String() + "---\n"
+}
+
+// yamlQuote double-quotes values that would not read back as plain strings
+func yamlQuote(s string) string {
+	if s == "" || strings.ContainsAny(s, ":#,[]{}\"'&*!|>%@`") || strings.TrimSpace(s) != s {
+		return strconv.Quote(s)
+	}
+	return s
+}
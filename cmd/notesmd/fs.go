@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode"
 
 	blist "github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/glamour"
@@ -39,14 +40,20 @@ func readDir(dir string) []blist.Item {
 	return res
 }
 
+// lockedNoteMessage is shown in place of encrypted notes while locked
+const lockedNoteMessage = "🔒 Note chiffrée\n\nEntrée : saisir la phrase secrète pour l'afficher."
+
 // loadMarkdown loads and renders a Markdown file with word wrap
 func loadMarkdown(path string, width int) string {
-	data, err := os.ReadFile(path)
+	data, err := readNote(path)
+	if err == errNoteLocked {
+		return lockedNoteMessage
+	}
 	if err != nil {
 		return fmt.Sprintf("Erreur de lecture du fichier:\n%s\n\n%v", path, err)
 	}
 
-	if filepath.Ext(path) != ".md" {
+	if !isMarkdownNote(path) {
 		return string(data)
 	}
 
@@ -69,7 +76,7 @@ func loadMarkdown(path string, width int) string {
 
 // loadMarkdownRaw loads raw markdown content without rendering
 func loadMarkdownRaw(path string) string {
-	data, err := os.ReadFile(path)
+	data, err := readNote(path)
 	if err != nil {
 		return ""
 	}
@@ -78,14 +85,17 @@ func loadMarkdownRaw(path string) string {
 
 // loadMarkdownWithHighlight loads and renders markdown with search highlights
 func loadMarkdownWithHighlight(path string, query string, width int) string {
-	data, err := os.ReadFile(path)
+	data, err := readNote(path)
+	if err == errNoteLocked {
+		return lockedNoteMessage
+	}
 	if err != nil {
 		return fmt.Sprintf("Erreur de lecture du fichier:\n%s\n\n%v", path, err)
 	}
 
 	content := string(data)
 
-	if filepath.Ext(path) != ".md" {
+	if !isMarkdownNote(path) {
 		return highlightMatches(content, query)
 	}
 
@@ -179,37 +189,257 @@ func parseWikiLinks(content string) []string {
 	return links
 }
 
-// findNoteByName searches for a note file by name in rootDir and subdirectories
+// replaceWikiLinks rewrites every [[link]] and ![[embed]] outside code
+// blocks and code spans with the result of fn
+func replaceWikiLinks(content string, fn func(link string, embed bool) string) string {
+	var sb strings.Builder
+	var fence fenceState
+
+	lines := strings.SplitAfter(content, "\n")
+	for _, line := range lines {
+		inCode := fence.open
+		fence = fence.next(line)
+		if inCode || fence.open {
+			sb.WriteString(line)
+			continue
+		}
+
+		inSpan := false
+		for i := 0; i < len(line); i++ {
+			if line[i] == '`' {
+				inSpan = !inSpan
+			}
+			if !inSpan {
+				embed := strings.HasPrefix(line[i:], "![[")
+				start := i
+				if embed {
+					start++
+				}
+				if embed || strings.HasPrefix(line[i:], "[[") {
+					if end := strings.Index(line[start+2:], "]]"); end != -1 {
+						sb.WriteString(fn(strings.TrimSpace(line[start+2:start+2+end]), embed))
+						i = start + 2 + end + 1
+						continue
+					}
+				}
+			}
+			sb.WriteByte(line[i])
+		}
+	}
+	return sb.String()
+}
+
+// noteHeading is a Markdown heading found in a note
+type noteHeading struct {
+	level int
+	text  string
+	line  int // 0-based line number in the raw content
+}
+
+// parseHeadings extracts ATX headings from content, skipping fenced code
+func parseHeadings(content string) []noteHeading {
+	var headings []noteHeading
+	var fence fenceState
+
+	for i, line := range strings.Split(content, "\n") {
+		inCode := fence.open
+		fence = fence.next(line)
+		if inCode || fence.open {
+			continue
+		}
+
+		trimmed := strings.TrimLeft(line, " ")
+		level := headingLevel(trimmed)
+		if level == 0 {
+			continue
+		}
+
+		text := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(trimmed[level:]), "#"))
+		if text == "" {
+			continue
+		}
+		headings = append(headings, noteHeading{level: level, text: text, line: i})
+	}
+
+	return headings
+}
+
+// wikiLinkTarget returns the note name of a wiki link, without its
+// "#heading" anchor or "|alias"
+func wikiLinkTarget(link string) string {
+	link, _, _ = strings.Cut(link, "|")
+	link, _, _ = strings.Cut(link, "#")
+	return strings.TrimSpace(link)
+}
+
+// wikiLinkLabel returns the text displayed for a wiki link
+func wikiLinkLabel(link string) string {
+	if _, alias, found := strings.Cut(link, "|"); found && strings.TrimSpace(alias) != "" {
+		return strings.TrimSpace(alias)
+	}
+	return link
+}
+
+// parseTags extracts #tags from the note body and the frontmatter "tags" key
+func parseTags(content string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	add := func(tag string) {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if tag != "" && !seen[strings.ToLower(tag)] {
+			seen[strings.ToLower(tag)] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	fm, body := parseFrontmatter(content)
+	for _, tag := range fm["tags"] {
+		add(tag)
+	}
+
+	var fence fenceState
+	for _, line := range strings.Split(body, "\n") {
+		inCode := fence.open
+		fence = fence.next(line)
+		if inCode || fence.open {
+			continue
+		}
+
+		runes := []rune(line)
+		inInline := false
+		for i := 0; i < len(runes); i++ {
+			if runes[i] == '`' {
+				inInline = !inInline
+				continue
+			}
+			if inInline || runes[i] != '#' || (i > 0 && !unicode.IsSpace(runes[i-1])) {
+				continue
+			}
+
+			j := i + 1
+			for j < len(runes) && (isWordRune(runes[j]) || runes[j] == '-' || runes[j] == '/') {
+				j++
+			}
+			tag := string(runes[i+1 : j])
+			if strings.Trim(tag, "0123456789") != "" {
+				add(tag)
+			}
+			i = j - 1
+		}
+	}
+
+	return tags
+}
+
+// createNote writes a new note titled after its file name and returns its path
+func createNote(dir, name, content string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("le nom ne peut pas être vide")
+	}
+
+	// Add .md extension if not present
+	if filepath.Ext(name) == "" {
+		name += ".md"
+	}
+
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return "", fmt.Errorf("la note existe déjà : %s", name)
+	}
+
+	// Title derived from filename (without extension)
+	title := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	data := fmt.Sprintf("# %s\n\n%s\n", title, content)
+
+	// Content bringing its own header (frontmatter or title) is kept as is
+	if strings.HasPrefix(content, "---\n") || strings.HasPrefix(content, "# ") {
+		data = strings.TrimRight(content, "\n") + "\n"
+	}
+
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// findNoteByName searches for a note file by name in rootDir and
+// subdirectories. A name without extension matches a note, an encrypted
+// one when no plain note has that name.
 func findNoteByName(name string, rootDir string) string {
 	// Add .md extension if not present
+	encrypted := ""
 	if filepath.Ext(name) == "" {
 		name += ".md"
+		encrypted = name + encryptedExt
 	}
 
-	var foundPath string
+	var foundPath, encryptedPath string
 
 	// Walk through all files
-	filepath.WalkDir(rootDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return nil
-		}
-
+	walkVault(rootDir, func(path string, d os.DirEntry) error {
 		if d.IsDir() {
 			return nil
 		}
 
 		// Check if filename matches (case-insensitive)
-		if strings.EqualFold(filepath.Base(path), name) {
+		base := filepath.Base(path)
+		if strings.EqualFold(base, name) {
 			foundPath = path
 			return filepath.SkipAll // Stop searching once found
 		}
+		if encrypted != "" && encryptedPath == "" && strings.EqualFold(base, encrypted) {
+			encryptedPath = path
+		}
 
 		return nil
 	})
 
+	if foundPath == "" {
+		return encryptedPath
+	}
 	return foundPath
 }
 
+// walkNotes returns the Markdown notes under dir, sorted by path
+func walkNotes(dir string) []string {
+	var notes []string
+	walkVault(dir, func(path string, d os.DirEntry) error {
+		if d.IsDir() {
+			// Skip hidden directories such as .git
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(strings.ToLower(d.Name()), ".md") {
+			notes = append(notes, path)
+		}
+		return nil
+	})
+	return notes
+}
+
+// findBacklinks returns the notes linking to target with a wiki link
+func findBacklinks(target string, notes []string) []string {
+	name := noteLinkName(target)
+
+	var res []string
+	for _, path := range notes {
+		if path == target {
+			continue
+		}
+		for _, link := range parseWikiLinks(loadMarkdownRaw(path)) {
+			if strings.EqualFold(noteLinkName(wikiLinkTarget(link)), name) {
+				res = append(res, path)
+				break
+			}
+		}
+	}
+	return res
+}
+
 // convertWikiLinks converts [[Note]] to markdown links [Note](path)
 func convertWikiLinks(content string, rootDir string) string {
 	result := content
@@ -235,16 +465,17 @@ func convertWikiLinks(content string, rootDir string) string {
 
 		if linkText != "" {
 			// Find the actual file
-			notePath := findNoteByName(linkText, rootDir)
+			notePath := findNoteByName(wikiLinkTarget(linkText), rootDir)
+			label := wikiLinkLabel(linkText)
 
 			// Create markdown link with special marker for styling
 			var replacement string
 			if notePath != "" {
 				// Use emoji to make it stand out
-				replacement = fmt.Sprintf("🔗 [**%s**](%s)", linkText, notePath)
+				replacement = fmt.Sprintf("🔗 [**%s**](%s)", label, notePath)
 			} else {
 				// Non-existent note - different styling
-				replacement = fmt.Sprintf("🔗 [**%s**](#missing)", linkText)
+				replacement = fmt.Sprintf("🔗 [**%s**](#missing)", label)
 			}
 
 			// Replace [[...]] with markdown link
@@ -260,33 +491,42 @@ func convertWikiLinks(content string, rootDir string) string {
 
 // loadMarkdownWithLinks loads markdown and converts wiki-style links
 func loadMarkdownWithLinks(path string, rootDir string, width int) string {
-	data, err := os.ReadFile(path)
+	data, err := readNote(path)
+	if err == errNoteLocked {
+		return lockedNoteMessage
+	}
 	if err != nil {
 		return fmt.Sprintf("Erreur de lecture du fichier:\n%s\n\n%v", path, err)
 	}
 
 	content := string(data)
 
-	if filepath.Ext(path) != ".md" {
+	if !isMarkdownNote(path) {
 		return content
 	}
 
+	out, err := renderMarkdownWithLinks(content, rootDir, width, markdownTheme)
+	if err != nil {
+		return fmt.Sprintf("Erreur de rendu Markdown pour %s:\n%v", path, err)
+	}
+
+	return out
+}
+
+// renderMarkdownWithLinks converts wiki-style links and renders content
+// with the given Glamour style
+func renderMarkdownWithLinks(content string, rootDir string, width int, style string) (string, error) {
 	// Convert wiki links before rendering
 	contentWithLinks := convertWikiLinks(content, rootDir)
 
 	// Create renderer with word wrap
 	renderer, err := glamour.NewTermRenderer(
-		glamour.WithStylePath(markdownTheme),
+		glamour.WithStylePath(style),
 		glamour.WithWordWrap(width),
 	)
 	if err != nil {
-		return fmt.Sprintf("Erreur de création du renderer Markdown:\n%v", err)
-	}
-
-	out, err := renderer.Render(contentWithLinks)
-	if err != nil {
-		return fmt.Sprintf("Erreur de rendu Markdown pour %s:\n%v", path, err)
+		return "", err
 	}
 
-	return out
+	return renderer.Render(contentWithLinks)
 }
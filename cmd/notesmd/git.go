@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// gitState is the git status of a file, ordered by priority so a folder
+// shows the most important state of its content
+type gitState int
+
+const (
+	gitClean gitState = iota
+	gitUntracked
+	gitStaged
+	gitModified
+	gitConflicted
+)
+
+// marker returns the short flag shown after a file name
+func (s gitState) marker() string {
+	switch s {
+	case gitUntracked:
+		return "?"
+	case gitStaged:
+		return "+"
+	case gitModified:
+		return "M"
+	case gitConflicted:
+		return "!"
+	}
+	return ""
+}
+
+// label returns the state as shown in file descriptions
+func (s gitState) label() string {
+	switch s {
+	case gitUntracked:
+		return "non suivi"
+	case gitStaged:
+		return "indexé"
+	case gitModified:
+		return "modifié"
+	case gitConflicted:
+		return "conflit"
+	}
+	return ""
+}
+
+// gitEntry is a changed file of the vault
+type gitEntry struct {
+	path     string // absolute path
+	rel      string // path relative to the vault
+	index    byte   // staged change (porcelain X), '.' when none
+	worktree byte   // unstaged change (porcelain Y), '.' when none
+	state    gitState
+}
+
+// staged reports whether the entry has changes in the index
+func (e gitEntry) staged() bool {
+	return e.state != gitConflicted && e.state != gitUntracked && e.index != '.'
+}
+
+// gitStatus is a snapshot of the repository containing the vault
+type gitStatus struct {
+	branch   string
+	upstream string
+	ahead    int
+	behind   int
+	rebasing bool                // a pull --rebase stopped on conflicts
+	files    map[string]gitState // absolute path → state, folders included
+	entries  []gitEntry
+}
+
+// conflicts reports whether some files of the vault are conflicted
+func (g *gitStatus) conflicts() bool {
+	for _, e := range g.entries {
+		if e.state == gitConflicted {
+			return true
+		}
+	}
+	return false
+}
+
+// state returns the state of a file or folder of the vault
+func (g *gitStatus) state(path string) gitState {
+	if g == nil {
+		return gitClean
+	}
+	return g.files[path]
+}
+
+type gitStatusMsg struct {
+	status *gitStatus // nil when the vault is not in a git repository
+}
+
+// loadGitStatus reads the status of the repository at rootDir in the
+// background
+func loadGitStatus(rootDir string) tea.Cmd {
+	return func() tea.Msg {
+		status, _ := readGitStatus(rootDir)
+		return gitStatusMsg{status: status}
+	}
+}
+
+// readGitStatus runs git status for the repository containing rootDir
+func readGitStatus(rootDir string) (*gitStatus, error) {
+	// Status paths are relative to the repository root, the prefix locates
+	// rootDir inside it
+	revParse, err := exec.Command("git", "-C", rootDir, "rev-parse", "--show-prefix",
+		"--git-path", "rebase-merge", "--git-path", "rebase-apply").Output()
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimRight(string(revParse), "\n"), "\n")
+	if len(lines) != 3 {
+		return nil, fmt.Errorf("git rev-parse : sortie inattendue")
+	}
+
+	out, err := exec.Command("git", "-C", rootDir, "status",
+		"--porcelain=v2", "--branch", "--untracked-files=all", "-z").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	g := parseGitStatus(out, rootDir, strings.TrimSpace(lines[0]))
+	for _, dir := range lines[1:] {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(rootDir, dir)
+		}
+		if _, err := os.Stat(dir); err == nil {
+			g.rebasing = true
+		}
+	}
+	return g, nil
+}
+
+// parseGitStatus parses the output of git status --porcelain=v2 --branch -z
+func parseGitStatus(out []byte, rootDir, prefix string) *gitStatus {
+	g := &gitStatus{files: make(map[string]gitState)}
+	entries := bytes.Split(out, []byte{0})
+
+	for i := 0; i < len(entries); i++ {
+		entry := string(entries[i])
+		if entry == "" {
+			continue
+		}
+
+		var state gitState
+		var path string
+		xy := "??"
+		switch entry[0] {
+		case '#':
+			fields := strings.Fields(entry)
+			if len(fields) < 3 {
+				continue
+			}
+			switch fields[1] {
+			case "branch.head":
+				g.branch = fields[2]
+			case "branch.upstream":
+				g.upstream = fields[2]
+			case "branch.ab":
+				if len(fields) == 4 {
+					g.ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[2], "+"))
+					g.behind, _ = strconv.Atoi(strings.TrimPrefix(fields[3], "-"))
+				}
+			}
+			continue
+		case '1', '2':
+			// "1 XY sub mH mI mW hH hI path", renames add a score field and
+			// the original path as the next entry
+			n := 9
+			if entry[0] == '2' {
+				n = 10
+				i++
+			}
+			fields := strings.SplitN(entry, " ", n)
+			if len(fields) < n {
+				continue
+			}
+			xy = fields[1]
+			if xy[1] != '.' {
+				state = gitModified
+			} else {
+				state = gitStaged
+			}
+			path = fields[n-1]
+		case 'u':
+			fields := strings.SplitN(entry, " ", 11)
+			if len(fields) < 11 {
+				continue
+			}
+			state, path, xy = gitConflicted, fields[10], fields[1]
+		case '?':
+			state, path = gitUntracked, entry[2:]
+		default:
+			continue
+		}
+
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		rel := strings.TrimSuffix(path[len(prefix):], "/")
+		g.mark(rootDir, rel, state)
+		g.entries = append(g.entries, gitEntry{
+			path:     filepath.Join(rootDir, filepath.FromSlash(rel)),
+			rel:      filepath.FromSlash(rel),
+			index:    xy[0],
+			worktree: xy[1],
+			state:    state,
+		})
+	}
+	return g
+}
+
+// mark records the state of rel and propagates it to its parent folders
+func (g *gitStatus) mark(rootDir, rel string, state gitState) {
+	for rel != "." && rel != "" {
+		path := filepath.Join(rootDir, filepath.FromSlash(rel))
+		if g.files[path] < state {
+			g.files[path] = state
+		}
+		rel = filepath.ToSlash(filepath.Dir(filepath.FromSlash(rel)))
+	}
+}
+
+// refreshGit reloads the git status of the vault
+func (m *model) refreshGit() tea.Cmd {
+	if m.rootDir == "" {
+		return nil
+	}
+	return loadGitStatus(m.rootDir)
+}
+
+// notesChanged refreshes the git status after a write and schedules the
+// auto-commit when it is enabled
+func (m *model) notesChanged() tea.Cmd {
+	// A saved .gitignore or .notesmdignore applies right away
+	m.ignore = setVaultIgnore(m.rootDir, m.config)
+	cmds := []tea.Cmd{m.refreshGit()}
+	if m.git != nil && m.config != nil && m.config.Git.AutoCommit {
+		// Each save restarts the timer, only the last tick commits
+		m.autoCommitSeq++
+		seq := m.autoCommitSeq
+		cmds = append(cmds, tea.Tick(m.config.Git.autoCommitDelay(), func(time.Time) tea.Msg {
+			return autoCommitMsg{seq: seq}
+		}))
+	}
+	return tea.Batch(cmds...)
+}
+
+type autoCommitMsg struct {
+	seq int
+}
+
+// gitOpMsg reports the end of a git operation
+type gitOpMsg struct {
+	op     string
+	output string
+	err    error
+}
+
+// runGit runs git in rootDir and returns its combined output
+func runGit(rootDir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", rootDir}, args...)...)
+	// Never block the interface on a credential prompt or an editor
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_EDITOR=true")
+	out, err := cmd.CombinedOutput()
+	output := strings.TrimSpace(string(out))
+	if err != nil {
+		if output == "" {
+			output = err.Error()
+		}
+		return output, fmt.Errorf("git %s : %s", args[0], lastLine(output))
+	}
+	return output, nil
+}
+
+// lastLine returns the last non-empty line of s
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// gitOperation runs git commands in sequence, stopping at the first error
+func gitOperation(rootDir, op string, steps ...[]string) tea.Cmd {
+	return func() tea.Msg {
+		var outputs []string
+		for _, args := range steps {
+			out, err := runGit(rootDir, args...)
+			if out != "" {
+				outputs = append(outputs, out)
+			}
+			if err != nil {
+				return gitOpMsg{op: op, output: strings.Join(outputs, "\n"), err: err}
+			}
+		}
+		return gitOpMsg{op: op, output: strings.Join(outputs, "\n")}
+	}
+}
+
+// autoCommit stages every change of the vault and commits it with a
+// generated message. It does nothing while conflicts are pending.
+func autoCommit(rootDir string) tea.Cmd {
+	return func() tea.Msg {
+		status, err := readGitStatus(rootDir)
+		if err != nil || status.rebasing || status.conflicts() {
+			return gitOpMsg{op: "auto-commit"}
+		}
+		return commitAll(rootDir, "auto-commit", "")
+	}
+}
+
+// commitAll stages every change of the vault and commits it. An empty
+// message is generated from the staged files; nothing is committed when
+// there is no change.
+func commitAll(rootDir, op, message string) gitOpMsg {
+	msg := gitOpMsg{op: op}
+	if _, msg.err = runGit(rootDir, "add", "-A", "--", "."); msg.err != nil {
+		return msg
+	}
+	if message == "" {
+		var status *gitStatus
+		if status, msg.err = readGitStatus(rootDir); msg.err != nil {
+			return msg
+		}
+		if message = defaultCommitMessage(status.entries); message == "" {
+			return msg
+		}
+	}
+	msg.output, msg.err = runGit(rootDir, "commit", "-m", message)
+	return msg
+}
+
+// defaultCommitMessage summarises the staged notes, "" when nothing is staged
+func defaultCommitMessage(entries []gitEntry) string {
+	var names []string
+	added, deleted := 0, 0
+	for _, e := range entries {
+		if !e.staged() {
+			continue
+		}
+		switch e.index {
+		case 'A':
+			added++
+		case 'D':
+			deleted++
+		}
+		names = append(names, strings.TrimSuffix(filepath.Base(e.rel), ".md"))
+	}
+	if len(names) == 0 {
+		return ""
+	}
+
+	verb := "Mise à jour de "
+	switch len(names) {
+	case added:
+		verb = "Ajout de "
+	case deleted:
+		verb = "Suppression de "
+	}
+	if len(names) == 1 {
+		return verb + names[0]
+	}
+
+	list := strings.Join(names, ", ")
+	if len(names) > 3 {
+		others := "autres"
+		if len(names) == 4 {
+			others = "autre"
+		}
+		list = fmt.Sprintf("%s et %d %s", strings.Join(names[:3], ", "), len(names)-3, others)
+	}
+	return fmt.Sprintf("%s%d notes : %s", verb, len(names), list)
+}
+
+// conflictSides names the two versions of a conflicted file. During a
+// rebase "ours" is the upstream branch and "theirs" the local commits.
+func conflictSides(rebasing bool) (ours, theirs string) {
+	if rebasing {
+		return "distante", "locale"
+	}
+	return "locale", "distante"
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseGitStatus(t *testing.T) {
+	root := filepath.FromSlash("/vault")
+	out := strings.Join([]string{
+		"# branch.oid 0123456789abcdef",
+		"# branch.head main",
+		"# branch.upstream origin/main",
+		"# branch.ab +2 -1",
+		"1 .M N... 100644 100644 100644 aaa bbb notes/projets/plan.md",
+		"1 M. N... 100644 100644 100644 aaa bbb notes/index.md",
+		"2 R. N... 100644 100644 100644 aaa bbb R100 notes/new name.md",
+		"notes/old.md",
+		"u UU N... 100644 100644 100644 100644 aaa bbb ccc notes/projets/conflit.md",
+		"? notes/brouillon.md",
+		"? outside.md",
+		"",
+	}, "\x00")
+
+	g := parseGitStatus([]byte(out), root, "notes/")
+	if g.branch != "main" || g.upstream != "origin/main" || g.ahead != 2 || g.behind != 1 {
+		t.Errorf("branch = %q %q +%d -%d", g.branch, g.upstream, g.ahead, g.behind)
+	}
+
+	tests := map[string]gitState{
+		"projets/plan.md":    gitModified,
+		"index.md":           gitStaged,
+		"new name.md":        gitStaged,
+		"projets/conflit.md": gitConflicted,
+		"projets":            gitConflicted,
+		"brouillon.md":       gitUntracked,
+		"old.md":             gitClean,
+		"../outside.md":      gitClean,
+	}
+	for rel, want := range tests {
+		if got := g.state(filepath.Join(root, filepath.FromSlash(rel))); got != want {
+			t.Errorf("state(%s) = %v, want %v", rel, got, want)
+		}
+	}
+}
+
+// gitRepos creates a bare remote and two clones sharing a first commit
+func gitRepos(t *testing.T) (local, other string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git introuvable")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, v := range []string{"GIT_AUTHOR", "GIT_COMMITTER"} {
+		t.Setenv(v+"_NAME", "Test")
+		t.Setenv(v+"_EMAIL", "test@example.com")
+	}
+
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	local = filepath.Join(dir, "local")
+	other = filepath.Join(dir, "other")
+	mustGit(t, dir, "init", "-q", "--bare", remote)
+	mustGit(t, dir, "clone", "-q", remote, local)
+
+	os.WriteFile(filepath.Join(local, "note.md"), []byte("# Note\n\nligne\n"), 0644)
+	mustGit(t, local, "add", "-A")
+	mustGit(t, local, "commit", "-q", "-m", "init")
+	mustGit(t, local, "push", "-q", "-u", "origin", "HEAD")
+	mustGit(t, dir, "clone", "-q", remote, other)
+	return local, other
+}
+
+func mustGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	if out, err := runGit(dir, args...); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func runGitOp(t *testing.T, cmd tea.Cmd) gitOpMsg {
+	t.Helper()
+	return cmd().(gitOpMsg)
+}
+
+func TestGitPullConflict(t *testing.T) {
+	local, other := gitRepos(t)
+
+	os.WriteFile(filepath.Join(other, "note.md"), []byte("# Note\n\ndistant\n"), 0644)
+	mustGit(t, other, "commit", "-q", "-am", "distant")
+	mustGit(t, other, "push", "-q")
+
+	os.WriteFile(filepath.Join(local, "note.md"), []byte("# Note\n\nlocal\n"), 0644)
+	if msg := runGitOp(t, autoCommit(local)); msg.err != nil || msg.output == "" {
+		t.Fatalf("auto-commit: %v %q", msg.err, msg.output)
+	}
+	if out, _ := runGit(local, "log", "-1", "--format=%s"); out != "Mise à jour de note" {
+		t.Errorf("commit message = %q", out)
+	}
+
+	if msg := runGitOp(t, gitOperation(local, "pull", []string{"pull", "--rebase"})); msg.err == nil {
+		t.Fatal("pull should stop on the conflict")
+	}
+	status, err := readGitStatus(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	note := filepath.Join(local, "note.md")
+	if !status.rebasing || status.state(note) != gitConflicted {
+		t.Fatalf("rebasing = %v, state = %v", status.rebasing, status.state(note))
+	}
+
+	// Auto-commit waits for the conflict to be resolved
+	if msg := runGitOp(t, autoCommit(local)); msg.output != "" || msg.err != nil {
+		t.Errorf("auto-commit during rebase: %v %q", msg.err, msg.output)
+	}
+
+	// Keep the local version ("theirs" during a rebase), then push
+	msg := runGitOp(t, gitOperation(local, "rebase",
+		[]string{"checkout", "--theirs", "--", "note.md"},
+		[]string{"add", "--", "note.md"},
+		[]string{"rebase", "--continue"},
+		[]string{"push"}))
+	if msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	if data, _ := os.ReadFile(note); !strings.Contains(string(data), "local") {
+		t.Errorf("note = %q", data)
+	}
+	status, _ = readGitStatus(local)
+	if status.rebasing || len(status.entries) != 0 || status.ahead != 0 || status.behind != 0 {
+		t.Errorf("status after push = %+v", status)
+	}
+}
+
+func TestDefaultCommitMessage(t *testing.T) {
+	entry := func(rel string, x byte) gitEntry {
+		return gitEntry{rel: rel, index: x, worktree: '.', state: gitStaged}
+	}
+	tests := []struct {
+		entries []gitEntry
+		want    string
+	}{
+		{nil, ""},
+		{[]gitEntry{{rel: "a.md", index: '?', worktree: '?', state: gitUntracked}}, ""},
+		{[]gitEntry{entry("projets/plan.md", 'M')}, "Mise à jour de plan"},
+		{[]gitEntry{entry("a.md", 'A'), entry("b.md", 'A')}, "Ajout de 2 notes : a, b"},
+		{[]gitEntry{entry("a.md", 'D'), entry("b.md", 'M'), entry("c.md", 'M'), entry("d.md", 'M')},
+			"Mise à jour de 4 notes : a, b, c et 1 autre"},
+	}
+	for _, tt := range tests {
+		if got := defaultCommitMessage(tt.entries); got != tt.want {
+			t.Errorf("defaultCommitMessage = %q, want %q", got, tt.want)
+		}
+	}
+}
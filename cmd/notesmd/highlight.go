@@ -0,0 +1,317 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Chroma style used for fenced code blocks in the inline editor
+const codeHighlightStyle = "monokai"
+
+// Editor highlight styles
+var (
+	mdPlainStyle = lipgloss.NewStyle()
+
+	mdHeadingStyles = []lipgloss.Style{
+		lipgloss.NewStyle().Foreground(lipgloss.Color("208")).Bold(true),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("213")).Bold(true),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("81")).Bold(true),
+		lipgloss.NewStyle().Foreground(lipgloss.Color("117")).Bold(true),
+	}
+
+	mdBoldStyle      = lipgloss.NewStyle().Bold(true)
+	mdItalicStyle    = lipgloss.NewStyle().Italic(true)
+	mdStrikeStyle    = lipgloss.NewStyle().Strikethrough(true).Faint(true)
+	mdCodeStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+	mdFenceStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("244"))
+	mdQuoteStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("244")).Italic(true)
+	mdMarkerStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("208")).Bold(true)
+	mdTodoStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true)
+	mdDoneStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("46")).Bold(true)
+	mdDoneTextStyle  = lipgloss.NewStyle().Faint(true).Strikethrough(true)
+	mdWikiLinkStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("81")).Underline(true)
+	mdLinkTextStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("81"))
+	mdLinkURLStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	mdCodeBlockStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
+)
+
+// highlightedLine maps each rune of a source line to a style in its palette
+type highlightedLine struct {
+	runes   []rune
+	classes []int
+	palette []lipgloss.Style
+}
+
+// newHighlightedLine returns an unstyled line
+func newHighlightedLine(line string) highlightedLine {
+	runes := []rune(line)
+	return highlightedLine{
+		runes:   runes,
+		classes: make([]int, len(runes)),
+		palette: []lipgloss.Style{mdPlainStyle},
+	}
+}
+
+// fill applies a style to the rune range [start, end)
+func (h *highlightedLine) fill(start, end int, style lipgloss.Style) {
+	start = max(start, 0)
+	end = min(end, len(h.runes))
+	if start >= end {
+		return
+	}
+	h.palette = append(h.palette, style)
+	class := len(h.palette) - 1
+	for i := start; i < end; i++ {
+		h.classes[i] = class
+	}
+}
+
+// Columns a tab takes in the inline editor
+const editorTabWidth = 4
+
+// render renders the rune range [start, end), reversing the rune at cursor
+func (h highlightedLine) render(start, end, cursor int) string {
+	var b strings.Builder
+	for i := start; i < end; {
+		j := i + 1
+		if i != cursor {
+			for j < end && j != cursor && h.classes[j] == h.classes[i] {
+				j++
+			}
+		}
+		style := h.palette[h.classes[i]]
+		if i == cursor {
+			style = style.Reverse(true)
+		}
+		text := strings.ReplaceAll(string(h.runes[i:j]), "\t", strings.Repeat(" ", editorTabWidth))
+		b.WriteString(style.Render(text))
+		i = j
+	}
+	return b.String()
+}
+
+// fenceState tracks whether a line sits inside a fenced code block
+type fenceState struct {
+	open   bool
+	marker string // ``` or ~~~
+	lang   string
+}
+
+// next returns the fence state after the given line
+func (f fenceState) next(line string) fenceState {
+	trimmed := strings.TrimSpace(line)
+	if f.open {
+		if strings.HasPrefix(trimmed, f.marker) && strings.Trim(trimmed, f.marker[:1]) == "" {
+			return fenceState{}
+		}
+		return f
+	}
+	for _, marker := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmed, marker) {
+			lang := strings.TrimSpace(strings.TrimLeft(trimmed, marker[:1]))
+			if i := strings.IndexAny(lang, " {"); i >= 0 {
+				lang = lang[:i]
+			}
+			return fenceState{open: true, marker: marker, lang: lang}
+		}
+	}
+	return f
+}
+
+// isFenceLine reports whether the line opens or closes a code fence
+func isFenceLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// highlightMarkdownLine highlights a single line given the fence state before it
+func highlightMarkdownLine(line string, state fenceState) highlightedLine {
+	h := newHighlightedLine(line)
+
+	if isFenceLine(line) && (!state.open || state.next(line) != state) {
+		h.fill(0, len(h.runes), mdFenceStyle)
+		return h
+	}
+
+	if state.open {
+		highlightCode(&h, state.lang)
+		return h
+	}
+
+	trimmed := strings.TrimLeft(line, " ")
+	indent := len(h.runes) - len([]rune(trimmed))
+
+	// Headings
+	if level := headingLevel(trimmed); level > 0 {
+		style := mdHeadingStyles[min(level, len(mdHeadingStyles))-1]
+		h.fill(0, len(h.runes), style)
+		return h
+	}
+
+	// Blockquotes
+	if strings.HasPrefix(trimmed, ">") {
+		h.fill(0, len(h.runes), mdQuoteStyle)
+		return h
+	}
+
+	// List markers and task checkboxes
+	bodyStart := indent
+	if item, ok := parseListItem(line); ok {
+		markerEnd := len([]rune(item.indent + item.marker))
+		h.fill(indent, markerEnd, mdMarkerStyle)
+		bodyStart = markerEnd
+		if item.checkbox != "" {
+			boxEnd := markerEnd + len([]rune(item.checkbox))
+			if item.checked() {
+				h.fill(markerEnd, boxEnd, mdDoneStyle)
+				h.fill(boxEnd, len(h.runes), mdDoneTextStyle)
+				return h
+			}
+			h.fill(markerEnd, boxEnd, mdTodoStyle)
+			bodyStart = boxEnd
+		}
+	}
+
+	highlightInline(&h, bodyStart)
+	return h
+}
+
+// headingLevel returns the ATX heading level of a line, or 0
+func headingLevel(line string) int {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0
+	}
+	if level < len(line) && line[level] != ' ' {
+		return 0
+	}
+	return level
+}
+
+// highlightInline highlights inline spans (code, emphasis, links) from start
+func highlightInline(h *highlightedLine, start int) {
+	r := h.runes
+	for i := start; i < len(r); i++ {
+		switch {
+		case r[i] == '`':
+			if end := indexRune(r, '`', i+1); end > 0 {
+				h.fill(i, end+1, mdCodeStyle)
+				i = end
+			}
+
+		case r[i] == '[' && i+1 < len(r) && r[i+1] == '[':
+			if end := indexRunes(r, "]]", i+2); end > 0 {
+				h.fill(i, end+2, mdWikiLinkStyle)
+				i = end + 1
+			}
+
+		case r[i] == '[':
+			closeText := indexRune(r, ']', i+1)
+			if closeText > 0 && closeText+1 < len(r) && r[closeText+1] == '(' {
+				if closeURL := indexRune(r, ')', closeText+2); closeURL > 0 {
+					h.fill(i, closeText+1, mdLinkTextStyle)
+					h.fill(closeText+1, closeURL+1, mdLinkURLStyle)
+					i = closeURL
+				}
+			}
+
+		case r[i] == '~' && i+1 < len(r) && r[i+1] == '~':
+			if end := indexRunes(r, "~~", i+2); end > 0 {
+				h.fill(i, end+2, mdStrikeStyle)
+				i = end + 1
+			}
+
+		case (r[i] == '*' || r[i] == '_') && i+1 < len(r) && r[i+1] == r[i]:
+			if end := indexRunes(r, string([]rune{r[i], r[i]}), i+2); end > 0 {
+				h.fill(i, end+2, mdBoldStyle)
+				i = end + 1
+			}
+
+		case r[i] == '*' || r[i] == '_':
+			// Ignore intra-word underscores like snake_case
+			if r[i] == '_' && i > 0 && isWordRune(r[i-1]) {
+				continue
+			}
+			if end := indexRune(r, r[i], i+1); end > i+1 {
+				h.fill(i, end+1, mdItalicStyle)
+				i = end
+			}
+		}
+	}
+}
+
+// highlightCode colors a line of a fenced block with chroma
+func highlightCode(h *highlightedLine, lang string) {
+	h.fill(0, len(h.runes), mdCodeBlockStyle)
+
+	lexer := lexers.Get(lang)
+	if lang == "" || lexer == nil {
+		return
+	}
+	lexer = chroma.Coalesce(lexer)
+	style := styles.Get(codeHighlightStyle)
+
+	it, err := lexer.Tokenise(nil, string(h.runes))
+	if err != nil {
+		return
+	}
+
+	pos := 0
+	for tok := it(); tok != chroma.EOF; tok = it() {
+		n := len([]rune(tok.Value))
+		entry := style.Get(tok.Type)
+		if entry.Colour.IsSet() {
+			s := lipgloss.NewStyle().Foreground(lipgloss.Color(entry.Colour.String()))
+			if entry.Bold == chroma.Yes {
+				s = s.Bold(true)
+			}
+			if entry.Italic == chroma.Yes {
+				s = s.Italic(true)
+			}
+			h.fill(pos, pos+n, s)
+		}
+		pos += n
+		if pos >= len(h.runes) {
+			break
+		}
+	}
+}
+
+// indexRune finds r in runes starting at from, or -1
+func indexRune(runes []rune, r rune, from int) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexRunes finds the string s in runes starting at from, or -1
+func indexRunes(runes []rune, s string, from int) int {
+	needle := []rune(s)
+	for i := from; i+len(needle) <= len(runes); i++ {
+		match := true
+		for j, r := range needle {
+			if runes[i+j] != r {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 127
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// vaultDataDir is the hidden folder NotesMD keeps inside a vault
+const vaultDataDir = ".notesmd"
+
+// Snapshots kept per note by the local history store
+const maxSnapshots = 50
+
+const snapshotLayout = "20060102T150405.000000000Z"
+
+// noteVersion is a previous version of a note
+type noteVersion struct {
+	id      string // commit hash, or snapshot file path
+	time    time.Time
+	author  string
+	summary string
+	gitPath string // path of the note at that commit, relative to the repository
+}
+
+// snapshotDir returns the folder holding the snapshots of a note
+func snapshotDir(rootDir, path string) (string, error) {
+	rel, err := filepath.Rel(rootDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", fmt.Errorf("note hors du vault : %s", path)
+	}
+	return filepath.Join(rootDir, vaultDataDir, "history", rel), nil
+}
+
+// writeSnapshot stores the current content of a note before it is
+// overwritten, unless the latest snapshot already holds it
+func writeSnapshot(rootDir, path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	dir, err := snapshotDir(rootDir, path)
+	if err != nil {
+		return err
+	}
+
+	snapshots, _ := listSnapshots(rootDir, path)
+	if len(snapshots) > 0 {
+		if latest, err := os.ReadFile(snapshots[0].id); err == nil && bytes.Equal(latest, data) {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := time.Now().UTC().Format(snapshotLayout) + ".md"
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		return err
+	}
+
+	// Drop the oldest snapshots
+	for i := maxSnapshots - 1; i < len(snapshots); i++ {
+		os.Remove(snapshots[i].id)
+	}
+	return nil
+}
+
+// listSnapshots returns the snapshots of a note, newest first
+func listSnapshots(rootDir, path string) ([]noteVersion, error) {
+	dir, err := snapshotDir(rootDir, path)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var versions []noteVersion
+	for _, e := range entries {
+		t, err := time.Parse(snapshotLayout, strings.TrimSuffix(e.Name(), ".md"))
+		if e.IsDir() || err != nil {
+			continue
+		}
+		versions = append(versions, noteVersion{
+			id:      filepath.Join(dir, e.Name()),
+			time:    t,
+			summary: "Sauvegarde",
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].time.After(versions[j].time)
+	})
+	return versions, nil
+}
+
+// gitNoteHistory lists the commits touching a note, following renames
+func gitNoteHistory(rootDir, path string) ([]noteVersion, error) {
+	rel, err := filepath.Rel(rootDir, path)
+	if err != nil {
+		return nil, err
+	}
+	out, err := runGit(rootDir, "log", "--follow", "--name-only",
+		"--format=%x1e%H%x1f%at%x1f%an%x1f%s", "--", rel)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []noteVersion
+	for _, record := range strings.Split(out, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		fields := strings.Split(lines[0], "\x1f")
+		if len(fields) != 4 || len(lines) < 2 {
+			continue
+		}
+		unix, _ := strconv.ParseInt(fields[1], 10, 64)
+		versions = append(versions, noteVersion{
+			id:      fields[0],
+			time:    time.Unix(unix, 0),
+			author:  fields[2],
+			summary: fields[3],
+			gitPath: strings.TrimSpace(lines[len(lines)-1]),
+		})
+	}
+	return versions, nil
+}
+
+// noteHistory lists the previous versions of a note, from git when the
+// vault is a repository and from the snapshot store otherwise
+func noteHistory(rootDir, path string, useGit bool) ([]noteVersion, error) {
+	if useGit {
+		return gitNoteHistory(rootDir, path)
+	}
+	return listSnapshots(rootDir, path)
+}
+
+// versionContent returns the content of a note at a version
+func versionContent(rootDir string, v noteVersion) (string, error) {
+	if v.gitPath == "" {
+		data, err := os.ReadFile(v.id)
+		return string(data), err
+	}
+	// rev:path resolves path from the repository root. The output is read
+	// as is, runGit would trim the final newline.
+	out, err := exec.Command("git", "-C", rootDir, "show", v.id+":"+v.gitPath).Output()
+	if err != nil {
+		return "", fmt.Errorf("git show : %w", err)
+	}
+	return string(out), nil
+}
+
+// diffOp is a line of a line-based diff
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+// Above this many line pairs, diffLines falls back to a whole-file diff
+const maxDiffCells = 4_000_000
+
+// diffLines computes a line diff from a to b using the longest common
+// subsequence
+func diffLines(a, b []string) []diffOp {
+	// Common prefix and suffix are cheap to strip first
+	start := 0
+	for start < len(a) && start < len(b) && a[start] == b[start] {
+		start++
+	}
+	endA, endB := len(a), len(b)
+	for endA > start && endB > start && a[endA-1] == b[endB-1] {
+		endA--
+		endB--
+	}
+
+	var ops []diffOp
+	for _, line := range a[:start] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	midA, midB := a[start:endA], b[start:endB]
+	if len(midA)*len(midB) > maxDiffCells {
+		for _, line := range midA {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range midB {
+			ops = append(ops, diffOp{'+', line})
+		}
+	} else {
+		// lcs[i][j] is the LCS length of midA[i:] and midB[j:]
+		lcs := make([][]int, len(midA)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(midB)+1)
+		}
+		for i := len(midA) - 1; i >= 0; i-- {
+			for j := len(midB) - 1; j >= 0; j-- {
+				if midA[i] == midB[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(midA) || j < len(midB) {
+			switch {
+			case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+				ops = append(ops, diffOp{' ', midA[i]})
+				i++
+				j++
+			case i < len(midA) && (j == len(midB) || lcs[i+1][j] >= lcs[i][j+1]):
+				ops = append(ops, diffOp{'-', midA[i]})
+				i++
+			default:
+				ops = append(ops, diffOp{'+', midB[j]})
+				j++
+			}
+		}
+	}
+
+	for _, line := range a[endA:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// renderDiff renders the changes from old to current as colored unified
+// diff hunks with a few lines of context
+func renderDiff(old, current string) string {
+	const context = 3
+	ops := diffLines(strings.Split(old, "\n"), strings.Split(current, "\n"))
+
+	removed := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+	added := lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	hunk := lipgloss.NewStyle().Foreground(lipgloss.Color("81"))
+
+	// Keep changed lines and their context
+	keep := make([]bool, len(ops))
+	changes := 0
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		changes++
+		for k := max(i-context, 0); k <= min(i+context, len(ops)-1); k++ {
+			keep[k] = true
+		}
+	}
+	if changes == 0 {
+		return helpStyle.Render("Identique à la version actuelle.")
+	}
+
+	var lines []string
+	oldLine, newLine := 1, 1
+	for i, op := range ops {
+		if keep[i] && (i == 0 || !keep[i-1]) {
+			lines = append(lines, hunk.Render(fmt.Sprintf("@@ ligne %d → %d @@", oldLine, newLine)))
+		}
+		if keep[i] {
+			switch op.kind {
+			case '-':
+				lines = append(lines, removed.Render("- "+op.text))
+			case '+':
+				lines = append(lines, added.Render("+ "+op.text))
+			default:
+				lines = append(lines, "  "+op.text)
+			}
+		}
+		if op.kind != '+' {
+			oldLine++
+		}
+		if op.kind != '-' {
+			newLine++
+		}
+	}
+	return strings.Join(lines, "\n")
+}
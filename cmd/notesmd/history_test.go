@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	a := strings.Split("a\nb\nc\nd", "\n")
+	b := strings.Split("a\nc\nx\nd", "\n")
+
+	var got []string
+	for _, op := range diffLines(a, b) {
+		got = append(got, string(op.kind)+op.text)
+	}
+	want := " a -b  c +x  d"
+	if strings.Join(got, " ") != want {
+		t.Errorf("diffLines = %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestSnapshotHistory(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "projets", "note.md")
+	os.MkdirAll(filepath.Dir(path), 0755)
+
+	for _, content := range []string{"v1\n", "v1\n", "v2\n"} {
+		os.WriteFile(path, []byte(content), 0644)
+		if err := writeSnapshot(root, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	versions, err := noteHistory(root, path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("got %d snapshots, want 2 (identical content is stored once)", len(versions))
+	}
+	if content, _ := versionContent(root, versions[0]); content != "v2\n" {
+		t.Errorf("latest snapshot = %q", content)
+	}
+	if content, _ := versionContent(root, versions[1]); content != "v1\n" {
+		t.Errorf("oldest snapshot = %q", content)
+	}
+}
+
+func TestGitNoteHistory(t *testing.T) {
+	local, _ := gitRepos(t)
+	vault := filepath.Join(local, "vault")
+	os.MkdirAll(vault, 0755)
+
+	// The note is renamed into the vault, history follows it
+	mustGit(t, local, "mv", "note.md", "vault/note.md")
+	mustGit(t, local, "commit", "-q", "-m", "déplacement")
+	path := filepath.Join(vault, "note.md")
+	os.WriteFile(path, []byte("# Note\n\nmodifiée\n"), 0644)
+	mustGit(t, local, "commit", "-q", "-am", "modification")
+
+	versions, err := noteHistory(vault, path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summaries []string
+	for _, v := range versions {
+		summaries = append(summaries, v.summary)
+	}
+	if got := strings.Join(summaries, ","); got != "modification,déplacement,init" {
+		t.Fatalf("summaries = %q", got)
+	}
+	if content, err := versionContent(vault, versions[2]); err != nil || content != "# Note\n\nligne\n" {
+		t.Errorf("first version = %q, %v", content, err)
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// htmlNode is an element or text node of a parsed HTML fragment
+type htmlNode struct {
+	tag      string // lowercase element name, "" for text
+	attrs    map[string]string
+	text     string
+	children []*htmlNode
+}
+
+func (n *htmlNode) attr(name string) string {
+	return n.attrs[name]
+}
+
+// parseHTMLFragment parses XHTML, ENML or reasonably well-formed HTML
+func parseHTMLFragment(src string) (*htmlNode, error) {
+	dec := xml.NewDecoder(strings.NewReader(src))
+	dec.Strict = false
+	dec.AutoClose = xml.HTMLAutoClose
+	dec.Entity = xml.HTMLEntity
+
+	root := &htmlNode{tag: "root"}
+	stack := []*htmlNode{root}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return root, err
+		}
+
+		parent := stack[len(stack)-1]
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			n := &htmlNode{tag: strings.ToLower(tok.Name.Local), attrs: make(map[string]string)}
+			for _, a := range tok.Attr {
+				n.attrs[strings.ToLower(a.Name.Local)] = a.Value
+			}
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			// Close up to the matching element, tolerating unclosed tags
+			name := strings.ToLower(tok.Name.Local)
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].tag == name {
+					stack = stack[:i]
+					break
+				}
+			}
+		case xml.CharData:
+			parent.children = append(parent.children, &htmlNode{text: string(tok)})
+		}
+	}
+	return root, nil
+}
+
+// htmlBlockTags are elements rendered as separate blocks
+var htmlBlockTags = map[string]bool{
+	"root": true, "html": true, "body": true, "en-note": true, "div": true, "p": true,
+	"section": true, "article": true, "header": true, "footer": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "blockquote": true, "pre": true,
+	"table": true, "tr": true, "td": true, "th": true, "hr": true,
+}
+
+var (
+	spaceRun    = regexp.MustCompile(`[ \t\r\n]+`)
+	blankLines  = regexp.MustCompile(`\n{3,}`)
+	mdSpecials  = strings.NewReplacer(`\`, `\\`, "*", `\*`, "`", "\\`", "[", `\[`, "]", `\]`)
+	tableEscape = strings.NewReplacer("|", `\|`, "\n", " ")
+)
+
+// htmlConverter turns parsed HTML into Markdown
+type htmlConverter struct {
+	// media renders <img> and <en-media> elements
+	media func(n *htmlNode) string
+	// skipped collects the elements that could not be converted
+	skipped []string
+}
+
+// htmlToMarkdown converts an HTML or ENML document to Markdown
+func htmlToMarkdown(src string, c *htmlConverter) (string, error) {
+	root, err := parseHTMLFragment(src)
+	out := c.render(root)
+	out = blankLines.ReplaceAllString(out, "\n\n")
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+		}
+		if !inFence {
+			lines[i] = strings.TrimRight(line, " \t")
+		}
+	}
+	return strings.Join(lines, "\n") + "\n", err
+}
+
+// children renders the children of n, dropping layout whitespace in blocks
+func (c *htmlConverter) children(n *htmlNode) string {
+	var sb strings.Builder
+	for _, child := range n.children {
+		if child.tag == "" && htmlBlockTags[n.tag] && strings.TrimSpace(child.text) == "" {
+			continue
+		}
+		sb.WriteString(c.render(child))
+	}
+	return sb.String()
+}
+
+// block wraps content as a Markdown block
+func block(content string) string {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return ""
+	}
+	return "\n\n" + content + "\n\n"
+}
+
+// wrapInline surrounds trimmed content with a Markdown marker
+func wrapInline(content, marker string) string {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return content
+	}
+	lead := content[:len(content)-len(strings.TrimLeft(content, " "))]
+	trail := content[len(strings.TrimRight(content, " ")):]
+	return lead + marker + trimmed + marker + trail
+}
+
+func (c *htmlConverter) render(n *htmlNode) string {
+	if n.tag == "" {
+		return mdSpecials.Replace(spaceRun.ReplaceAllString(n.text, " "))
+	}
+
+	// Evernote code blocks are styled divs
+	if n.tag == "div" && strings.Contains(n.attr("style"), "-en-codeblock") {
+		return codeBlock(rawText(n))
+	}
+
+	switch n.tag {
+	case "head", "title", "script", "style":
+		return ""
+	case "br":
+		return "\\\n"
+	case "hr":
+		return "\n\n---\n\n"
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		text := strings.TrimSpace(strings.ReplaceAll(c.children(n), "\\\n", " "))
+		return block(strings.Repeat("#", int(n.tag[1]-'0')) + " " + text)
+	case "b", "strong":
+		return wrapInline(c.children(n), "**")
+	case "i", "em":
+		return wrapInline(c.children(n), "*")
+	case "s", "strike", "del":
+		return wrapInline(c.children(n), "~~")
+	case "code", "tt":
+		return "`" + rawText(n) + "`"
+	case "pre":
+		return codeBlock(rawText(n))
+	case "a":
+		text := strings.TrimSpace(c.children(n))
+		href := n.attr("href")
+		switch {
+		case href == "":
+			return text
+		case text == "" || text == mdSpecials.Replace(href):
+			return "<" + href + ">"
+		}
+		return fmt.Sprintf("[%s](<%s>)", text, href)
+	case "img", "en-media":
+		if c.media != nil {
+			return c.media(n)
+		}
+		return ""
+	case "en-todo":
+		if n.attr("checked") == "true" {
+			return "- [x] "
+		}
+		return "- [ ] "
+	case "en-crypt":
+		c.skipped = append(c.skipped, "contenu chiffré")
+		return "*[contenu chiffré non importé]*"
+	case "ul", "ol":
+		return block(c.list(n))
+	case "li":
+		return c.children(n)
+	case "blockquote":
+		inner := strings.TrimSpace(blankLines.ReplaceAllString(c.children(n), "\n\n"))
+		lines := strings.Split(inner, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return block(strings.Join(lines, "\n"))
+	case "table":
+		return block(c.table(n))
+	}
+
+	if htmlBlockTags[n.tag] {
+		return block(c.children(n))
+	}
+	return c.children(n)
+}
+
+// list renders ul/ol items, indenting nested content under each marker
+func (c *htmlConverter) list(n *htmlNode) string {
+	var items []string
+	num := 1
+	for _, li := range n.children {
+		if li.tag != "li" {
+			continue
+		}
+		marker := "- "
+		if n.tag == "ol" {
+			marker = fmt.Sprintf("%d. ", num)
+			num++
+		}
+
+		content := strings.TrimSpace(blankLines.ReplaceAllString(c.children(li), "\n\n"))
+		content = strings.ReplaceAll(content, "\n\n", "\n")
+		lines := strings.Split(content, "\n")
+		for i := 1; i < len(lines); i++ {
+			lines[i] = strings.Repeat(" ", len(marker)) + lines[i]
+		}
+		items = append(items, marker+strings.Join(lines, "\n"))
+	}
+	return strings.Join(items, "\n")
+}
+
+// table renders a table as a GFM pipe table, the first row being the header
+func (c *htmlConverter) table(n *htmlNode) string {
+	var rows [][]string
+	var collect func(n *htmlNode)
+	collect = func(n *htmlNode) {
+		for _, child := range n.children {
+			if child.tag != "tr" {
+				collect(child)
+				continue
+			}
+			var cells []string
+			for _, cell := range child.children {
+				if cell.tag == "td" || cell.tag == "th" {
+					text := strings.TrimSpace(strings.ReplaceAll(c.children(cell), "\\\n", " "))
+					cells = append(cells, tableEscape.Replace(text))
+				}
+			}
+			rows = append(rows, cells)
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	var sb strings.Builder
+	for i, row := range rows {
+		for len(row) < cols {
+			row = append(row, "")
+		}
+		sb.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			sb.WriteString("|" + strings.Repeat(" --- |", cols) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// rawText returns the text of n, with line breaks for block children
+func rawText(n *htmlNode) string {
+	var sb strings.Builder
+	var walk func(n *htmlNode)
+	walk = func(n *htmlNode) {
+		if n.tag == "" {
+			sb.WriteString(n.text)
+			return
+		}
+		if n.tag == "br" {
+			sb.WriteString("\n")
+			return
+		}
+		for _, child := range n.children {
+			walk(child)
+		}
+		if n.tag == "div" || n.tag == "p" {
+			sb.WriteString("\n")
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+// codeBlock renders text as a fenced code block
+func codeBlock(text string) string {
+	return "\n\n```\n" + strings.Trim(text, "\n") + "\n```\n\n"
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Name of the ignore file read in every folder of a vault, with the
+// .gitignore syntax
+const ignoreFileName = ".notesmdignore"
+
+// ignoreRule is a line of an ignore file
+type ignoreRule struct {
+	pattern  string
+	negate   bool // "!" re-includes what an earlier rule excluded
+	dirOnly  bool // trailing "/" only matches directories
+	anchored bool // a slash ties the pattern to the folder of the file
+}
+
+// parseIgnoreRule parses a gitignore line, reporting false for blank lines
+// and comments
+func parseIgnoreRule(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, "\r")
+	if !strings.HasSuffix(line, `\ `) {
+		line = strings.TrimRight(line, " ")
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var r ignoreRule
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+	r.pattern = line
+	return r, true
+}
+
+// matches reports whether rel, relative to the folder of the rule, matches
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if !r.anchored {
+		ok, _ := path.Match(r.pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(r.pattern, "/"), strings.Split(rel, "/"))
+}
+
+// matchSegments matches a slash-separated glob where "**" stands for any
+// number of folders
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := len(parts); i >= 0; i-- {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
+
+// ignoreMatcher decides which files of a vault are hidden from the list, the
+// scan, the search and link resolution
+type ignoreMatcher struct {
+	root      string
+	gitignore bool         // read .gitignore files too
+	global    []ignoreRule // filters.ignore of the configuration
+
+	mu    sync.Mutex
+	rules map[string][]ignoreRule // folder relative to root → its ignore files
+}
+
+// newIgnoreMatcher returns the matcher of the vault at root
+func newIgnoreMatcher(root string, config *Config) *ignoreMatcher {
+	m := &ignoreMatcher{root: root, gitignore: true, rules: map[string][]ignoreRule{}}
+	if config != nil {
+		m.gitignore = config.Search.RespectGitignore
+		for _, line := range config.Filters.Ignore {
+			if r, ok := parseIgnoreRule(line); ok {
+				m.global = append(m.global, r)
+			}
+		}
+	}
+	return m
+}
+
+// folderRules returns the rules of the ignore files of a folder, read once
+func (m *ignoreMatcher) folderRules(dir string) []ignoreRule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rules, ok := m.rules[dir]; ok {
+		return rules
+	}
+
+	var rules []ignoreRule
+	names := []string{ignoreFileName}
+	if m.gitignore {
+		names = []string{".gitignore", ignoreFileName}
+	}
+	for _, name := range names {
+		f, err := os.Open(filepath.Join(m.root, filepath.FromSlash(dir), name))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if r, ok := parseIgnoreRule(scanner.Text()); ok {
+				rules = append(rules, r)
+			}
+		}
+		f.Close()
+	}
+	m.rules[dir] = rules
+	return rules
+}
+
+// Ignored reports whether path is hidden. Everything inside an ignored
+// folder is hidden too, as with git.
+func (m *ignoreMatcher) Ignored(p string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	rel, err := filepath.Rel(m.root, p)
+	if err != nil || rel == "." || !isWithin(m.root, p) {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := 1; i < len(parts); i++ {
+		if m.excluded(parts[:i], true) {
+			return true
+		}
+	}
+	return m.excluded(parts, isDir)
+}
+
+// excluded applies the rules to a path, the last matching rule winning:
+// filters.ignore first, then the ignore files from the root down
+func (m *ignoreMatcher) excluded(parts []string, isDir bool) bool {
+	name := parts[len(parts)-1]
+	if isDir && (name == ".git" || name == vaultDataDir) {
+		return true
+	}
+
+	rel := strings.Join(parts, "/")
+	excluded := false
+	for _, r := range m.global {
+		if r.matches(rel, isDir) {
+			excluded = !r.negate
+		}
+	}
+	for depth := 0; depth < len(parts); depth++ {
+		sub := strings.Join(parts[depth:], "/")
+		for _, r := range m.folderRules(strings.Join(parts[:depth], "/")) {
+			if r.matches(sub, isDir) {
+				excluded = !r.negate
+			}
+		}
+	}
+	return excluded
+}
+
+// Matchers of the open vaults, keyed by root
+var vaultIgnores = struct {
+	sync.Mutex
+	matchers map[string]*ignoreMatcher
+}{matchers: map[string]*ignoreMatcher{}}
+
+// setVaultIgnore builds the matcher of the vault at root from its
+// configuration, rereading its ignore files
+func setVaultIgnore(root string, config *Config) *ignoreMatcher {
+	m := newIgnoreMatcher(root, config)
+	vaultIgnores.Lock()
+	vaultIgnores.matchers[root] = m
+	vaultIgnores.Unlock()
+	return m
+}
+
+// vaultIgnore returns the matcher of the vault containing dir, the default
+// one rooted at dir when no vault was set up
+func vaultIgnore(dir string) *ignoreMatcher {
+	vaultIgnores.Lock()
+	defer vaultIgnores.Unlock()
+
+	var found *ignoreMatcher
+	for root, m := range vaultIgnores.matchers {
+		if isWithin(root, dir) && (found == nil || len(root) > len(found.root)) {
+			found = m
+		}
+	}
+	if found == nil {
+		found = newIgnoreMatcher(dir, nil)
+		vaultIgnores.matchers[dir] = found
+	}
+	return found
+}
+
+// walkVault walks dir like filepath.WalkDir, skipping ignored files and
+// folders and unreadable entries
+func walkVault(dir string, fn func(path string, d fs.DirEntry) error) error {
+	ignore := vaultIgnore(dir)
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if path != dir && ignore.Ignored(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(path, d)
+	})
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreMatcher(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, ".gitignore"), "node_modules/\n*.log\n!keep.log\n/build\n# commentaire\n")
+	writeTestFile(t, filepath.Join(root, ignoreFileName), "drafts/**/old.md\n")
+	writeTestFile(t, filepath.Join(root, "projets", ".gitignore"), "secret.md\n!*.log\n")
+
+	config := DefaultConfig()
+	config.Filters.Ignore = []string{"*.tmp", "archives/", "drafts/old"}
+	m := newIgnoreMatcher(root, config)
+
+	cases := []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"a.tmp", false, true},
+		{"sub/b.tmp", false, true},
+		{"archives", true, true},
+		{"notes/archives", true, true},
+		{"notes/archives", false, false},
+		{"drafts/old", false, true},
+		{"drafts/new", false, false},
+		{"note.md", false, false},
+		{".git", true, true},
+		{".git/config", false, true},
+		{"node_modules/pkg/readme.md", false, true},
+		{"debug.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"docs/build", true, false},
+		{"drafts/old.md", false, true},
+		{"drafts/2024/mai/old.md", false, true},
+		{"projets/secret.md", false, true},
+		{"secret.md", false, false},
+		{"projets/trace.log", false, false},
+	}
+	for _, c := range cases {
+		if got := m.Ignored(filepath.Join(root, filepath.FromSlash(c.rel)), c.isDir); got != c.want {
+			t.Errorf("Ignored(%q, dir=%v) = %v, want %v", c.rel, c.isDir, got, c.want)
+		}
+	}
+
+	config.Search.RespectGitignore = false
+	if newIgnoreMatcher(root, config).Ignored(filepath.Join(root, "debug.log"), false) {
+		t.Error("debug.log ignored although respect_gitignore is off")
+	}
+}
+
+func TestWalkVaultSkipsIgnored(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, ".gitignore"), "node_modules/\n")
+	writeTestFile(t, filepath.Join(root, "node_modules", "lib", "Note.md"), "# dépendance")
+	writeTestFile(t, filepath.Join(root, "notes", "Note.md"), "# vraie note")
+	setVaultIgnore(root, DefaultConfig())
+
+	if got, want := findNoteByName("Note", root), filepath.Join(root, "notes", "Note.md"); got != want {
+		t.Errorf("findNoteByName = %q, want %q", got, want)
+	}
+	if notes := walkNotes(root); len(notes) != 1 {
+		t.Errorf("walkNotes = %v, want only notes/Note.md", notes)
+	}
+	if results := searchFiles(root, "dépendance"); len(results) != 0 {
+		t.Errorf("searchFiles found %v in an ignored folder", results)
+	}
+}
@@ -0,0 +1,548 @@
+package main
+
+import (
+	"archive/tar"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// noteImporter writes imported notes and their attachments under dest
+type noteImporter struct {
+	dest        string
+	attachments string // NotesConfig.AttachmentFolder
+	report      *importReport
+	used        map[string]bool // lowercase paths already written
+}
+
+func newNoteImporter(dest string, config *Config, report *importReport) *noteImporter {
+	return &noteImporter{
+		dest:        dest,
+		attachments: config.Notes.AttachmentFolder,
+		report:      report,
+		used:        make(map[string]bool),
+	}
+}
+
+// attachmentDir returns the folder receiving the attachments of a note in
+// noteDir. "./" folders are relative to the note, others to dest.
+func (imp *noteImporter) attachmentDir(noteDir string) string {
+	switch {
+	case imp.attachments == "":
+		return filepath.Join(imp.dest, "attachments")
+	case imp.attachments == "." || strings.HasPrefix(imp.attachments, "./"):
+		return filepath.Join(noteDir, imp.attachments)
+	default:
+		return filepath.Join(imp.dest, imp.attachments)
+	}
+}
+
+// uniquePath returns dir/name+ext, numbering it when already taken
+func (imp *noteImporter) uniquePath(dir, name, ext string) string {
+	for i := 1; ; i++ {
+		candidate := name + ext
+		if i > 1 {
+			candidate = fmt.Sprintf("%s (%d)%s", name, i, ext)
+		}
+		p := filepath.Join(dir, candidate)
+		if _, err := os.Stat(p); err == nil || imp.used[strings.ToLower(p)] {
+			continue
+		}
+		imp.used[strings.ToLower(p)] = true
+		return p
+	}
+}
+
+// writeAttachment stores an attachment for a note in noteDir and returns
+// its path
+func (imp *noteImporter) writeAttachment(noteDir, fileName string, data []byte) (string, error) {
+	dir := imp.attachmentDir(noteDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	ext := filepath.Ext(fileName)
+	p := imp.uniquePath(dir, sanitizeFileName(strings.TrimSuffix(fileName, ext)), strings.ToLower(ext))
+	return p, os.WriteFile(p, data, 0644)
+}
+
+// writeNote writes a note with its frontmatter and title heading
+func (imp *noteImporter) writeNote(p string, fm []frontmatterField, title, body string, modified time.Time) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	content := formatFrontmatter(fm)
+	body = strings.TrimSpace(body)
+	if !strings.HasPrefix(body, "# ") {
+		content += "\n# " + title + "\n"
+	}
+	if body != "" {
+		content += "\n" + body + "\n"
+	}
+
+	if err := os.WriteFile(p, []byte(strings.TrimLeft(content, "\n")), 0644); err != nil {
+		return err
+	}
+	if !modified.IsZero() {
+		os.Chtimes(p, modified, modified)
+	}
+	imp.report.Notes++
+	return nil
+}
+
+// mediaLink links an attachment from a note, inline for images
+func mediaLink(noteDir, attachment, label, mimeType string) string {
+	rel, err := filepath.Rel(noteDir, attachment)
+	if err != nil {
+		rel = attachment
+	}
+	if label == "" {
+		label = filepath.Base(attachment)
+	}
+	link := fmt.Sprintf("[%s](<%s>)", markdownEscape(label), filepath.ToSlash(rel))
+	if strings.HasPrefix(mimeType, "image/") || isImageFile(attachment) {
+		return "!" + link
+	}
+	return link
+}
+
+// sanitizeFileName turns a note title into a portable file name
+func sanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < 32 {
+			return '-'
+		}
+		return r
+	}, name)
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if runes := []rune(name); len(runes) > 100 {
+		name = strings.TrimSpace(string(runes[:100]))
+	}
+	if name == "" {
+		return "Sans titre"
+	}
+	return name
+}
+
+// extensionForMime returns a file extension for a MIME type
+func extensionForMime(mimeType string) string {
+	switch mimeType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "application/pdf":
+		return ".pdf"
+	}
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+// ========== Evernote ENEX ==========
+
+type enexNote struct {
+	Title      string   `xml:"title"`
+	Content    string   `xml:"content"`
+	Created    string   `xml:"created"`
+	Updated    string   `xml:"updated"`
+	Tags       []string `xml:"tag"`
+	Attributes struct {
+		SourceURL string `xml:"source-url"`
+	} `xml:"note-attributes"`
+	Resources []enexResource `xml:"resource"`
+}
+
+type enexResource struct {
+	Data struct {
+		Encoding string `xml:"encoding,attr"`
+		Value    string `xml:",chardata"`
+	} `xml:"data"`
+	Mime     string `xml:"mime"`
+	FileName string `xml:"resource-attributes>file-name"`
+}
+
+// parseENEXTime converts an ENEX timestamp (20231010T120000Z)
+func parseENEXTime(s string) time.Time {
+	t, err := time.Parse("20060102T150405Z", strings.TrimSpace(s))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// formatImportTime formats a timestamp for the frontmatter
+func formatImportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// importENEX converts the notes of an Evernote export into dest
+func importENEX(file, dest string, config *Config) (importReport, error) {
+	report := importReport{Source: file}
+	f, err := os.Open(file)
+	if err != nil {
+		return report, err
+	}
+	defer f.Close()
+
+	imp := newNoteImporter(dest, config, &report)
+	dec := xml.NewDecoder(f)
+	dec.Strict = false
+
+	// Notes are decoded one at a time so large exports stay cheap
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("ENEX invalide : %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "note" {
+			continue
+		}
+
+		var note enexNote
+		if err := dec.DecodeElement(&note, &start); err != nil {
+			return report, fmt.Errorf("ENEX invalide : %w", err)
+		}
+		if err := imp.importENEXNote(note); err != nil {
+			return report, err
+		}
+	}
+
+	if report.Notes == 0 {
+		return report, fmt.Errorf("aucune note trouvée dans %s", file)
+	}
+	return report, nil
+}
+
+func (imp *noteImporter) importENEXNote(note enexNote) error {
+	title := strings.TrimSpace(note.Title)
+	if title == "" {
+		title = "Sans titre"
+	}
+	notePath := imp.uniquePath(imp.dest, sanitizeFileName(title), ".md")
+	noteDir := filepath.Dir(notePath)
+
+	// Extract resources, indexed by the MD5 hash used by <en-media>
+	type resource struct {
+		path, mime, name string
+		linked           bool
+	}
+	resources := make(map[string]*resource)
+	var order []string
+	for _, r := range note.Resources {
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(r.Data.Value), ""))
+		if err != nil {
+			imp.report.unsupported("pièce jointe illisible dans %q", title)
+			continue
+		}
+		sum := md5.Sum(data)
+		hash := hex.EncodeToString(sum[:])
+
+		name := r.FileName
+		if name == "" {
+			name = hash[:8] + extensionForMime(r.Mime)
+		}
+		p, err := imp.writeAttachment(noteDir, name, data)
+		if err != nil {
+			return err
+		}
+		resources[hash] = &resource{path: p, mime: r.Mime, name: r.FileName}
+		order = append(order, hash)
+	}
+
+	conv := &htmlConverter{media: func(n *htmlNode) string {
+		if n.tag == "img" {
+			return fmt.Sprintf("![%s](<%s>)", markdownEscape(n.attr("alt")), n.attr("src"))
+		}
+		r, ok := resources[n.attr("hash")]
+		if !ok {
+			imp.report.unsupported("ressource manquante dans %q", title)
+			return ""
+		}
+		r.linked = true
+		return mediaLink(noteDir, r.path, r.name, r.mime)
+	}}
+	body, err := htmlToMarkdown(note.Content, conv)
+	if err != nil && body == "" {
+		imp.report.unsupported("contenu illisible dans %q : %v", title, err)
+	}
+	for _, s := range conv.skipped {
+		imp.report.unsupported("%s dans %q", s, title)
+	}
+
+	// Keep attachments that the content doesn't reference
+	var extra []string
+	for _, hash := range order {
+		if r := resources[hash]; !r.linked {
+			extra = append(extra, "- "+mediaLink(noteDir, r.path, r.name, r.mime))
+		}
+	}
+	if len(extra) > 0 {
+		body = strings.TrimSpace(body) + "\n\n## Pièces jointes\n\n" + strings.Join(extra, "\n")
+	}
+
+	created := parseENEXTime(note.Created)
+	updated := parseENEXTime(note.Updated)
+	fm := []frontmatterField{
+		{key: "created", values: []string{formatImportTime(created)}},
+		{key: "updated", values: []string{formatImportTime(updated)}},
+		{key: "tags", values: note.Tags, list: true},
+		{key: "source", values: []string{note.Attributes.SourceURL}},
+	}
+	if updated.IsZero() {
+		updated = created
+	}
+	return imp.writeNote(notePath, fm, title, body, updated)
+}
+
+// ========== Joplin JEX ==========
+
+// Joplin item types
+const (
+	joplinNote     = "1"
+	joplinFolder   = "2"
+	joplinResource = "4"
+	joplinTag      = "5"
+	joplinNoteTag  = "6"
+)
+
+// joplinItem is an entry of a JEX archive: a title line, an optional
+// body and a trailing block of "key: value" metadata
+type joplinItem struct {
+	title string
+	body  string
+	meta  map[string]string
+	path  string // output path of notes
+}
+
+var joplinMetaLine = regexp.MustCompile(`^([a-z_]+): ?(.*)$`)
+
+// parseJoplinItem splits a JEX entry into title, body and metadata
+func parseJoplinItem(content string) *joplinItem {
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(content, "\r\n", "\n"), "\n"), "\n")
+	item := &joplinItem{meta: make(map[string]string)}
+
+	end := len(lines)
+	for end > 0 {
+		m := joplinMetaLine.FindStringSubmatch(lines[end-1])
+		if m == nil {
+			break
+		}
+		item.meta[m[1]] = m[2]
+		end--
+	}
+	lines = lines[:end]
+
+	if len(lines) > 0 {
+		item.title = strings.TrimSpace(lines[0])
+		item.body = strings.TrimSpace(strings.Join(lines[1:], "\n"))
+	}
+	return item
+}
+
+// joplinTime picks the user-facing timestamp of an item
+func joplinTime(meta map[string]string, key string) time.Time {
+	for _, k := range []string{"user_" + key, key} {
+		if t, err := time.Parse(time.RFC3339Nano, meta[k]); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+var (
+	joplinLink     = regexp.MustCompile(`:/([0-9a-f]{32})`)
+	spacedLinkDest = regexp.MustCompile(`\]\(([^)<>]* [^)<>]*)\)`)
+)
+
+// importJEX converts the notes of a Joplin export archive into dest
+func importJEX(file, dest string, config *Config) (importReport, error) {
+	report := importReport{Source: file}
+	f, err := os.Open(file)
+	if err != nil {
+		return report, err
+	}
+	defer f.Close()
+
+	items := make(map[string]*joplinItem)
+	resourceFiles := make(map[string][]byte)
+
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("JEX invalide : %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(hdr.Name)
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return report, err
+		}
+		switch {
+		case path.Dir(name) == "resources":
+			id := strings.TrimSuffix(path.Base(name), path.Ext(name))
+			resourceFiles[id] = data
+		case path.Dir(name) == "." && path.Ext(name) == ".md":
+			id := strings.TrimSuffix(name, ".md")
+			items[id] = parseJoplinItem(string(data))
+		}
+	}
+
+	imp := newNoteImporter(dest, config, &report)
+
+	// Tags of each note
+	noteTags := make(map[string][]string)
+	for _, item := range items {
+		if item.meta["type_"] == joplinNoteTag {
+			if tag, ok := items[item.meta["tag_id"]]; ok {
+				noteTags[item.meta["note_id"]] = append(noteTags[item.meta["note_id"]], tag.title)
+			}
+		}
+	}
+
+	// Notebook folders, resolved through their parents
+	var folderPath func(id string, depth int) string
+	folderPath = func(id string, depth int) string {
+		folder, ok := items[id]
+		if !ok || folder.meta["type_"] != joplinFolder || depth > 20 {
+			return ""
+		}
+		return filepath.Join(folderPath(folder.meta["parent_id"], depth+1), sanitizeFileName(folder.title))
+	}
+
+	// Assign note paths first so links between notes can be rewritten
+	var noteIDs []string
+	for id, item := range items {
+		if item.meta["type_"] == joplinNote {
+			noteIDs = append(noteIDs, id)
+		}
+	}
+	sort.Slice(noteIDs, func(i, j int) bool {
+		return items[noteIDs[i]].title < items[noteIDs[j]].title
+	})
+	for _, id := range noteIDs {
+		item := items[id]
+		if item.meta["encryption_applied"] == "1" {
+			report.unsupported("note chiffrée ignorée : %s", id)
+			continue
+		}
+		dir := filepath.Join(dest, folderPath(item.meta["parent_id"], 0))
+		item.path = imp.uniquePath(dir, sanitizeFileName(item.title), ".md")
+	}
+
+	// Resources are written once per attachment folder
+	written := make(map[string]string)
+	resourcePath := func(noteDir, id string) (string, error) {
+		dir := imp.attachmentDir(noteDir)
+		if p, ok := written[dir+"\x00"+id]; ok {
+			return p, nil
+		}
+		res := items[id]
+		data, ok := resourceFiles[id]
+		if !ok {
+			return "", errors.New("fichier absent")
+		}
+		name := res.title
+		if filepath.Ext(name) == "" {
+			name += "." + strings.TrimPrefix(res.meta["file_extension"], ".")
+		}
+		p, err := imp.writeAttachment(noteDir, name, data)
+		if err == nil {
+			written[dir+"\x00"+id] = p
+		}
+		return p, err
+	}
+
+	for _, id := range noteIDs {
+		item := items[id]
+		if item.path == "" {
+			continue
+		}
+		noteDir := filepath.Dir(item.path)
+
+		// Rewrite :/id references to notes and resources
+		rewrite := func(s string) string {
+			return joplinLink.ReplaceAllStringFunc(s, func(match string) string {
+				target, ok := items[match[2:]]
+				if !ok {
+					return match
+				}
+				switch target.meta["type_"] {
+				case joplinNote:
+					if target.path != "" {
+						rel, _ := filepath.Rel(noteDir, target.path)
+						return filepath.ToSlash(rel)
+					}
+				case joplinResource:
+					p, err := resourcePath(noteDir, match[2:])
+					if err != nil {
+						report.unsupported("ressource %q de %q : %v", target.title, item.title, err)
+						return match
+					}
+					rel, _ := filepath.Rel(noteDir, p)
+					return filepath.ToSlash(rel)
+				}
+				return match
+			})
+		}
+
+		body := item.body
+		if item.meta["markup_language"] == "2" {
+			conv := &htmlConverter{media: func(n *htmlNode) string {
+				return fmt.Sprintf("![%s](<%s>)", markdownEscape(n.attr("alt")), n.attr("src"))
+			}}
+			if converted, err := htmlToMarkdown(body, conv); err == nil || converted != "" {
+				body = converted
+			}
+		}
+		body = rewrite(body)
+		// Rewritten paths may contain spaces, which Markdown links need enclosed
+		body = spacedLinkDest.ReplaceAllString(body, "](<$1>)")
+
+		created := joplinTime(item.meta, "created_time")
+		updated := joplinTime(item.meta, "updated_time")
+		tags := noteTags[id]
+		sort.Strings(tags)
+		fm := []frontmatterField{
+			{key: "created", values: []string{formatImportTime(created)}},
+			{key: "updated", values: []string{formatImportTime(updated)}},
+			{key: "tags", values: tags, list: true},
+			{key: "source", values: []string{item.meta["source_url"]}},
+		}
+		if err := imp.writeNote(item.path, fm, item.title, body, updated); err != nil {
+			return report, err
+		}
+	}
+
+	if report.Notes == 0 {
+		return report, fmt.Errorf("aucune note trouvée dans %s", file)
+	}
+	return report, nil
+}
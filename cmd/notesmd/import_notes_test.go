@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func readImported(t *testing.T, path string) (frontmatter, string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parseFrontmatter(string(data))
+}
+
+func TestImportENEX(t *testing.T) {
+	dest := t.TempDir()
+	report, err := importENEX(filepath.Join("testdata", "sample.enex"), dest, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Notes != 2 {
+		t.Fatalf("Notes = %d, want 2", report.Notes)
+	}
+
+	fm, body := readImported(t, filepath.Join(dest, "Recette- crêpes.md"))
+	if got := fm.Get("created"); got != "2023-10-10T12:00:00Z" {
+		t.Errorf("created = %q", got)
+	}
+	if got := fm.Get("updated"); got != "2023-10-11T08:30:00Z" {
+		t.Errorf("updated = %q", got)
+	}
+	if got := strings.Join(fm["tags"], ","); got != "cuisine,dessert" {
+		t.Errorf("tags = %q", got)
+	}
+	if got := fm.Get("source"); got != "https://example.com/crepes" {
+		t.Errorf("source = %q", got)
+	}
+
+	for _, want := range []string{
+		"# Recette: crêpes",
+		"**Ingrédients**",
+		"- 250 g de farine\n- 3 œufs",
+		"- [x] Acheter du lait",
+		"![photo.png](<attachments/photo.png>)",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q:\n%s", want, body)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dest, "attachments", "photo.png")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImportJEX(t *testing.T) {
+	dest := t.TempDir()
+	report, err := importJEX(filepath.Join("testdata", "sample.jex"), dest, &Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Notes != 2 {
+		t.Fatalf("Notes = %d, want 2", report.Notes)
+	}
+
+	fm, body := readImported(t, filepath.Join(dest, "Projets", "Réunion.md"))
+	if got := fm.Get("created"); got != "2023-10-02T09:00:00Z" {
+		t.Errorf("created = %q", got)
+	}
+	if got := strings.Join(fm["tags"], ","); got != "travail" {
+		t.Errorf("tags = %q", got)
+	}
+	for _, want := range []string{"[Suivi](../Suivi.md)", "![schéma.png](../attachments/schéma.png)"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q:\n%s", want, body)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dest, "attachments", "schéma.png")); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"<p>a <i>b</i></p><p>c<br/>d</p>", "a *b*\n\nc\\\nd\n"},
+		{"<ol><li>un</li><li>deux</li></ol>", "1. un\n2. deux\n"},
+		{"<table><tr><th>a</th><th>b</th></tr><tr><td>1</td><td>x|y</td></tr></table>", "| a | b |\n| --- | --- |\n| 1 | x\\|y |\n"},
+		{`<div style="-en-codeblock:true"><div>x := 1</div><div>*y</div></div>`, "```\nx := 1\n*y\n```\n"},
+	}
+	for _, tt := range tests {
+		got, err := htmlToMarkdown(tt.src, &htmlConverter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("htmlToMarkdown(%q) = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// importReport describes what an importer changed and what it skipped
+type importReport struct {
+	Source      string   `json:"source"`
+	Notes       int      `json:"notes,omitempty"`
+	Settings    []string `json:"settings"`
+	Bookmarks   int      `json:"bookmarks"`
+	Unsupported []string `json:"unsupported"`
+}
+
+func (r *importReport) setting(key string, value any) {
+	r.Settings = append(r.Settings, fmt.Sprintf("%s = %v", key, value))
+}
+
+func (r *importReport) unsupported(format string, args ...any) {
+	r.Unsupported = append(r.Unsupported, fmt.Sprintf(format, args...))
+}
+
+// Obsidian core plugins with an equivalent in NotesMD
+var supportedObsidianPlugins = map[string]bool{
+	"file-explorer":     true,
+	"global-search":     true,
+	"switcher":          true,
+	"backlink":          true,
+	"outgoing-link":     true,
+	"tag-pane":          true,
+	"outline":           true,
+	"daily-notes":       true,
+	"templates":         true,
+	"bookmarks":         true,
+	"starred":           true,
+	"file-recovery":     true,
+	"editor-status":     true,
+	"word-count":        true,
+	"note-composer":     true,
+	"markdown-importer": true,
+}
+
+// obsidianSyntax lists Obsidian-only syntax NotesMD renders as plain Markdown
+var obsidianSyntax = []struct {
+	label   string
+	pattern *regexp.Regexp
+}{
+	{"requêtes Dataview", regexp.MustCompile("(?m)^```dataview(js)?\\b")},
+	{"requêtes de recherche intégrées", regexp.MustCompile("(?m)^```query\\b")},
+	{"commentaires %%…%%", regexp.MustCompile(`%%[^%]*%%`)},
+	{"références de bloc ^id", regexp.MustCompile(`(?m)(\s\^[A-Za-z0-9-]+$|\[\[[^\]]*#\^)`)},
+	{"callouts > [!type]", regexp.MustCompile(`(?m)^>\s*\[![A-Za-z]+\]`)},
+	{"syntaxe Templater <% %>", regexp.MustCompile(`<%[\s\S]*?%>`)},
+	{"formules LaTeX $$", regexp.MustCompile(`\$\$`)},
+}
+
+// importObsidian maps the settings of the Obsidian vault at vaultDir onto
+// config and imports its bookmarks into state. default_dir is only replaced
+// when setDefaultDir is set or the current one doesn't exist.
+func importObsidian(vaultDir string, config *Config, state *SessionState, setDefaultDir bool) (importReport, error) {
+	report := importReport{Source: vaultDir}
+	settingsDir := filepath.Join(vaultDir, ".obsidian")
+	if info, err := os.Stat(settingsDir); err != nil || !info.IsDir() {
+		return report, fmt.Errorf("pas un vault Obsidian (.obsidian introuvable) : %s", vaultDir)
+	}
+
+	if config.DefaultDir != vaultDir {
+		if setDefaultDir || !isDir(config.DefaultDir) {
+			config.DefaultDir = vaultDir
+			report.setting("default_dir", vaultDir)
+		} else {
+			report.unsupported("default_dir garde %s : --default-dir pour le remplacer", config.DefaultDir)
+		}
+	}
+
+	importObsidianApp(settingsDir, config, &report)
+	importObsidianDailyNotes(settingsDir, config, &report)
+	importObsidianTemplates(settingsDir, config, &report)
+	importObsidianPlugins(settingsDir, &report)
+	importObsidianBookmarks(vaultDir, settingsDir, config, state, &report)
+	scanObsidianSyntax(vaultDir, &report)
+
+	return report, nil
+}
+
+// readObsidianJSON decodes a settings file, reporting false when it is absent
+func readObsidianJSON(settingsDir, name string, v any, report *importReport) bool {
+	data, err := os.ReadFile(filepath.Join(settingsDir, name))
+	if err != nil {
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		report.unsupported("%s illisible : %v", name, err)
+		return false
+	}
+	return true
+}
+
+func importObsidianApp(settingsDir string, config *Config, report *importReport) {
+	var app struct {
+		AttachmentFolderPath string `json:"attachmentFolderPath"`
+		NewFileLocation      string `json:"newFileLocation"`
+		NewFileFolderPath    string `json:"newFileFolderPath"`
+		NewLinkFormat        string `json:"newLinkFormat"`
+		UseMarkdownLinks     bool   `json:"useMarkdownLinks"`
+	}
+	if !readObsidianJSON(settingsDir, "app.json", &app, report) {
+		return
+	}
+
+	// "/" is the vault root, "./" the folder of the note
+	attachments := strings.TrimPrefix(app.AttachmentFolderPath, "/")
+	if attachments != config.Notes.AttachmentFolder {
+		config.Notes.AttachmentFolder = attachments
+		report.setting("notes.attachment_folder", fmt.Sprintf("%q", attachments))
+	}
+
+	switch app.NewFileLocation {
+	case "root", "current", "folder":
+		config.Notes.NewNoteLocation = app.NewFileLocation
+		report.setting("notes.new_note_location", app.NewFileLocation)
+		if app.NewFileLocation == "folder" {
+			config.Notes.NewNoteFolder = app.NewFileFolderPath
+			report.setting("notes.new_note_folder", app.NewFileFolderPath)
+		}
+	}
+
+	if app.UseMarkdownLinks {
+		report.unsupported("liens Markdown pour les nouveaux liens (useMarkdownLinks) : NotesMD crée des liens wiki")
+	}
+	if app.NewLinkFormat == "relative" || app.NewLinkFormat == "absolute" {
+		report.unsupported("format de lien %q : NotesMD résout les liens wiki par nom de note", app.NewLinkFormat)
+	}
+}
+
+func importObsidianDailyNotes(settingsDir string, config *Config, report *importReport) {
+	var daily struct {
+		Folder   string `json:"folder"`
+		Format   string `json:"format"`
+		Template string `json:"template"`
+	}
+	if !readObsidianJSON(settingsDir, "daily-notes.json", &daily, report) {
+		return
+	}
+
+	config.Capture.DailyFolder = strings.Trim(daily.Folder, "/")
+	report.setting("capture.daily_folder", fmt.Sprintf("%q", config.Capture.DailyFolder))
+
+	if daily.Format == "" {
+		daily.Format = "YYYY-MM-DD"
+	}
+	if layout, ok := momentToGoLayout(daily.Format); ok {
+		config.Capture.DailyFormat = layout
+		report.setting("capture.daily_format", layout)
+	} else {
+		report.unsupported("format de note du jour %q non convertible", daily.Format)
+	}
+
+	if daily.Template != "" {
+		report.unsupported("modèle de note du jour %q : utilisez notesmd new --template", daily.Template)
+	}
+}
+
+func importObsidianTemplates(settingsDir string, config *Config, report *importReport) {
+	var templates struct {
+		Folder string `json:"folder"`
+	}
+	if !readObsidianJSON(settingsDir, "templates.json", &templates, report) || templates.Folder == "" {
+		return
+	}
+	config.Notes.TemplatesFolder = strings.Trim(templates.Folder, "/")
+	report.setting("notes.templates_folder", config.Notes.TemplatesFolder)
+}
+
+func importObsidianPlugins(settingsDir string, report *importReport) {
+	// core-plugins.json is a list of enabled ids, or an id → enabled map
+	// in recent versions
+	var enabled []string
+	data, err := os.ReadFile(filepath.Join(settingsDir, "core-plugins.json"))
+	if err == nil {
+		var byID map[string]bool
+		if json.Unmarshal(data, &enabled) != nil && json.Unmarshal(data, &byID) == nil {
+			for id, on := range byID {
+				if on {
+					enabled = append(enabled, id)
+				}
+			}
+		}
+	}
+	sort.Strings(enabled)
+	for _, id := range enabled {
+		if !supportedObsidianPlugins[id] {
+			report.unsupported("plugin natif %q", id)
+		}
+	}
+
+	var community []string
+	readObsidianJSON(settingsDir, "community-plugins.json", &community, report)
+	for _, id := range community {
+		report.unsupported("plugin communautaire %q", id)
+	}
+}
+
+// obsidianBookmark is an entry of bookmarks.json (or the older starred.json)
+type obsidianBookmark struct {
+	Type  string             `json:"type"`
+	Path  string             `json:"path"`
+	Title string             `json:"title"`
+	Query string             `json:"query"`
+	URL   string             `json:"url"`
+	Items []obsidianBookmark `json:"items"`
+}
+
+// importObsidianBookmarks adds the bookmarks to the session vaultDir belongs
+// to, that of its named vault or the one outside vaults
+func importObsidianBookmarks(vaultDir, settingsDir string, config *Config, state *SessionState, report *importReport) {
+	var file struct {
+		Items []obsidianBookmark `json:"items"`
+	}
+	if !readObsidianJSON(settingsDir, "bookmarks.json", &file, report) &&
+		!readObsidianJSON(settingsDir, "starred.json", &file, report) {
+		return
+	}
+
+	bookmarks := &state.Bookmarks
+	if v, ok := config.vaultForDir(vaultDir); ok {
+		bookmarks = &state.vaultState(v.Name).Bookmarks
+	}
+	existing := make(map[string]bool)
+	for _, b := range *bookmarks {
+		existing[b] = true
+	}
+
+	var walk func(items []obsidianBookmark)
+	walk = func(items []obsidianBookmark) {
+		for _, item := range items {
+			switch item.Type {
+			case "group":
+				report.unsupported("groupe de signets %q aplati", item.Title)
+				walk(item.Items)
+				continue
+			case "file", "folder":
+			case "heading":
+				report.unsupported("signet de titre converti en signet de note : %s", item.Path)
+			case "block":
+				report.unsupported("signet de bloc converti en signet de note : %s", item.Path)
+			case "search":
+				report.unsupported("signet de recherche %q", item.Query)
+				continue
+			case "url":
+				report.unsupported("signet d'URL %q", item.URL)
+				continue
+			default:
+				report.unsupported("signet de type %q", item.Type)
+				continue
+			}
+
+			path := filepath.Join(vaultDir, filepath.FromSlash(item.Path))
+			if _, err := os.Stat(path); err != nil {
+				report.unsupported("signet vers un fichier absent : %s", item.Path)
+				continue
+			}
+			if !existing[path] {
+				existing[path] = true
+				*bookmarks = append(*bookmarks, path)
+				report.Bookmarks++
+			}
+		}
+	}
+	walk(file.Items)
+}
+
+// scanObsidianSyntax reports notes using syntax NotesMD doesn't render
+func scanObsidianSyntax(vaultDir string, report *importReport) {
+	counts := make([]int, len(obsidianSyntax))
+	examples := make([]string, len(obsidianSyntax))
+
+	for _, path := range walkNotes(vaultDir) {
+		content := loadMarkdownRaw(path)
+		for i, syntax := range obsidianSyntax {
+			if syntax.pattern.MatchString(content) {
+				counts[i]++
+				if examples[i] == "" {
+					examples[i], _ = filepath.Rel(vaultDir, path)
+				}
+			}
+		}
+	}
+	for i, syntax := range obsidianSyntax {
+		if counts[i] > 0 {
+			report.unsupported("%s dans %d note(s), ex. %s", syntax.label, counts[i], examples[i])
+		}
+	}
+
+	var canvases, excalidraw int
+	filepath.WalkDir(vaultDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() && path != vaultDir && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		switch {
+		case strings.HasSuffix(d.Name(), ".canvas"):
+			canvases++
+		case strings.HasSuffix(d.Name(), ".excalidraw.md"):
+			excalidraw++
+		}
+		return nil
+	})
+	if canvases > 0 {
+		report.unsupported("%d canvas (.canvas)", canvases)
+	}
+	if excalidraw > 0 {
+		report.unsupported("%d dessin(s) Excalidraw", excalidraw)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// obsidianVault writes an Obsidian vault with its .obsidian settings
+func obsidianVault(t *testing.T) string {
+	t.Helper()
+	vault := t.TempDir()
+	settings := filepath.Join(vault, ".obsidian")
+	writeTestFile(t, filepath.Join(settings, "app.json"), `{
+		"attachmentFolderPath": "/assets",
+		"newFileLocation": "folder",
+		"newFileFolderPath": "inbox",
+		"useMarkdownLinks": true
+	}`)
+	writeTestFile(t, filepath.Join(settings, "daily-notes.json"), `{"folder": "/journal/", "format": "DD-MM-YYYY"}`)
+	writeTestFile(t, filepath.Join(settings, "templates.json"), `{"folder": "/modèles/"}`)
+	writeTestFile(t, filepath.Join(settings, "core-plugins.json"), `{"backlink": true, "canvas": true, "graph": false}`)
+	writeTestFile(t, filepath.Join(settings, "community-plugins.json"), `["dataview"]`)
+	writeTestFile(t, filepath.Join(settings, "bookmarks.json"), `{"items": [
+		{"type": "file", "path": "projet.md"},
+		{"type": "group", "title": "Travail", "items": [
+			{"type": "heading", "path": "notes/idées.md", "subpath": "#Suite"},
+			{"type": "file", "path": "absente.md"}
+		]},
+		{"type": "search", "query": "tag:#todo"},
+		{"type": "file", "path": "projet.md"}
+	]}`)
+	writeTestFile(t, filepath.Join(vault, "projet.md"), "# Projet\n\n> [!note] Un callout\n")
+	writeTestFile(t, filepath.Join(vault, "notes", "idées.md"), "# Idées\n")
+	return vault
+}
+
+func TestImportObsidianSettings(t *testing.T) {
+	vault := obsidianVault(t)
+	config := DefaultConfig()
+	config.DefaultDir = filepath.Join(t.TempDir(), "absent")
+
+	report, err := importObsidian(vault, config, &SessionState{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.DefaultDir != vault {
+		t.Errorf("default_dir = %q, want the vault when the old one doesn't exist", config.DefaultDir)
+	}
+	if config.Notes.AttachmentFolder != "assets" || config.Notes.NewNoteLocation != "folder" || config.Notes.NewNoteFolder != "inbox" {
+		t.Errorf("app.json mapped to %+v", config.Notes)
+	}
+	if config.Capture.DailyFolder != "journal" || config.Capture.DailyFormat != "02-01-2006" {
+		t.Errorf("daily notes mapped to folder %q, format %q", config.Capture.DailyFolder, config.Capture.DailyFormat)
+	}
+	if config.Notes.TemplatesFolder != "modèles" {
+		t.Errorf("templates folder = %q", config.Notes.TemplatesFolder)
+	}
+
+	unsupported := strings.Join(report.Unsupported, "\n")
+	for _, want := range []string{`plugin natif "canvas"`, `plugin communautaire "dataview"`, "useMarkdownLinks", "callouts"} {
+		if !strings.Contains(unsupported, want) {
+			t.Errorf("report misses %q:\n%s", want, unsupported)
+		}
+	}
+	if strings.Contains(unsupported, "backlink") || strings.Contains(unsupported, "graph") {
+		t.Errorf("supported or disabled plugin reported:\n%s", unsupported)
+	}
+
+	if _, err := importObsidian(t.TempDir(), DefaultConfig(), &SessionState{}, false); err == nil {
+		t.Error("folder without .obsidian imported")
+	}
+}
+
+func TestImportObsidianKeepsDefaultDir(t *testing.T) {
+	vault := obsidianVault(t)
+	current := t.TempDir()
+
+	config := DefaultConfig()
+	config.DefaultDir = current
+	report, _ := importObsidian(vault, config, &SessionState{}, false)
+	if config.DefaultDir != current {
+		t.Errorf("existing default_dir replaced by %q", config.DefaultDir)
+	}
+	if !strings.Contains(strings.Join(report.Unsupported, "\n"), "--default-dir") {
+		t.Error("kept default_dir not reported")
+	}
+
+	importObsidian(vault, config, &SessionState{}, true)
+	if config.DefaultDir != vault {
+		t.Errorf("default_dir = %q with --default-dir", config.DefaultDir)
+	}
+}
+
+func TestImportObsidianBookmarks(t *testing.T) {
+	vault := obsidianVault(t)
+	projet := filepath.Join(vault, "projet.md")
+	idees := filepath.Join(vault, "notes", "idées.md")
+
+	// Outside named vaults, the bookmarks join the flat session
+	state := &SessionState{Bookmarks: []string{projet}}
+	report, _ := importObsidian(vault, DefaultConfig(), state, false)
+	if !slices.Equal(state.Bookmarks, []string{projet, idees}) || report.Bookmarks != 1 {
+		t.Errorf("bookmarks = %q, %d imported", state.Bookmarks, report.Bookmarks)
+	}
+	unsupported := strings.Join(report.Unsupported, "\n")
+	for _, want := range []string{`groupe de signets "Travail"`, "absente.md", `"tag:#todo"`, "signet de titre"} {
+		if !strings.Contains(unsupported, want) {
+			t.Errorf("report misses %q:\n%s", want, unsupported)
+		}
+	}
+
+	// In a named vault, they go to its session
+	config := DefaultConfig()
+	config.Vaults = []VaultConfig{{Name: "obsidian", Path: vault}}
+	state = &SessionState{}
+	importObsidian(vault, config, state, false)
+	if len(state.Bookmarks) != 0 {
+		t.Errorf("flat bookmarks = %q, want them in the vault session", state.Bookmarks)
+	}
+	if got := state.vaultState("obsidian").Bookmarks; !slices.Equal(got, []string{projet, idees}) {
+		t.Errorf("vault bookmarks = %q", got)
+	}
+}
+
+func TestCLIImportObsidianWithSkippedSettings(t *testing.T) {
+	testVault(t)
+	vault := obsidianVault(t)
+	writeTestFile(t, getConfigPath(), "{\n  \"editor\": \"vim\",\n  \"thme\": 2\n}\n")
+
+	code, stdout, stderr := runTestCLI(t, "", "import", "obsidian", vault)
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if !strings.Contains(stderr, "Réglages ignorés") || !strings.Contains(stdout, "Configuration enregistrée") {
+		t.Errorf("import with skipped settings:\n%s\n%s", stdout, stderr)
+	}
+	var saved map[string]any
+	if err := json.Unmarshal(mustRead(t, getConfigPath()), &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved["thme"] != 2.0 || saved["editor"] != "vim" || saved["capture"].(map[string]any)["daily_folder"] != "journal" {
+		t.Errorf("config.json after import: %v", saved)
+	}
+
+	// Invalid JSON is left alone, the bookmarks are still imported
+	writeTestFile(t, getConfigPath(), "{editor: vim")
+	code, stdout, _ = runTestCLI(t, "", "import", "obsidian", vault)
+	if code != 0 || !strings.Contains(stdout, "réglages non enregistrés") {
+		t.Errorf("exit %d:\n%s", code, stdout)
+	}
+	if got := string(mustRead(t, getConfigPath())); got != "{editor: vim" {
+		t.Errorf("invalid config.json rewritten: %q", got)
+	}
+	state, err := LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Bookmarks) != 2 {
+		t.Errorf("bookmarks not saved: %q", state.Bookmarks)
+	}
+}
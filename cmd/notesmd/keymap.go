@@ -0,0 +1,451 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// action is a command of the file browser that keys can be bound to
+type action string
+
+const (
+	actUp            action = "up"
+	actDown          action = "down"
+	actTop           action = "top"
+	actBottom        action = "bottom"
+	actHalfPageDown  action = "half_page_down"
+	actHalfPageUp    action = "half_page_up"
+	actPageDown      action = "page_down"
+	actPageUp        action = "page_up"
+	actOpen          action = "open"
+	actParent        action = "parent"
+	actHome          action = "home"
+	actGoToFolder    action = "go_to_folder"
+	actBack          action = "back"
+	actForward       action = "forward"
+	actNewNote       action = "new_note"
+	actNewFolder     action = "new_folder"
+	actDelete        action = "delete"
+	actRename        action = "rename"
+	actEditExternal  action = "edit_external"
+	actEditInline    action = "edit_inline"
+	actCopyFile      action = "copy_file"
+	actPaste         action = "paste"
+	actCopyPath      action = "copy_path"
+	actCopyContent   action = "copy_content"
+	actCopyMenu      action = "copy_menu"
+	actExport        action = "export"
+	actHistory       action = "history"
+	actEncrypt       action = "encrypt"
+	actMoveToFolder  action = "move_to_folder"
+	actBookmark      action = "bookmark"
+	actBookmarks     action = "bookmarks"
+	actRecent        action = "recent"
+	actLinks         action = "links"
+	actGit           action = "git"
+	actGitCommit     action = "git_commit"
+	actGitPull       action = "git_pull"
+	actGitPush       action = "git_push"
+	actToggleMdOnly  action = "toggle_md_only"
+	actToggleHidden  action = "toggle_hidden"
+	actSort          action = "sort"
+	actSearch        action = "search"
+	actSearchHeading action = "search_headings"
+	actSearchNote    action = "search_note"
+	actScrollUp      action = "scroll_up"
+	actScrollDown    action = "scroll_down"
+	actOutline       action = "outline"
+	actTheme         action = "theme"
+	actHelp          action = "help"
+	actPalette       action = "palette"
+	actShowConfig    action = "show_config"
+	actSwitchVault   action = "switch_vault"
+	actSettings      action = "settings"
+	actQuit          action = "quit"
+)
+
+// keyBinding binds key sequences to an action. A sequence is a chord of
+// space separated keys, like "g g".
+type keyBinding struct {
+	action action
+	keys   []string
+	group  string // help section
+	help   string
+	custom bool // keys come from the configuration
+}
+
+// Help sections, in display order
+var keyGroups = []string{"Navigation", "Fichiers", "Organisation", "Recherche et filtres", "Interface"}
+
+var defaultBindings = []keyBinding{
+	{action: actUp, keys: []string{"up", "k"}, group: "Navigation", help: "monter"},
+	{action: actDown, keys: []string{"down", "j"}, group: "Navigation", help: "descendre"},
+	{action: actTop, keys: []string{"g g", "home"}, group: "Navigation", help: "début"},
+	{action: actBottom, keys: []string{"G", "end"}, group: "Navigation", help: "fin"},
+	{action: actHalfPageDown, keys: []string{"ctrl+d"}, group: "Navigation", help: "½ page ↓"},
+	{action: actHalfPageUp, keys: []string{"ctrl+u"}, group: "Navigation", help: "½ page ↑"},
+	{action: actPageDown, keys: []string{"pgdown"}, group: "Navigation", help: "page ↓"},
+	{action: actPageUp, keys: []string{"pgup"}, group: "Navigation", help: "page ↑"},
+	{action: actOpen, keys: []string{"enter", "l", "right"}, group: "Navigation", help: "ouvrir"},
+	{action: actParent, keys: []string{"h", "left", "-"}, group: "Navigation", help: "dossier parent"},
+	{action: actHome, keys: []string{"~"}, group: "Navigation", help: "home"},
+	{action: actGoToFolder, group: "Navigation", help: "aller au dossier…"},
+	{action: actBack, keys: []string{"ctrl+o"}, group: "Navigation", help: "historique ←"},
+	{action: actForward, keys: []string{"ctrl+i"}, group: "Navigation", help: "historique →"},
+
+	{action: actNewNote, keys: []string{"n"}, group: "Fichiers", help: "nouvelle note"},
+	{action: actNewFolder, keys: []string{"N"}, group: "Fichiers", help: "nouveau dossier"},
+	{action: actDelete, keys: []string{"D"}, group: "Fichiers", help: "supprimer"},
+	{action: actRename, keys: []string{"r"}, group: "Fichiers", help: "renommer"},
+	{action: actEditExternal, keys: []string{"e"}, group: "Fichiers", help: "éditeur externe"},
+	{action: actEditInline, keys: []string{"E"}, group: "Fichiers", help: "édition rapide"},
+	{action: actCopyFile, keys: []string{"c"}, group: "Fichiers", help: "copier"},
+	{action: actPaste, keys: []string{"p"}, group: "Fichiers", help: "coller"},
+	{action: actCopyPath, keys: []string{"y"}, group: "Fichiers", help: "copier le chemin"},
+	{action: actCopyContent, keys: []string{"Y"}, group: "Fichiers", help: "copier le contenu"},
+	{action: actCopyMenu, keys: []string{"C"}, group: "Fichiers", help: "copier (lien wiki, texte rendu…)"},
+	{action: actExport, keys: []string{"x"}, group: "Fichiers", help: "exporter"},
+	{action: actHistory, keys: []string{"H"}, group: "Fichiers", help: "historique"},
+	{action: actEncrypt, keys: []string{"Z"}, group: "Fichiers", help: "chiffrer/déchiffrer"},
+	{action: actMoveToFolder, group: "Fichiers", help: "déplacer vers le dossier…"},
+
+	{action: actBookmark, keys: []string{"b"}, group: "Organisation", help: "bookmark"},
+	{action: actBookmarks, keys: []string{"B"}, group: "Organisation", help: "voir bookmarks"},
+	{action: actRecent, keys: []string{"ctrl+r"}, group: "Organisation", help: "récents"},
+	{action: actLinks, keys: []string{"L"}, group: "Organisation", help: "liens wiki"},
+	{action: actGit, keys: []string{"ctrl+g"}, group: "Organisation", help: "git"},
+	{action: actSwitchVault, keys: []string{"V"}, group: "Organisation", help: "changer de vault"},
+	{action: actGitCommit, group: "Organisation", help: "git : tout committer…"},
+	{action: actGitPull, group: "Organisation", help: "git : pull"},
+	{action: actGitPush, group: "Organisation", help: "git : push"},
+
+	{action: actSearch, keys: []string{"/"}, group: "Recherche et filtres", help: "rechercher"},
+	{action: actSearchHeading, keys: []string{"#"}, group: "Recherche et filtres", help: "titres"},
+	{action: actSearchNote, keys: []string{"F"}, group: "Recherche et filtres", help: "recherche note"},
+	{action: actToggleMdOnly, keys: []string{"m"}, group: "Recherche et filtres", help: "filtre .md"},
+	{action: actToggleHidden, keys: []string{"."}, group: "Recherche et filtres", help: "cachés"},
+	{action: actSort, keys: []string{"s"}, group: "Recherche et filtres", help: "tri"},
+
+	{action: actScrollUp, keys: []string{"u"}, group: "Interface", help: "scroll ↑"},
+	{action: actScrollDown, keys: []string{"d"}, group: "Interface", help: "scroll ↓"},
+	{action: actOutline, keys: []string{"O"}, group: "Interface", help: "sommaire"},
+	{action: actTheme, keys: []string{"t"}, group: "Interface", help: "thème"},
+	{action: actHelp, keys: []string{"?"}, group: "Interface", help: "aide"},
+	{action: actPalette, keys: []string{":", "ctrl+p"}, group: "Interface", help: "palette de commandes"},
+	{action: actSettings, keys: []string{","}, group: "Interface", help: "réglages"},
+	{action: actShowConfig, group: "Interface", help: "configuration effective"},
+	{action: actQuit, keys: []string{"q", "ctrl+c"}, group: "Interface", help: "quitter"},
+}
+
+// keymap resolves key presses to actions
+type keymap struct {
+	bindings  []keyBinding      // effective bindings, in registry order
+	actions   map[string]action // sequence → action
+	prefixes  map[string]bool   // incomplete chords
+	conflicts []string
+}
+
+// newKeymap builds the keymap from the defaults and the user overrides,
+// which replace the keys of an action ("keys": {"delete": ["x", "g d"]}).
+// Overridden keys win over defaults; other clashes keep the first binding.
+func newKeymap(overrides map[string][]string) *keymap {
+	k := &keymap{
+		actions:  make(map[string]action),
+		prefixes: make(map[string]bool),
+	}
+
+	known := make(map[string]bool)
+	for _, b := range defaultBindings {
+		known[string(b.action)] = true
+	}
+	for _, name := range sortedKeys(overrides) {
+		if !known[name] {
+			k.conflicts = append(k.conflicts, fmt.Sprintf("action inconnue : %q", name))
+		}
+	}
+
+	k.bindings = make([]keyBinding, len(defaultBindings))
+	copy(k.bindings, defaultBindings)
+	for i := range k.bindings {
+		if keys, ok := overrides[string(k.bindings[i].action)]; ok {
+			k.bindings[i].keys = keys
+			k.bindings[i].custom = true
+		}
+	}
+
+	for _, custom := range []bool{true, false} {
+		for i := range k.bindings {
+			b := &k.bindings[i]
+			if b.custom != custom {
+				continue
+			}
+			var kept []string
+			for _, seq := range b.keys {
+				seq = normalizeSequence(seq)
+				if seq == "" {
+					continue
+				}
+				if other, taken := k.actions[seq]; taken {
+					if other != b.action {
+						k.conflicts = append(k.conflicts, fmt.Sprintf("%s : %s l'emporte sur %s", displaySequence(seq), other, b.action))
+					}
+					continue
+				}
+				k.actions[seq] = b.action
+				kept = append(kept, seq)
+			}
+			b.keys = kept
+		}
+	}
+
+	for seq := range k.actions {
+		parts := strings.Split(seq, " ")
+		for i := 1; i < len(parts); i++ {
+			k.prefixes[strings.Join(parts[:i], " ")] = true
+		}
+	}
+	for _, seq := range sortedKeys(k.actions) {
+		if k.prefixes[seq] {
+			k.conflicts = append(k.conflicts, fmt.Sprintf("%s (%s) est masqué : c'est aussi le début d'un autre raccourci",
+				displaySequence(seq), k.actions[seq]))
+		}
+	}
+	return k
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// normalizeSequence cleans up a sequence from the configuration
+func normalizeSequence(seq string) string {
+	parts := strings.Fields(seq)
+	for i, p := range parts {
+		parts[i] = normalizeKey(p)
+	}
+	return strings.Join(parts, " ")
+}
+
+// normalizeKey names a key as in sequences, where space is spelled out
+func normalizeKey(key string) string {
+	if key == " " {
+		return "space"
+	}
+	return key
+}
+
+// keyResult is the outcome of a key press
+type keyResult int
+
+const (
+	keyUnbound keyResult = iota
+	keyPending           // prefix of a chord, waiting for the next key
+	keyAction
+)
+
+// press feeds key to the chord in progress. prefix holds the keys pressed
+// so far and is updated.
+func (k *keymap) press(prefix *string, key string) (keyResult, action) {
+	seq := normalizeKey(key)
+	if *prefix != "" {
+		seq = *prefix + " " + seq
+	}
+	if k.prefixes[seq] {
+		*prefix = seq
+		return keyPending, ""
+	}
+	if act, ok := k.actions[seq]; ok {
+		*prefix = ""
+		return keyAction, act
+	}
+	if *prefix != "" {
+		// Abandoned chord, the key starts over
+		*prefix = ""
+		return k.press(prefix, key)
+	}
+	return keyUnbound, ""
+}
+
+// binding returns the effective binding of an action
+func (k *keymap) binding(act action) keyBinding {
+	for _, b := range k.bindings {
+		if b.action == act {
+			return b
+		}
+	}
+	return keyBinding{action: act}
+}
+
+// hint returns the first key of an action as displayed, "" when unbound
+func (k *keymap) hint(act action) string {
+	if b := k.binding(act); len(b.keys) > 0 {
+		return displaySequence(b.keys[0])
+	}
+	return ""
+}
+
+// prettyKeys names some keys the way the interface shows them
+var prettyKeys = map[string]string{
+	"up":     "↑",
+	"down":   "↓",
+	"left":   "←",
+	"right":  "→",
+	"enter":  "Enter",
+	"space":  "Espace",
+	"esc":    "Esc",
+	"tab":    "Tab",
+	"pgup":   "PgUp",
+	"pgdown": "PgDn",
+	"home":   "Début",
+	"end":    "Fin",
+}
+
+// displaySequence formats a sequence for the help, "g g" as "gg"
+func displaySequence(seq string) string {
+	parts := strings.Split(seq, " ")
+	single := true
+	for i, p := range parts {
+		if pretty, ok := prettyKeys[p]; ok {
+			p = pretty
+		} else if rest, ok := strings.CutPrefix(p, "ctrl+"); ok {
+			p = "Ctrl+" + rest
+		} else if rest, ok := strings.CutPrefix(p, "alt+"); ok {
+			p = "Alt+" + rest
+		}
+		if len([]rune(p)) > 1 {
+			single = false
+		}
+		parts[i] = p
+	}
+	if single {
+		return strings.Join(parts, "")
+	}
+	return strings.Join(parts, " ")
+}
+
+// keyHint is a key continuing a chord
+type keyHint struct {
+	key  string
+	help string
+}
+
+// continuations lists the keys completing the chord prefix
+func (k *keymap) continuations(prefix string) []keyHint {
+	seen := make(map[string]bool)
+	var hints []keyHint
+	for _, seq := range sortedKeys(k.actions) {
+		rest, ok := strings.CutPrefix(seq, prefix+" ")
+		if !ok {
+			continue
+		}
+		next, more, chord := strings.Cut(rest, " ")
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+		help := k.binding(k.actions[seq]).help
+		if chord && more != "" {
+			help = "+…"
+		}
+		hints = append(hints, keyHint{key: displaySequence(next), help: help})
+	}
+	return hints
+}
+
+// whichKeyView renders the keys completing the chord in progress
+func (k *keymap) whichKeyView(prefix string, width int) string {
+	var items []string
+	for _, h := range k.continuations(prefix) {
+		items = append(items, keyStyle.Render(h.key)+" "+h.help)
+	}
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		lipgloss.NewStyle().Bold(true).Render(displaySequence(prefix)+" …"),
+		wrapJoin(items, "   ", width-6),
+	)
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("213")).
+		Padding(0, 1).
+		Render(content)
+}
+
+var keyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("213")).Bold(true)
+
+// wrapJoin joins items with sep, breaking lines before width
+func wrapJoin(items []string, sep string, width int) string {
+	var lines []string
+	line := ""
+	for _, item := range items {
+		switch {
+		case line == "":
+			line = item
+		case width > 0 && lipgloss.Width(line+sep+item) > width:
+			lines = append(lines, line)
+			line = item
+		default:
+			line += sep + item
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// helpSections renders the bindings of each help section
+func (k *keymap) helpSections(width int) []string {
+	var sections []string
+	for _, group := range keyGroups {
+		var items []string
+		for _, b := range k.bindings {
+			if b.group != group || len(b.keys) == 0 {
+				continue
+			}
+			keys := make([]string, len(b.keys))
+			for i, seq := range b.keys {
+				keys[i] = displaySequence(seq)
+			}
+			items = append(items, keyStyle.Render(strings.Join(keys, "/"))+": "+b.help)
+		}
+		if len(items) == 0 {
+			continue
+		}
+		title := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("213")).
+			Bold(true).
+			Render(group + ":")
+		sections = append(sections, title, wrapJoin(items, " | ", width), "")
+	}
+	return sections
+}
+
+// footer renders the short key reminder under the file list
+func (k *keymap) footer() string {
+	var items []string
+	add := func(keys, label string) {
+		if keys != "" {
+			items = append(items, keys+" "+label)
+		}
+	}
+	add(k.hint(actHelp), "aide")
+	if up, down := k.hint(actUp), k.hint(actDown); up != "" && down != "" {
+		add(up+"/"+down, "naviguer")
+	}
+	add(k.hint(actDelete), "supprimer")
+	add(k.hint(actRename), "renommer")
+	add(k.hint(actToggleMdOnly), "filtre .md")
+	add(k.hint(actNewNote), "nouvelle note")
+	add(k.hint(actSearch), "rechercher")
+	add(k.hint(actSearchNote), "recherche note")
+	add(k.hint(actQuit), "quitter")
+	return strings.Join(items, " • ")
+}
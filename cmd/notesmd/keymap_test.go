@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestDefaultKeymapHasNoConflicts(t *testing.T) {
+	if k := newKeymap(nil); len(k.conflicts) > 0 {
+		t.Errorf("conflicts = %q", k.conflicts)
+	}
+}
+
+func TestKeymapChords(t *testing.T) {
+	k := newKeymap(map[string][]string{"delete": {"space d"}})
+
+	var prefix string
+	if result, _ := k.press(&prefix, "g"); result != keyPending || prefix != "g" {
+		t.Fatalf("g: result %d, prefix %q", result, prefix)
+	}
+	if result, act := k.press(&prefix, "g"); result != keyAction || act != actTop || prefix != "" {
+		t.Fatalf("gg: result %d, action %q, prefix %q", result, act, prefix)
+	}
+
+	// An abandoned chord replays the key on its own
+	k.press(&prefix, "g")
+	if result, act := k.press(&prefix, "j"); result != keyAction || act != actDown {
+		t.Fatalf("g j: result %d, action %q", result, act)
+	}
+
+	k.press(&prefix, " ")
+	if result, act := k.press(&prefix, "d"); result != keyAction || act != actDelete {
+		t.Fatalf("space d: result %d, action %q", result, act)
+	}
+	if result, _ := k.press(&prefix, "D"); result != keyUnbound {
+		t.Fatalf("D still bound after override")
+	}
+}
+
+func TestKeymapConflicts(t *testing.T) {
+	k := newKeymap(map[string][]string{
+		"export":  {"n"},      // takes the key of new_note
+		"theme":   {"x"},      // the default key of export, now free
+		"search":  {"s", "z"}, // s belongs to sort
+		"bookmak": {"b"},
+		"recent":  {"z z"}, // hides the z of search
+	})
+
+	if act := k.actions["n"]; act != actExport {
+		t.Errorf("n = %q, want export", act)
+	}
+	if act := k.actions["x"]; act != actTheme {
+		t.Errorf("x = %q, want theme", act)
+	}
+	if len(k.binding(actNewNote).keys) != 0 {
+		t.Errorf("new_note keeps %q", k.binding(actNewNote).keys)
+	}
+
+	want := []string{`"bookmak"`, "n : export", "s : search", "z (search) est masqué"}
+	all := strings.Join(k.conflicts, "\n")
+	for _, w := range want {
+		if !strings.Contains(all, w) {
+			t.Errorf("conflicts missing %q:\n%s", w, all)
+		}
+	}
+}
+
+func TestHomeKeysUseKeymap(t *testing.T) {
+	config := DefaultConfig()
+	config.Keys = map[string][]string{"theme": {"T"}, "open": {"o"}, "quit": {"Q"}}
+	m := initialModel(t.TempDir(), config, &SessionState{})
+
+	press := func(key string) tea.Cmd {
+		t.Helper()
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		updated, cmd := m.Update(msg)
+		m = updated.(model)
+		return cmd
+	}
+
+	theme := m.themeIndex
+	if press("t"); m.themeIndex != theme {
+		t.Error("unbound t still toggles the theme")
+	}
+	if press("T"); m.themeIndex == theme {
+		t.Error("rebound theme key ignored")
+	}
+	if cmd := press("q"); cmd != nil {
+		t.Error("unbound q still quits")
+	}
+	if cmd := press("Q"); cmd == nil {
+		t.Error("rebound quit key ignored")
+	} else if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("rebound quit key doesn't quit")
+	}
+	if press("o"); m.mode != modeBrowser {
+		t.Error("rebound open key doesn't enter the browser")
+	}
+}
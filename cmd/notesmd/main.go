@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	blist "github.com/charmbracelet/bubbles/list"
 	bviewport "github.com/charmbracelet/bubbles/viewport"
@@ -11,11 +12,34 @@ import (
 )
 
 func main() {
-	config, err := LoadConfig()
+	configFile, args, err := takeFlag(os.Args[1:], "config")
 	if err != nil {
-		config = DefaultConfig()
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if configFile != "" {
+		if configFileFlag, err = filepath.Abs(configFile); err != nil {
+			fmt.Fprintln(os.Stderr, "Erreur de chemin :", err)
+			os.Exit(2)
+		}
+	}
+
+	moved, err := migrateLegacyFiles()
+	for _, m := range moved {
+		fmt.Fprintln(os.Stderr, "notesmd : déplacé", m)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "notesmd : migration impossible :", err)
+	}
+
+	if code, handled := runCLI(args); handled {
+		os.Exit(code)
 	}
 
+	// Invalid settings are reported once the interface is up
+	config, configErr := LoadConfig()
+	setMarkdownTheme(config.MarkdownTheme)
+
 	state, err := LoadState()
 	if err != nil {
 		state = &SessionState{
@@ -24,9 +48,24 @@ func main() {
 		}
 	}
 
+	vaultName, args, err := takeFlag(args, "vault")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+
 	startDir := "."
-	if len(os.Args) > 1 {
-		startDir = os.Args[1]
+	if vaultName != "" {
+		v, ok := config.findVault(vaultName)
+		if !ok {
+			fmt.Println("Vault inconnu :", vaultName)
+			os.Exit(1)
+		}
+		startDir = v.dir()
+	} else if len(args) > 0 {
+		startDir = args[0]
+	} else if v, ok := config.findVault(state.LastVault); ok {
+		startDir = v.dir()
 	} else if state.LastDirectory != "" {
 		startDir = state.LastDirectory
 	} else if config.DefaultDir != "" {
@@ -39,32 +78,49 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err := runTUI(absDir, config, configErr, state, ""); err != nil {
+		fmt.Println("Erreur:", err)
+		os.Exit(1)
+	}
+}
+
+// runTUI runs the interface on absDir, opening openPath once the terminal
+// size is known when it is not empty, then saves the session state.
+// configErr, from loading config.json, is shown in a warning modal.
+func runTUI(absDir string, config *Config, configErr error, state *SessionState, openPath string) error {
 	m := initialModel(absDir, config, state)
+	m.globalConfigErr = configErr
+	m.applyVaultConfig()
+	m.openConfigWarnings(true)
+	if openPath != "" {
+		m.mode = modeBrowser
+		m.openOnStart = openPath
+	}
 
 	finalModel, err := tea.NewProgram(m).Run()
 	if err != nil {
-		fmt.Println("Erreur:", err)
-		os.Exit(1)
+		return err
 	}
 
 	if finalModel, ok := finalModel.(model); ok {
-		saveState := &SessionState{
-			LastDirectory: finalModel.currentDir,
-			LastTheme:     finalModel.themeIndex,
-			RecentFiles:   finalModel.recentFiles,
-			Bookmarks:     finalModel.bookmarks,
-		}
-		SaveState(saveState)
+		finalModel.storeVaultState()
+		finalModel.state.LastVault = finalModel.vault
+		finalModel.state.LastTheme = finalModel.themeIndex
+		SaveState(finalModel.state)
 	}
+	return nil
 }
 
-// initialModel creates and returns the initial application model
+// initialModel creates and returns the initial application model. When
+// absDir lies in a named vault, the vault and its session are restored.
 func initialModel(absDir string, config *Config, state *SessionState) model {
 	items := []blist.Item{}
 
 	l := blist.New(items, blist.NewDefaultDelegate(), 0, 0)
 	l.Title = "Fichiers"
 	l.SetShowHelp(false)
+	// Keys go through the keymap, the list keeps none of its own
+	l.KeyMap = blist.KeyMap{}
 
 	vp := bviewport.New(0, 0)
 	vp.SetContent("")
@@ -76,17 +132,7 @@ func initialModel(absDir string, config *Config, state *SessionState) model {
 		themeIndex = config.Theme
 	}
 
-	recentFiles := state.RecentFiles
-	if recentFiles == nil {
-		recentFiles = []string{}
-	}
-
-	bookmarks := state.Bookmarks
-	if bookmarks == nil {
-		bookmarks = []string{}
-	}
-
-	return model{
+	m := model{
 		mode:              modeHome,
 		rootDir:           absDir,
 		currentDir:        absDir,
@@ -96,28 +142,51 @@ func initialModel(absDir string, config *Config, state *SessionState) model {
 		autoPreview:       true,
 		lastSelectedIndex: -1,
 		config:            config,
-		recentFiles:       recentFiles,
-		bookmarks:         bookmarks,
+		globalConfig:      config,
+		state:             state,
 		statusBar:         NewStatusBar(),
 		themeIndex:        themeIndex,
 		mdOnly:            config.Filters.MdOnly,
 		showHidden:        config.Filters.ShowHidden,
 		sortMode:          config.Filters.SortMode,
+		keys:              newKeymap(config.Keys),
+		ignore:            setVaultIgnore(absDir, config),
 	}
+
+	// In a named vault the session is its own, the flat fields of the state
+	// belong to folders outside vaults
+	if v, ok := config.vaultForDir(absDir); ok {
+		m.rootDir = v.dir()
+		m.restoreVaultState(v)
+		if absDir != m.rootDir {
+			// An explicit folder of the vault wins over its last position
+			m.currentDir = absDir
+			m.resumeNote = ""
+		}
+	} else {
+		m.recentFiles = append([]string{}, state.RecentFiles...)
+		m.bookmarks = append([]string{}, state.Bookmarks...)
+	}
+	return m
 }
 
 // Init initializes the Bubble Tea program
 func (m model) Init() tea.Cmd {
-	return tea.EnterAltScreen
+	cmds := []tea.Cmd{tea.EnterAltScreen, m.refreshGit(), watchConfig()}
+	if n := len(m.keys.conflicts); n > 0 {
+		cmds = append(cmds, statusMessage(fmt.Sprintf("⚠ %d conflit(s) de raccourcis, voir l'aide (%s)", n, m.keys.hint(actHelp)), 5*time.Second))
+	}
+	return tea.Batch(cmds...)
 }
 
-// View delegates to the appropriate view function based on mode
+// View delegates to the appropriate view function based on mode. A pending
+// OSC 52 sequence leads the frame, it takes no room on screen.
 func (m model) View() string {
 	switch m.mode {
 	case modeHome:
-		return m.viewHome()
+		return m.osc52 + m.viewHome()
 	case modeBrowser:
-		return m.viewBrowser()
+		return m.osc52 + m.viewBrowser()
 	default:
 		return ""
 	}
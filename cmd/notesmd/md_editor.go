@@ -0,0 +1,698 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// Indentation inserted by tab in the inline editor
+const editorIndent = "  "
+
+// Bracket pairs completed automatically while typing
+var autoPairs = map[rune]rune{
+	'(': ')',
+	'[': ']',
+	'{': '}',
+}
+
+// listItem describes the prefix of a Markdown list line
+type listItem struct {
+	indent   string // leading whitespace
+	marker   string // "- ", "* ", "+ ", "1. " or "1) "
+	checkbox string // "[ ] ", "[x] " or ""
+	body     string // text after the prefix
+}
+
+// prefix returns the full list prefix (indent, marker and checkbox)
+func (l listItem) prefix() string {
+	return l.indent + l.marker + l.checkbox
+}
+
+// checked reports whether the item is a completed task
+func (l listItem) checked() bool {
+	return l.checkbox == "[x] " || l.checkbox == "[X] "
+}
+
+// nextMarker returns the marker to use for the following item
+func (l listItem) nextMarker() string {
+	num := strings.TrimRight(l.marker, ".) ")
+	n, err := strconv.Atoi(num)
+	if err != nil {
+		return l.marker
+	}
+	delim := l.marker[len(num) : len(num)+1]
+	return fmt.Sprintf("%d%s ", n+1, delim)
+}
+
+// parseListItem parses a bullet, numbered or task list line
+func parseListItem(line string) (listItem, bool) {
+	rest := strings.TrimLeft(line, " \t")
+	item := listItem{indent: line[:len(line)-len(rest)]}
+
+	switch {
+	case strings.HasPrefix(rest, "- "), strings.HasPrefix(rest, "* "), strings.HasPrefix(rest, "+ "):
+		item.marker = rest[:2]
+	default:
+		digits := 0
+		for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+			digits++
+		}
+		if digits == 0 || digits > 9 || len(rest) < digits+2 {
+			return listItem{}, false
+		}
+		if (rest[digits] != '.' && rest[digits] != ')') || rest[digits+1] != ' ' {
+			return listItem{}, false
+		}
+		item.marker = rest[:digits+2]
+	}
+	rest = rest[len(item.marker):]
+
+	for _, box := range []string{"[ ] ", "[x] ", "[X] "} {
+		if strings.HasPrefix(rest, box) || rest == strings.TrimSpace(box) {
+			item.checkbox = box
+			rest = strings.TrimPrefix(rest, strings.TrimSpace(box))
+			rest = strings.TrimPrefix(rest, " ")
+			break
+		}
+	}
+
+	item.body = rest
+	return item, true
+}
+
+// Lines between two cached fence states in the inline editor
+const fenceCheckpointStride = 256
+
+// markdownEditor is a Markdown text editor with highlighting and smart editing
+type markdownEditor struct {
+	buf             textBuffer
+	focused         bool
+	placeholder     string
+	width           int
+	height          int
+	offset          int // first visible line
+	showLineNumbers bool
+	completer       linkCompleter
+
+	// fence state before every fenceCheckpointStride-th line
+	fenceCheckpoints []fenceState
+}
+
+// newMarkdownEditor creates an editor of the given size
+func newMarkdownEditor(width, height int) markdownEditor {
+	e := markdownEditor{buf: newTextBuffer("")}
+	e.SetSize(width, height)
+	return e
+}
+
+// SetSize sets the editor dimensions, gutter included
+func (e *markdownEditor) SetSize(width, height int) {
+	e.width = max(width, 10)
+	e.height = max(height, 1)
+}
+
+// SetShowLineNumbers toggles the line number gutter
+func (e *markdownEditor) SetShowLineNumbers(show bool) {
+	e.showLineNumbers = show
+}
+
+// SetLinkTargets sets the vault notes offered by [[ completion
+func (e *markdownEditor) SetLinkTargets(rootDir string, notes []string) {
+	e.completer = linkCompleter{rootDir: rootDir, notes: notes}
+}
+
+// Completing reports whether the completion popup is open
+func (e markdownEditor) Completing() bool {
+	return e.completer.active
+}
+
+// textWidth returns the width available for text after the gutter
+func (e markdownEditor) textWidth() int {
+	if e.showLineNumbers {
+		gutter := len(strconv.Itoa(e.buf.LineCount())) + 2
+		return max(e.width-max(gutter, 5), 5)
+	}
+	return e.width
+}
+
+func (e *markdownEditor) SetValue(s string) {
+	e.buf = newTextBuffer(s)
+	e.offset = 0
+	e.fenceCheckpoints = nil
+	e.scrollToCursor()
+}
+
+func (e markdownEditor) Value() string {
+	return e.buf.String()
+}
+
+func (e *markdownEditor) Focus() tea.Cmd {
+	e.focused = true
+	return nil
+}
+
+func (e *markdownEditor) Blur() {
+	e.focused = false
+}
+
+func (e markdownEditor) Focused() bool {
+	return e.focused
+}
+
+// cursor returns the cursor line and rune column
+func (e markdownEditor) cursor() (row, col int) {
+	return e.buf.row, e.buf.col
+}
+
+// currentLine returns the runes of the line under the cursor
+func (e markdownEditor) currentLine() []rune {
+	return e.buf.Line(e.buf.row)
+}
+
+// runeAt returns the rune at col on the current line, or 0
+func (e markdownEditor) runeAt(col int) rune {
+	line := e.currentLine()
+	if col < 0 || col >= len(line) {
+		return 0
+	}
+	return line[col]
+}
+
+// edited drops cached state invalidated by a change at the cursor line
+func (e *markdownEditor) edited(row int) {
+	keep := row/fenceCheckpointStride + 1
+	if keep < len(e.fenceCheckpoints) {
+		e.fenceCheckpoints = e.fenceCheckpoints[:keep]
+	}
+}
+
+func (e *markdownEditor) insert(s string) {
+	e.edited(e.buf.row)
+	e.buf.Insert(s)
+}
+
+func (e *markdownEditor) setColumn(col int) {
+	e.buf.SetCursor(e.buf.row, col)
+}
+
+// deleteBefore removes n runes before the cursor
+func (e *markdownEditor) deleteBefore(n int) {
+	e.edited(max(e.buf.row-1, 0))
+	e.buf.DeleteBefore(n)
+}
+
+// deleteAfter removes n runes after the cursor
+func (e *markdownEditor) deleteAfter(n int) {
+	e.edited(e.buf.row)
+	e.buf.DeleteAfter(n)
+}
+
+// Update handles editing keys, smart Markdown editing and completion
+func (e markdownEditor) Update(msg tea.Msg) (markdownEditor, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok || !e.Focused() {
+		return e, nil
+	}
+
+	switch {
+	case e.completer.active && e.handleCompletionKey(key):
+	case e.handleSmartKey(key):
+		e.refreshCompletion()
+	default:
+		e.handleEditKey(key)
+		e.refreshCompletion()
+	}
+
+	e.scrollToCursor()
+	e.updateFenceCheckpoints()
+	return e, nil
+}
+
+// handleEditKey applies basic cursor movement and text editing
+func (e *markdownEditor) handleEditKey(msg tea.KeyMsg) {
+	b := &e.buf
+
+	switch msg.String() {
+	case "left", "ctrl+b":
+		if b.col > 0 {
+			b.col--
+		} else if b.row > 0 {
+			b.SetCursor(b.row-1, len(b.Line(b.row-1)))
+		}
+	case "right", "ctrl+f":
+		if b.col < len(b.Line(b.row)) {
+			b.col++
+		} else if b.row < b.LineCount()-1 {
+			b.SetCursor(b.row+1, 0)
+		}
+	case "up", "ctrl+p":
+		e.moveVertical(-1)
+	case "down", "ctrl+n":
+		e.moveVertical(1)
+	case "pgup":
+		e.moveVertical(-e.height)
+	case "pgdown":
+		e.moveVertical(e.height)
+	case "home", "ctrl+a":
+		b.col = 0
+	case "end", "ctrl+e":
+		b.col = len(b.Line(b.row))
+	case "alt+left", "alt+b", "ctrl+left":
+		b.WordLeft()
+	case "alt+right", "alt+f", "ctrl+right":
+		b.WordRight()
+	case "ctrl+home", "alt+<":
+		b.SetCursor(0, 0)
+	case "ctrl+end", "alt+>":
+		last := b.LineCount() - 1
+		b.SetCursor(last, len(b.Line(last)))
+	case "enter", "ctrl+m":
+		e.insert("\n")
+	case "backspace", "ctrl+h":
+		e.deleteBefore(1)
+	case "delete", "ctrl+d":
+		e.deleteAfter(1)
+	case "ctrl+w", "alt+backspace":
+		row, col := b.row, b.col
+		b.WordLeft()
+		if b.row != row {
+			// At the start of a line, join it with the previous one
+			b.SetCursor(row, col)
+			e.deleteBefore(1)
+			break
+		}
+		n := col - b.col
+		b.col = col
+		e.deleteBefore(n)
+	case "ctrl+k":
+		e.deleteAfter(len(b.Line(b.row)) - b.col)
+	case "ctrl+u":
+		e.deleteBefore(b.col)
+	default:
+		if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+			e.insert(string(msg.Runes))
+		}
+	}
+}
+
+// moveVertical moves the cursor by n visual (soft-wrapped) rows
+func (e *markdownEditor) moveVertical(n int) {
+	width := e.textWidth()
+	b := &e.buf
+
+	for ; n != 0; n -= sign(n) {
+		line := b.Line(b.row)
+		rows := wrapLine(line, width)
+		r := visualRow(rows, b.col)
+		x := columnsOf(line[rows[r][0]:b.col])
+
+		switch {
+		case n < 0 && r > 0:
+			b.col = runeAtColumn(line, rows[r-1], x)
+		case n < 0 && b.row > 0:
+			b.row--
+			prev := wrapLine(b.Line(b.row), width)
+			b.col = runeAtColumn(b.Line(b.row), prev[len(prev)-1], x)
+		case n > 0 && r < len(rows)-1:
+			b.col = runeAtColumn(line, rows[r+1], x)
+		case n > 0 && b.row < b.LineCount()-1:
+			b.row++
+			next := wrapLine(b.Line(b.row), width)
+			b.col = runeAtColumn(b.Line(b.row), next[0], x)
+		default:
+			return
+		}
+	}
+}
+
+// visualRow returns the wrapped row of rows containing col
+func visualRow(rows [][2]int, col int) int {
+	for i, r := range rows {
+		if col < r[1] || i == len(rows)-1 {
+			return i
+		}
+	}
+	return 0
+}
+
+func sign(n int) int {
+	if n < 0 {
+		return -1
+	}
+	return 1
+}
+
+// handleCompletionKey navigates or accepts the completion popup
+func (e *markdownEditor) handleCompletionKey(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "up", "ctrl+p":
+		e.completer.moveSelection(-1)
+		return true
+	case "down", "ctrl+n":
+		e.completer.moveSelection(1)
+		return true
+	case "esc":
+		e.completer.dismiss()
+		return true
+	case "enter", "tab":
+		e.acceptCompletion()
+		return true
+	}
+	return false
+}
+
+// acceptCompletion replaces the typed link text with the selected suggestion
+func (e *markdownEditor) acceptCompletion() {
+	item, ok := e.completer.selectedItem()
+	if !ok {
+		return
+	}
+	_, col := e.cursor()
+
+	e.deleteBefore(col - e.completer.start)
+	e.insert(item.insert)
+
+	_, col = e.cursor()
+	if e.runeAt(col) == ']' && e.runeAt(col+1) == ']' {
+		e.setColumn(col + 2)
+	} else {
+		e.insert("]]")
+	}
+	e.completer.active = false
+}
+
+// refreshCompletion updates the popup for the current cursor position
+func (e *markdownEditor) refreshCompletion() {
+	row, col := e.cursor()
+	e.completer.update(e.currentLine(), row, col, e.Value)
+}
+
+// handleSmartKey applies Markdown-aware editing, returning true if handled
+func (e *markdownEditor) handleSmartKey(msg tea.KeyMsg) bool {
+	if msg.Paste {
+		return false
+	}
+
+	switch msg.String() {
+	case "enter":
+		return e.continueList()
+	case "tab":
+		e.indentLine()
+		return true
+	case "shift+tab":
+		e.outdentLine()
+		return true
+	case "backspace":
+		return e.deletePair()
+	}
+
+	if msg.Type == tea.KeyRunes && len(msg.Runes) == 1 {
+		return e.autoPair(msg.Runes[0])
+	}
+	return false
+}
+
+// continueList starts a new list item (or ends the list) on enter
+func (e *markdownEditor) continueList() bool {
+	line := string(e.currentLine())
+	_, col := e.cursor()
+
+	item, ok := parseListItem(line)
+	if !ok {
+		if strings.HasPrefix(strings.TrimLeft(line, " "), "> ") && col == len([]rune(line)) {
+			indent := line[:len(line)-len(strings.TrimLeft(line, " "))]
+			e.insert("\n" + indent + "> ")
+			return true
+		}
+		return false
+	}
+
+	prefixLen := len([]rune(item.prefix()))
+	if col < prefixLen {
+		return false
+	}
+
+	// Enter on an empty item ends the list
+	if strings.TrimSpace(item.body) == "" && col == len([]rune(line)) {
+		e.deleteBefore(col)
+		return true
+	}
+
+	next := item.indent + item.nextMarker()
+	if item.checkbox != "" {
+		next += "[ ] "
+	}
+	e.insert("\n" + next)
+	return true
+}
+
+// indentLine indents list items, or inserts indentation elsewhere
+func (e *markdownEditor) indentLine() {
+	line := string(e.currentLine())
+	_, col := e.cursor()
+
+	if _, ok := parseListItem(line); !ok {
+		e.insert(editorIndent)
+		return
+	}
+
+	e.setColumn(0)
+	e.insert(editorIndent)
+	e.setColumn(col + len(editorIndent))
+}
+
+// outdentLine removes one level of indentation from the current line
+func (e *markdownEditor) outdentLine() {
+	line := string(e.currentLine())
+	_, col := e.cursor()
+
+	n := 0
+	for n < len(editorIndent) && n < len(line) && line[n] == ' ' {
+		n++
+	}
+	if n == 0 {
+		return
+	}
+
+	e.setColumn(0)
+	e.deleteAfter(n)
+	e.setColumn(max(col-n, 0))
+}
+
+// autoPair inserts matching brackets and steps over closing ones
+func (e *markdownEditor) autoPair(r rune) bool {
+	_, col := e.cursor()
+
+	for _, closer := range autoPairs {
+		if r == closer && e.runeAt(col) == closer {
+			e.setColumn(col + 1)
+			return true
+		}
+	}
+
+	closer, ok := autoPairs[r]
+	if !ok {
+		return false
+	}
+
+	// Only pair when the next character is not part of a word
+	if next := e.runeAt(col); next != 0 && isWordRune(next) {
+		return false
+	}
+
+	e.insert(string([]rune{r, closer}))
+	e.setColumn(col + 1)
+	return true
+}
+
+// deletePair removes both characters of an empty bracket pair
+func (e *markdownEditor) deletePair() bool {
+	_, col := e.cursor()
+	if col == 0 {
+		return false
+	}
+
+	closer, ok := autoPairs[e.runeAt(col-1)]
+	if !ok || e.runeAt(col) != closer {
+		return false
+	}
+
+	e.deleteAfter(1)
+	e.deleteBefore(1)
+	return true
+}
+
+// columnsOf returns the screen width of runes, tabs expanded
+func columnsOf(runes []rune) int {
+	n := 0
+	for _, r := range runes {
+		n += runeColumns(r)
+	}
+	return n
+}
+
+// runeColumns returns the screen width of r: two for wide runes, zero for
+// combining marks
+func runeColumns(r rune) int {
+	if r == '\t' {
+		return editorTabWidth
+	}
+	return runewidth.RuneWidth(r)
+}
+
+// wrapLine splits a line into rune ranges of at most width screen columns
+func wrapLine(line []rune, width int) [][2]int {
+	if len(line) == 0 {
+		return [][2]int{{0, 0}}
+	}
+	var rows [][2]int
+	start, used := 0, 0
+	for i, r := range line {
+		w := runeColumns(r)
+		if used+w > width && i > start {
+			rows = append(rows, [2]int{start, i})
+			start, used = i, 0
+		}
+		used += w
+	}
+	rows = append(rows, [2]int{start, len(line)})
+	// Keep room for the cursor after a full last row
+	if used >= width {
+		rows = append(rows, [2]int{len(line), len(line)})
+	}
+	return rows
+}
+
+// runeAtColumn returns the rune of the row span shown at screen column x
+func runeAtColumn(line []rune, span [2]int, x int) int {
+	col := 0
+	for i := span[0]; i < span[1]; i++ {
+		w := runeColumns(line[i])
+		if col+w > x {
+			return i
+		}
+		col += w
+	}
+	return span[1]
+}
+
+// scrollToCursor adjusts the scroll offset so the cursor stays visible
+func (e *markdownEditor) scrollToCursor() {
+	row, _ := e.cursor()
+	if row < e.offset {
+		e.offset = row
+		return
+	}
+
+	width := e.textWidth()
+	used := 0
+	for i := row; i >= e.offset; i-- {
+		used += len(wrapLine(e.buf.Line(i), width))
+		if used > e.height {
+			e.offset = min(i+1, row)
+			return
+		}
+	}
+}
+
+// updateFenceCheckpoints extends the fence cache past the visible lines
+func (e *markdownEditor) updateFenceCheckpoints() {
+	target := (e.offset + e.height) / fenceCheckpointStride
+	if len(e.fenceCheckpoints) == 0 {
+		e.fenceCheckpoints = []fenceState{{}}
+	}
+	for len(e.fenceCheckpoints) <= target {
+		k := len(e.fenceCheckpoints) - 1
+		state := e.fenceCheckpoints[k]
+		end := min((k+1)*fenceCheckpointStride, e.buf.LineCount())
+		for i := k * fenceCheckpointStride; i < end; i++ {
+			state = state.next(string(e.buf.Line(i)))
+		}
+		e.fenceCheckpoints = append(e.fenceCheckpoints, state)
+	}
+}
+
+// fenceBefore returns the fence state before line i
+func (e markdownEditor) fenceBefore(i int) fenceState {
+	var state fenceState
+	start := 0
+	if k := min(i/fenceCheckpointStride, len(e.fenceCheckpoints)-1); k >= 0 {
+		state = e.fenceCheckpoints[k]
+		start = k * fenceCheckpointStride
+	}
+	for j := start; j < i; j++ {
+		state = state.next(string(e.buf.Line(j)))
+	}
+	return state
+}
+
+// View renders the visible lines with Markdown highlighting
+func (e markdownEditor) View() string {
+	if e.buf.LineCount() == 1 && len(e.buf.Line(0)) == 0 && e.placeholder != "" {
+		placeholder := helpStyle.Render(e.placeholder)
+		if e.Focused() {
+			placeholder = lipgloss.NewStyle().Reverse(true).Render(" ") + placeholder
+		}
+		return lipgloss.NewStyle().Width(e.width).Height(e.height).Render(placeholder)
+	}
+
+	row, col := e.cursor()
+	width := e.textWidth()
+	gutterWidth := e.width - width
+
+	gutterStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+	cursorStyle := lipgloss.NewStyle().Reverse(true)
+
+	state := e.fenceBefore(e.offset)
+
+	var out []string
+	for i := e.offset; i < e.buf.LineCount() && len(out) < e.height; i++ {
+		line := string(e.buf.Line(i))
+		h := highlightMarkdownLine(line, state)
+		state = state.next(line)
+
+		cursorCol := -1
+		if e.Focused() && i == row {
+			cursorCol = col
+		}
+
+		for r, span := range wrapLine(h.runes, width) {
+			if len(out) >= e.height {
+				break
+			}
+
+			var b strings.Builder
+			if e.showLineNumbers {
+				if r == 0 {
+					b.WriteString(gutterStyle.Render(fmt.Sprintf("%*d ", gutterWidth-1, i+1)))
+				} else {
+					b.WriteString(strings.Repeat(" ", gutterWidth))
+				}
+			}
+
+			b.WriteString(h.render(span[0], span[1], cursorCol))
+
+			// Cursor past the end of the line
+			if cursorCol == len(h.runes) && span[1] == len(h.runes) && columnsOf(h.runes[span[0]:span[1]]) < width {
+				b.WriteString(cursorStyle.Render(" "))
+			}
+
+			out = append(out, b.String())
+		}
+	}
+
+	for len(out) < e.height {
+		out = append(out, "")
+	}
+
+	view := lipgloss.NewStyle().Width(e.width).Render(strings.Join(out, "\n"))
+	if popup := e.completer.View(e.width); popup != "" {
+		view = lipgloss.JoinVertical(lipgloss.Left, view, popup)
+	}
+	return view
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseListItem(t *testing.T) {
+	tests := []struct {
+		line                           string
+		ok                             bool
+		indent, marker, checkbox, body string
+		next                           string
+	}{
+		{line: "- item", ok: true, marker: "- ", body: "item", next: "- "},
+		{line: "  * item", ok: true, indent: "  ", marker: "* ", body: "item", next: "* "},
+		{line: "+ ", ok: true, marker: "+ ", next: "+ "},
+		{line: "1. un", ok: true, marker: "1. ", body: "un", next: "2. "},
+		{line: "\t9) neuf", ok: true, indent: "\t", marker: "9) ", body: "neuf", next: "10) "},
+		{line: "- [ ] tâche", ok: true, marker: "- ", checkbox: "[ ] ", body: "tâche", next: "- "},
+		{line: "- [x] faite", ok: true, marker: "- ", checkbox: "[x] ", body: "faite", next: "- "},
+		{line: "3. [X]", ok: true, marker: "3. ", checkbox: "[X] ", next: "4. "},
+		{line: "-item"},
+		{line: "1.item"},
+		{line: "texte"},
+		{line: "1234567890. trop long"},
+	}
+	for _, tt := range tests {
+		item, ok := parseListItem(tt.line)
+		if ok != tt.ok {
+			t.Errorf("parseListItem(%q) ok = %v", tt.line, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if item.indent != tt.indent || item.marker != tt.marker || item.checkbox != tt.checkbox || item.body != tt.body {
+			t.Errorf("parseListItem(%q) = %+v", tt.line, item)
+		}
+		if next := item.nextMarker(); next != tt.next {
+			t.Errorf("nextMarker(%q) = %q, want %q", tt.line, next, tt.next)
+		}
+	}
+}
+
+func TestContinueList(t *testing.T) {
+	tests := []struct {
+		name, value, want string
+	}{
+		{"bullet", "- un", "- un\n- "},
+		{"numbered", "  1. un", "  1. un\n  2. "},
+		{"renumbered", "9) neuf", "9) neuf\n10) "},
+		{"task", "- [x] fait", "- [x] fait\n- [ ] "},
+		{"quote", "> cité", "> cité\n> "},
+		{"empty item ends the list", "- un\n- ", "- un\n"},
+		{"empty task ends the list", "1. [ ] ", ""},
+		{"plain text", "texte", "texte\n"},
+	}
+	for _, tt := range tests {
+		e := newMarkdownEditor(80, 10)
+		e.SetValue(tt.value)
+		e.Focus()
+		e, _ = e.Update(tea.KeyMsg{Type: tea.KeyCtrlEnd})
+		e, _ = e.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if got := e.Value(); got != tt.want {
+			t.Errorf("%s: enter on %q gives %q, want %q", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
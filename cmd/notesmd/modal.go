@@ -7,10 +7,11 @@ import (
 	"strings"
 
 	blist "github.com/charmbracelet/bubbles/list"
-	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	fuzzy "github.com/sahilm/fuzzy"
 )
 
 // focusField represents which field is currently focused
@@ -24,7 +25,7 @@ const (
 // noteModal represents the note creation modal state
 type noteModal struct {
 	nameInput    textinput.Model
-	contentInput textarea.Model
+	contentInput markdownEditor
 	focused      focusField
 	width        int
 	height       int
@@ -39,17 +40,13 @@ func newNoteModal() noteModal {
 	ti.CharLimit = 100
 	ti.Width = 50
 
-	// Content textarea
-	ta := textarea.New()
-	ta.Placeholder = "Écrivez votre note en Markdown..."
-	ta.ShowLineNumbers = false
-	ta.CharLimit = 5000
-	ta.SetWidth(60)
-	ta.SetHeight(10)
+	// Content editor
+	ed := newMarkdownEditor(60, 10)
+	ed.placeholder = "Écrivez votre note en Markdown..."
 
 	return noteModal{
 		nameInput:    ti,
-		contentInput: ta,
+		contentInput: ed,
 		focused:      focusName,
 	}
 }
@@ -62,6 +59,10 @@ func (m noteModal) Update(msg tea.Msg) (noteModal, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "tab", "shift+tab":
+			// Tab accepts a link suggestion while completing
+			if m.focused == focusContent && m.contentInput.Completing() {
+				break
+			}
 			// Switch focus between fields
 			if m.focused == focusName {
 				m.focused = focusContent
@@ -107,7 +108,7 @@ func (m noteModal) View() string {
 
 	// Help text
 	helpText := helpStyle.Render(
-		"TAB: changer de champ • Ctrl+S/Ctrl+Enter: sauvegarder • Esc: annuler",
+		"TAB: changer de champ • [[: lier une note • Ctrl+S/Ctrl+Enter: sauvegarder • Esc: annuler",
 	)
 
 	// Assemble modal content
@@ -136,6 +137,11 @@ func (m noteModal) View() string {
 	return modal
 }
 
+// SetLinkTargets enables [[ completion over the given vault notes
+func (m *noteModal) SetLinkTargets(rootDir string, notes []string) {
+	m.contentInput.SetLinkTargets(rootDir, notes)
+}
+
 // GetName returns the current name input value
 func (m noteModal) GetName() string {
 	return strings.TrimSpace(m.nameInput.Value())
@@ -148,28 +154,7 @@ func (m noteModal) GetContent() string {
 
 // CreateNote creates the note file and returns the path
 func (m noteModal) CreateNote(currentDir string) (string, error) {
-	name := m.GetName()
-	if name == "" {
-		return "", fmt.Errorf("le nom ne peut pas être vide")
-	}
-
-	// Add .md extension if not present
-	if filepath.Ext(name) == "" {
-		name += ".md"
-	}
-
-	path := filepath.Join(currentDir, name)
-
-	// Title derived from filename (without extension)
-	title := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
-	content := fmt.Sprintf("# %s\n\n%s\n", title, m.GetContent())
-
-	err := os.WriteFile(path, []byte(content), 0o644)
-	if err != nil {
-		return "", err
-	}
-
-	return path, nil
+	return createNote(currentDir, m.GetName(), m.GetContent())
 }
 
 // ========== Delete Confirmation Modal ==========
@@ -508,74 +493,33 @@ func (m bookmarksModal) View() string {
 
 // ========== Help Modal ==========
 
-type helpModal struct{}
+type helpModal struct {
+	keys *keymap
+}
 
-func newHelpModal() helpModal {
-	return helpModal{}
+func newHelpModal(keys *keymap) helpModal {
+	return helpModal{keys: keys}
 }
 
 func (m helpModal) View() string {
 	title := titleStyle.Render("📖 Raccourcis")
 
-	// Navigation section
-	navTitle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("213")).
-		Bold(true).
-		Render("Navigation:")
-	navContent := `j/k/↑/↓: liste | gg: début | G: fin | Ctrl+d/u: page
-Ctrl+o/i: historique | -: parent | ~: home | h/l: ←/→`
-
-	// File operations section
-	fileTitle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("213")).
-		Bold(true).
-		Render("Fichiers:")
-	fileContent := `n: nouvelle note | N: dossier | D: supprimer | r: renommer
-e: éditeur externe | E: édition rapide | c: copier | p: coller | y: path | Y: contenu`
-
-	// Organization section
-	orgTitle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("213")).
-		Bold(true).
-		Render("Organisation:")
-	orgContent := `b: bookmark | B: voir bookmarks | Ctrl+R: récents | L: liens wiki`
-
-	// Filters section
-	filterTitle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("213")).
-		Bold(true).
-		Render("Filtres:")
-	filterContent := `m: .md only | .: hidden | s: tri | /: nom | F: note`
+	// Sections are generated from the keymap so they follow the configuration
+	parts := []string{title}
+	parts = append(parts, m.keys.helpSections(64)...)
 
-	// Interface section
-	uiTitle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("213")).
-		Bold(true).
-		Render("Interface:")
-	uiContent := `u/d: scroll | t: thème | ?: aide | q: quitter`
+	if len(m.keys.conflicts) > 0 {
+		conflictTitle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("196")).
+			Bold(true).
+			Render("Conflits de raccourcis:")
+		parts = append(parts, conflictTitle, strings.Join(m.keys.conflicts, "\n"), "")
+	}
 
-	helpText := helpStyle.Render("Esc ou ? pour fermer")
+	helpText := helpStyle.Render("Esc ou " + m.keys.hint(actHelp) + " pour fermer")
+	parts = append(parts, helpText)
 
-	content := lipgloss.JoinVertical(
-		lipgloss.Left,
-		title,
-		navTitle,
-		navContent,
-		"",
-		fileTitle,
-		fileContent,
-		"",
-		orgTitle,
-		orgContent,
-		"",
-		filterTitle,
-		filterContent,
-		"",
-		uiTitle,
-		uiContent,
-		"",
-		helpText,
-	)
+	content := lipgloss.JoinVertical(lipgloss.Left, parts...)
 
 	modalStyle := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
@@ -589,26 +533,23 @@ e: éditeur externe | E: édition rapide | c: copier | p: coller | y: path | Y:
 // ========== Edit Modal ==========
 
 type editModal struct {
-	textarea textarea.Model
+	editor   markdownEditor
 	notePath string
+	loaded   bool // content was read, and decrypted, from the note
 	width    int
 	height   int
 }
 
 func newEditModal(notePath string, content string, width, height int) editModal {
-	ta := textarea.New()
-	ta.Placeholder = "Éditez votre note en Markdown..."
-	ta.ShowLineNumbers = true
-	ta.CharLimit = 50000
-
 	// Use most of the screen for editing
-	ta.SetWidth(width - 20)
-	ta.SetHeight(height - 15)
-	ta.SetValue(content)
-	ta.Focus()
+	ed := newMarkdownEditor(width-20, height-15)
+	ed.SetShowLineNumbers(true)
+	ed.placeholder = "Éditez votre note en Markdown..."
+	ed.SetValue(content)
+	ed.Focus()
 
 	return editModal{
-		textarea: ta,
+		editor:   ed,
 		notePath: notePath,
 		width:    width,
 		height:   height,
@@ -617,7 +558,7 @@ func newEditModal(notePath string, content string, width, height int) editModal
 
 func (m editModal) Update(msg tea.Msg) (editModal, tea.Cmd) {
 	var cmd tea.Cmd
-	m.textarea, cmd = m.textarea.Update(msg)
+	m.editor, cmd = m.editor.Update(msg)
 	return m, cmd
 }
 
@@ -631,9 +572,9 @@ func (m editModal) View() string {
 		Foreground(lipgloss.Color("81")).
 		Render(filepath.Base(m.notePath))
 
-	textareaView := m.textarea.View()
+	textareaView := m.editor.View()
 
-	helpText := helpStyle.Render("Ctrl+S: sauvegarder • Esc: annuler")
+	helpText := helpStyle.Render("Ctrl+S: sauvegarder • Tab/Shift+Tab: indenter • [[: lier une note • Esc: annuler")
 
 	content := lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -654,7 +595,7 @@ func (m editModal) View() string {
 }
 
 func (m editModal) GetContent() string {
-	return m.textarea.Value()
+	return m.editor.Value()
 }
 
 // ========== Links Modal ==========
@@ -690,7 +631,7 @@ type linksModal struct {
 func newLinksModal(links []string, rootDir string, width, height int) linksModal {
 	items := make([]blist.Item, 0, len(links))
 	for _, linkName := range links {
-		notePath := findNoteByName(linkName, rootDir)
+		notePath := findNoteByName(wikiLinkTarget(linkName), rootDir)
 		items = append(items, linkItem{
 			name:   linkName,
 			path:   notePath,
@@ -740,3 +681,1137 @@ func (m linksModal) View() string {
 
 	return modalStyle.Render(content)
 }
+
+// ========== Symbol Search Modal ==========
+
+type symbolSearchModal struct {
+	input   textinput.Model
+	list    blist.Model
+	symbols []headingSymbol
+}
+
+func newSymbolSearchModal(symbols []headingSymbol, width, height int) symbolSearchModal {
+	ti := textinput.New()
+	ti.Placeholder = "titre, note › titre..."
+	ti.Focus()
+	ti.CharLimit = 100
+	ti.Width = 50
+
+	l := blist.New(nil, blist.NewDefaultDelegate(), width-10, height-14)
+	l.Title = "Titres du vault"
+	l.SetShowHelp(false)
+	l.SetShowFilter(false)
+
+	m := symbolSearchModal{
+		input:   ti,
+		list:    l,
+		symbols: symbols,
+	}
+	m.refresh()
+	return m
+}
+
+// refresh fuzzy-matches the symbols against the query
+func (m *symbolSearchModal) refresh() {
+	query := strings.TrimSpace(m.input.Value())
+
+	var items []blist.Item
+	if query == "" {
+		for _, s := range m.symbols {
+			items = append(items, s)
+		}
+	} else {
+		labels := make([]string, len(m.symbols))
+		for i, s := range m.symbols {
+			labels[i] = s.label
+		}
+		for _, match := range fuzzy.Find(query, labels) {
+			items = append(items, m.symbols[match.Index])
+		}
+	}
+
+	m.list.SetItems(items)
+	m.list.Select(0)
+}
+
+func (m symbolSearchModal) Update(msg tea.Msg) (symbolSearchModal, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.String() {
+		case "ctrl+p":
+			m.list.CursorUp()
+			return m, nil
+		case "ctrl+n":
+			m.list.CursorDown()
+			return m, nil
+		case "up", "down", "pgup", "pgdown":
+			m.list, cmd = m.list.Update(key)
+			return m, cmd
+		}
+	}
+
+	before := m.input.Value()
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != before {
+		m.refresh()
+	}
+	return m, cmd
+}
+
+func (m symbolSearchModal) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("213")).
+		Bold(true).
+		Render("§ Aller au titre")
+
+	count := helpStyle.Render(fmt.Sprintf("%d titres", len(m.list.Items())))
+
+	helpText := helpStyle.Render("↑/↓: naviguer • Enter: ouvrir • Esc: fermer")
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		"",
+		m.input.View(),
+		count,
+		"",
+		m.list.View(),
+		"",
+		helpText,
+	)
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("213")).
+		Padding(1, 2).
+		Width(80)
+
+	return modalStyle.Render(content)
+}
+
+// ========== Export Modal ==========
+
+type exportModal struct {
+	path     string
+	selected int
+	choosing bool // typing the destination of the selected format
+	input    textinput.Model
+	err      string
+}
+
+func newExportModal(path string) exportModal {
+	ti := textinput.New()
+	ti.CharLimit = 400
+	ti.Width = 70
+	return exportModal{path: path, input: ti}
+}
+
+// chooseDestination asks where to write the selected format
+func (m *exportModal) chooseDestination() tea.Cmd {
+	m.choosing = true
+	m.err = ""
+	m.input.SetValue(defaultExportPath(m.path, noteExportFormats[m.selected]))
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+func (m *exportModal) move(delta int) {
+	m.selected = (m.selected + delta + len(noteExportFormats)) % len(noteExportFormats)
+}
+
+func (m exportModal) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("214")).
+		Bold(true).
+		Render("📤 Exporter la note")
+
+	name := helpStyle.Render(filepath.Base(m.path))
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("0")).
+		Background(lipgloss.Color("214")).
+		Bold(true)
+
+	var rows []string
+	for i, f := range noteExportFormats {
+		row := fmt.Sprintf("%d. %s  %s", i+1, f.label, helpStyle.Render(f.suffix))
+		if i == m.selected {
+			row = selectedStyle.Render(fmt.Sprintf("%d. %s", i+1, f.label)) + "  " + helpStyle.Render(f.suffix)
+		}
+		rows = append(rows, row)
+	}
+
+	helpText := helpStyle.Render("↑/↓/1-3: choisir • Enter: continuer • Esc: annuler")
+
+	lines := []string{title, name, "", strings.Join(rows, "\n"), ""}
+	if m.choosing {
+		lines = append(lines, "Exporter vers :", m.input.View(), "")
+		helpText = helpStyle.Render("Enter: exporter • Esc: revenir aux formats")
+	}
+	if m.err != "" {
+		lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(m.err), "")
+	}
+	lines = append(lines, helpText)
+	content := lipgloss.JoinVertical(lipgloss.Left, lines...)
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("214")).
+		Padding(1, 2).
+		Width(80)
+
+	return modalStyle.Render(content)
+}
+
+// ========== Copy Modal ==========
+
+type copyModal struct {
+	path     string
+	isDir    bool
+	selected int
+}
+
+func newCopyModal(path string, isDir bool) copyModal {
+	return copyModal{path: path, isDir: isDir}
+}
+
+// formats returns the copy formats available for the selected item
+func (m copyModal) formats() []copyFormat {
+	var formats []copyFormat
+	for _, f := range copyFormats {
+		if f.dirs || !m.isDir {
+			formats = append(formats, f)
+		}
+	}
+	return formats
+}
+
+func (m *copyModal) move(delta int) {
+	n := len(m.formats())
+	m.selected = (m.selected + delta + n) % n
+}
+
+func (m copyModal) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("214")).
+		Bold(true).
+		Render("📋 Copier dans le presse-papiers")
+
+	name := helpStyle.Render(filepath.Base(m.path))
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("0")).
+		Background(lipgloss.Color("214")).
+		Bold(true)
+
+	formats := m.formats()
+	var rows []string
+	for i, f := range formats {
+		row := fmt.Sprintf("%d. %s", i+1, f.label)
+		if i == m.selected {
+			row = selectedStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+
+	helpText := helpStyle.Render(fmt.Sprintf("↑/↓/1-%d: choisir • Enter: copier • Esc: annuler", len(formats)))
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		name,
+		"",
+		strings.Join(rows, "\n"),
+		"",
+		helpText,
+	)
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("214")).
+		Padding(1, 2).
+		Width(60)
+
+	return modalStyle.Render(content)
+}
+
+// ========== Command Palette ==========
+
+// Rows shown at once by the palette
+const paletteRows = 12
+
+type paletteModal struct {
+	input    textinput.Model
+	entries  []paletteEntry
+	matches  []int // indexes into entries, or into options while prompting
+	selected int
+	prompt   action   // action asking for its argument, "" while choosing
+	label    string   // prompt label
+	target   string   // file the prompted action applies to
+	options  []string // argument suggestions, nil for free text
+}
+
+func newPaletteModal(entries []paletteEntry) paletteModal {
+	ti := textinput.New()
+	ti.Placeholder = "Rechercher une commande..."
+	ti.Prompt = ": "
+	ti.CharLimit = 200
+	ti.Width = 60
+
+	m := paletteModal{input: ti, entries: entries}
+	m.refresh()
+	return m
+}
+
+// startPrompt switches the palette to typing the argument of act
+func (m *paletteModal) startPrompt(act action, label, target string, options []string) tea.Cmd {
+	m.prompt = act
+	m.label = label
+	m.target = target
+	m.options = options
+	m.input.SetValue("")
+	m.input.Placeholder = ""
+	m.input.Prompt = "› "
+	m.refresh()
+	return m.input.Focus()
+}
+
+// refresh fuzzy-matches the commands, or the suggestions, against the input
+func (m *paletteModal) refresh() {
+	if m.prompt != "" {
+		m.matches = fuzzyFilter(m.input.Value(), m.options)
+	} else {
+		labels := make([]string, len(m.entries))
+		for i, e := range m.entries {
+			labels[i] = e.title + " " + e.group
+		}
+		m.matches = fuzzyFilter(m.input.Value(), labels)
+	}
+	m.selected = 0
+}
+
+func (m *paletteModal) move(delta int) {
+	if len(m.matches) > 0 {
+		m.selected = (m.selected + delta + len(m.matches)) % len(m.matches)
+	}
+}
+
+func (m paletteModal) selectedEntry() (paletteEntry, bool) {
+	if m.prompt != "" || len(m.matches) == 0 {
+		return paletteEntry{}, false
+	}
+	return m.entries[m.matches[m.selected]], true
+}
+
+func (m paletteModal) selectedOption() (string, bool) {
+	if m.prompt == "" || len(m.matches) == 0 {
+		return "", false
+	}
+	return m.options[m.matches[m.selected]], true
+}
+
+func (m paletteModal) Update(msg tea.Msg) (paletteModal, tea.Cmd) {
+	before := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != before {
+		m.refresh()
+	}
+	return m, cmd
+}
+
+func (m paletteModal) View() string {
+	titleText := "⌘ Commandes"
+	if m.prompt != "" {
+		titleText = m.label
+	}
+	title := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("213")).
+		Bold(true).
+		Render(titleText)
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("0")).
+		Background(lipgloss.Color("213")).
+		Bold(true)
+
+	// Window of rows around the selection
+	start := 0
+	if m.selected >= paletteRows {
+		start = m.selected - paletteRows + 1
+	}
+	end := min(start+paletteRows, len(m.matches))
+
+	const width = 64
+	var rows []string
+	for i := start; i < end; i++ {
+		var row string
+		if m.prompt != "" {
+			row = m.options[m.matches[i]]
+		} else {
+			e := m.entries[m.matches[i]]
+			left := e.title + "  " + helpStyle.Render(e.group)
+			gap := max(width-lipgloss.Width(left)-lipgloss.Width(e.keys), 2)
+			row = left + strings.Repeat(" ", gap) + keyStyle.Render(e.keys)
+			if i == m.selected {
+				row = selectedStyle.Render(e.title) + "  " + helpStyle.Render(e.group) + strings.Repeat(" ", gap) + keyStyle.Render(e.keys)
+			}
+		}
+		if i == m.selected && m.prompt != "" {
+			row = selectedStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 && (m.prompt == "" || m.options != nil) {
+		rows = append(rows, helpStyle.Render("Aucun résultat"))
+	}
+
+	help := "↑/↓: naviguer • Enter: exécuter • Esc: fermer"
+	if m.prompt != "" && m.options != nil {
+		help = "↑/↓: naviguer • Tab: compléter • Enter: valider • Esc: annuler"
+	} else if m.prompt != "" {
+		help = "Enter: valider • Esc: annuler"
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		"",
+		m.input.View(),
+		"",
+		strings.Join(rows, "\n"),
+		"",
+		helpStyle.Render(help),
+	)
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("213")).
+		Padding(1, 2).
+		Width(width + 6)
+
+	return modalStyle.Render(content)
+}
+
+// ========== Git Modal ==========
+
+type gitModal struct {
+	status   *gitStatus
+	selected int
+	message  textinput.Model
+	editing  bool   // typing the commit message
+	busy     string // running operation
+	output   string // result of the last operation
+	failed   bool
+}
+
+func newGitModal(status *gitStatus) gitModal {
+	ti := textinput.New()
+	ti.Placeholder = "message du commit"
+	ti.CharLimit = 200
+	ti.Width = 60
+
+	m := gitModal{message: ti}
+	m.setStatus(status)
+	return m
+}
+
+// setStatus refreshes the panel, keeping the selection in range
+func (m *gitModal) setStatus(status *gitStatus) {
+	m.status = status
+	if m.selected >= len(m.entries()) {
+		m.selected = max(len(m.entries())-1, 0)
+	}
+}
+
+func (m gitModal) entries() []gitEntry {
+	if m.status == nil {
+		return nil
+	}
+	return m.status.entries
+}
+
+// selectedEntry returns the entry under the cursor
+func (m gitModal) selectedEntry() (gitEntry, bool) {
+	entries := m.entries()
+	if m.selected < 0 || m.selected >= len(entries) {
+		return gitEntry{}, false
+	}
+	return entries[m.selected], true
+}
+
+func (m *gitModal) move(delta int) {
+	if n := len(m.entries()); n > 0 {
+		m.selected = (m.selected + delta + n) % n
+	}
+}
+
+func (m gitModal) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("214")).
+		Bold(true).
+		Render("🔀 Git")
+
+	var lines []string
+	if m.status == nil {
+		lines = append(lines, helpStyle.Render("Le vault n'est pas un dépôt git."))
+	} else {
+		branch := "⎇ " + m.status.branch
+		if m.status.upstream != "" {
+			branch += fmt.Sprintf(" → %s ↑%d ↓%d", m.status.upstream, m.status.ahead, m.status.behind)
+		}
+		if m.status.rebasing {
+			branch += "  " + lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render("rebase en cours")
+		}
+		lines = append(lines, branch, "")
+
+		selectedStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("0")).
+			Background(lipgloss.Color("214")).
+			Bold(true)
+		conflictStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+
+		if len(m.entries()) == 0 {
+			lines = append(lines, helpStyle.Render("Aucune modification."))
+		}
+		for i, e := range m.entries() {
+			check := "[ ]"
+			if e.staged() {
+				check = "[x]"
+			}
+			row := fmt.Sprintf("%s %s %s", check, e.state.marker(), e.rel)
+			switch {
+			case i == m.selected:
+				row = selectedStyle.Render(row)
+			case e.state == gitConflicted:
+				row = conflictStyle.Render(row)
+			}
+			lines = append(lines, row)
+		}
+	}
+
+	messageLabel := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("213")).
+		Bold(true).
+		Render("Message:")
+
+	var result string
+	switch {
+	case m.busy != "":
+		result = helpStyle.Render("⏳ " + m.busy + "…")
+	case m.output != "":
+		style := helpStyle
+		if m.failed {
+			style = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+		}
+		result = style.Render(m.output)
+	}
+
+	helpText := helpStyle.Render("Espace: (dés)indexer • a: tout indexer • c: commit • p: pull --rebase • P: push\n" +
+		"Enter: résoudre • C/A: continuer/annuler le rebase • r: rafraîchir • Esc: fermer")
+	if m.editing {
+		helpText = helpStyle.Render("Enter: committer les fichiers indexés • Esc: retour")
+	}
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		"",
+		strings.Join(lines, "\n"),
+		"",
+		messageLabel,
+		m.message.View(),
+		"",
+		result,
+		helpText,
+	)
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("214")).
+		Padding(1, 2).
+		Width(90)
+
+	return modalStyle.Render(content)
+}
+
+// ========== Conflict Modal ==========
+
+type conflictModal struct {
+	entry    gitEntry
+	rebasing bool
+	viewport viewport.Model
+}
+
+func newConflictModal(entry gitEntry, rebasing bool, width, height int) conflictModal {
+	vp := viewport.New(max(width-16, 40), max(height-16, 10))
+	m := conflictModal{entry: entry, rebasing: rebasing, viewport: vp}
+	m.reload()
+	return m
+}
+
+// reload reads the conflicted file, highlighting both sides of each conflict
+func (m *conflictModal) reload() {
+	ours, theirs := conflictSides(m.rebasing)
+	oursStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("81"))
+	theirsStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+	markerStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Bold(true)
+
+	var lines []string
+	side := 0 // 0 outside a conflict, 1 ours, 2 theirs
+	for _, line := range strings.Split(loadMarkdownRaw(m.entry.path), "\n") {
+		switch {
+		case strings.HasPrefix(line, "<<<<<<<"):
+			side = 1
+			lines = append(lines, markerStyle.Render("<<<<<<< version "+ours))
+		case strings.HasPrefix(line, "=======") && side == 1:
+			side = 2
+			lines = append(lines, markerStyle.Render("======="))
+		case strings.HasPrefix(line, ">>>>>>>") && side == 2:
+			side = 0
+			lines = append(lines, markerStyle.Render(">>>>>>> version "+theirs))
+		case side == 1:
+			lines = append(lines, oursStyle.Render(line))
+		case side == 2:
+			lines = append(lines, theirsStyle.Render(line))
+		default:
+			lines = append(lines, line)
+		}
+	}
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+}
+
+// resolved reports whether the file no longer contains conflict markers
+func (m conflictModal) resolved() bool {
+	for _, line := range strings.Split(loadMarkdownRaw(m.entry.path), "\n") {
+		if strings.HasPrefix(line, "<<<<<<<") || strings.HasPrefix(line, ">>>>>>>") {
+			return false
+		}
+	}
+	return true
+}
+
+func (m conflictModal) Update(msg tea.Msg) (conflictModal, tea.Cmd) {
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m conflictModal) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("196")).
+		Bold(true).
+		Render("⚠ Conflit : " + m.entry.rel)
+
+	ours, theirs := conflictSides(m.rebasing)
+	helpText := helpStyle.Render(fmt.Sprintf(
+		"o: garder la version %s • t: garder la version %s • e: éditer • a: marquer résolu • ↑/↓: défiler • Esc: fermer",
+		ours, theirs))
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		"",
+		m.viewport.View(),
+		"",
+		helpText,
+	)
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("196")).
+		Padding(1, 2)
+
+	return modalStyle.Render(content)
+}
+
+// ========== Config Modal ==========
+
+type configModal struct {
+	viewport viewport.Model
+}
+
+func newConfigModal(rootDir string, config *Config, origins map[string]string, width, height int) configModal {
+	vp := viewport.New(max(width-16, 60), max(height-14, 10))
+
+	originStyles := map[string]lipgloss.Style{
+		originDefault: helpStyle,
+		originGlobal:  lipgloss.NewStyle().Foreground(lipgloss.Color("81")),
+		originVault:   lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true),
+	}
+
+	lines := []string{
+		helpStyle.Render("Ordre : défaut → global → vault, chaque fichier ne remplace que les clés qu'il contient"),
+		originStyles[originGlobal].Render("global : " + getConfigPath()),
+		originStyles[originVault].Render("vault  : " + vaultConfigPath(rootDir)),
+		"",
+	}
+	for _, setting := range effectiveSettings(config, origins) {
+		lines = append(lines, fmt.Sprintf("%s = %s  %s",
+			keyStyle.Render(setting.key), setting.value, originStyles[setting.origin].Render("("+setting.origin+")")))
+	}
+	vp.SetContent(strings.Join(lines, "\n"))
+	return configModal{viewport: vp}
+}
+
+func (m configModal) Update(msg tea.Msg) (configModal, tea.Cmd) {
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m configModal) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("213")).
+		Bold(true).
+		Render("⚙ Configuration effective")
+
+	helpText := helpStyle.Render("↑/↓: défiler • Esc: fermer")
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		"",
+		m.viewport.View(),
+		"",
+		helpText,
+	)
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("213")).
+		Padding(1, 2)
+
+	return modalStyle.Render(content)
+}
+
+// ========== Vault Switcher ==========
+
+type vaultModal struct {
+	vaults   []VaultConfig
+	current  string // name of the open vault
+	selected int
+}
+
+func newVaultModal(vaults []VaultConfig, current string) vaultModal {
+	m := vaultModal{vaults: vaults, current: current}
+	for i, v := range vaults {
+		if v.Name == current {
+			m.selected = i
+		}
+	}
+	return m
+}
+
+func (m *vaultModal) move(delta int) {
+	n := len(m.vaults)
+	m.selected = (m.selected + delta + n) % n
+}
+
+func (m vaultModal) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("141")).
+		Bold(true).
+		Render("📚 Vaults")
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("0")).
+		Background(lipgloss.Color("141")).
+		Bold(true)
+
+	var rows []string
+	for i, v := range m.vaults {
+		marker := "  "
+		if v.Name == m.current {
+			marker = "● "
+		}
+		row := fmt.Sprintf("%s%d. %s", marker, i+1, v.Name)
+		if i == m.selected {
+			row = selectedStyle.Render(row)
+		}
+		rows = append(rows, row, "     "+helpStyle.Render(v.dir()))
+	}
+
+	helpText := helpStyle.Render(fmt.Sprintf("↑/↓/1-%d: choisir • Enter: ouvrir • Esc: annuler", len(m.vaults)))
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		"",
+		strings.Join(rows, "\n"),
+		"",
+		helpText,
+	)
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("141")).
+		Padding(1, 2).
+		Width(70)
+
+	return modalStyle.Render(content)
+}
+
+// ========== Config Warnings ==========
+
+// Issues listed at once by the warning modal
+const configWarnRows = 10
+
+type configWarnModal struct {
+	issues []configIssue
+}
+
+func (m configWarnModal) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("214")).
+		Bold(true).
+		Render("⚠ Configuration : réglages ignorés")
+
+	var rows []string
+	for i, issue := range m.issues {
+		if i == configWarnRows {
+			rows = append(rows, helpStyle.Render(fmt.Sprintf("… et %d autre(s)", len(m.issues)-i)))
+			break
+		}
+		loc := issue.file
+		if issue.line > 0 {
+			loc = fmt.Sprintf("%s:%d", issue.file, issue.line)
+		}
+		msg := issue.msg
+		if issue.key != "" {
+			msg = keyStyle.Render(issue.key) + " : " + msg
+		}
+		rows = append(rows, helpStyle.Render(loc), "  "+msg)
+	}
+
+	helpText := helpStyle.Render("Les autres réglages sont appliqués • notesmd config check • Enter/Esc: fermer")
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		"",
+		strings.Join(rows, "\n"),
+		"",
+		helpText,
+	)
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("214")).
+		Padding(1, 2).
+		Width(90)
+
+	return modalStyle.Render(content)
+}
+
+// ========== Settings Modal ==========
+
+type settingsModal struct {
+	config   *Config           // edited copy of the global configuration
+	origins  map[string]string // to flag settings the vault overrides
+	selected int
+	editing  bool
+	input    textinput.Model
+	err      string
+}
+
+func newSettingsModal(config *Config, origins map[string]string) settingsModal {
+	ti := textinput.New()
+	ti.CharLimit = 200
+	ti.Width = 40
+	return settingsModal{config: config, origins: origins, input: ti}
+}
+
+func (m *settingsModal) move(delta int) {
+	n := len(settingFields)
+	m.selected = (m.selected + delta + n) % n
+}
+
+// startEdit types in a new value for the selected field
+func (m *settingsModal) startEdit() tea.Cmd {
+	m.editing = true
+	m.err = ""
+	m.input.SetValue(settingFields[m.selected].value(m.config))
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+func (m settingsModal) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("81")).
+		Bold(true).
+		Render("⚙ Réglages")
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("0")).
+		Background(lipgloss.Color("81")).
+		Bold(true)
+
+	var rows []string
+	for i, f := range settingFields {
+		value := f.value(m.config)
+		if i == m.selected && m.editing {
+			value = m.input.View()
+		}
+		row := fmt.Sprintf("%-34s %s", f.label, value)
+		if i == m.selected && !m.editing {
+			row = selectedStyle.Render(row)
+		}
+		if m.origins[f.key] == originVault {
+			row += helpStyle.Render("  (remplacé par le vault)")
+		}
+		rows = append(rows, row)
+	}
+
+	helpText := helpStyle.Render("↑/↓: choisir • Espace: changer • Enter: saisir • Ctrl+S: enregistrer • Esc: annuler")
+	if m.editing {
+		helpText = helpStyle.Render("Enter: valider • Esc: annuler la saisie")
+	}
+
+	lines := []string{title, helpStyle.Render(getConfigPath()), "", strings.Join(rows, "\n"), ""}
+	if m.err != "" {
+		lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(m.err), "")
+	}
+	lines = append(lines, helpText)
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("81")).
+		Padding(1, 2).
+		Width(90)
+
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
+
+// ========== History Modal ==========
+
+type historyModal struct {
+	rootDir  string
+	path     string
+	versions []noteVersion
+	selected int
+	rendered bool   // show the version rendered instead of the diff
+	content  string // content of the selected version
+	err      error
+	list     int // width of the version list
+	viewport viewport.Model
+}
+
+func newHistoryModal(rootDir, path string, versions []noteVersion, width, height int) historyModal {
+	listWidth := 36
+	vp := viewport.New(max(width-listWidth-16, 40), max(height-14, 10))
+	m := historyModal{
+		rootDir:  rootDir,
+		path:     path,
+		versions: versions,
+		list:     listWidth,
+		viewport: vp,
+	}
+	m.load()
+	return m
+}
+
+// load reads the selected version and renders it in the preview pane
+func (m *historyModal) load() {
+	m.content, m.err = "", nil
+	if len(m.versions) == 0 {
+		m.viewport.SetContent(helpStyle.Render("Aucune version précédente."))
+		return
+	}
+
+	m.content, m.err = versionContent(m.rootDir, m.versions[m.selected])
+	if m.err == nil && isEncryptedNote(m.path) {
+		var plain []byte
+		plain, m.err = sessionKeys.decrypt([]byte(m.content))
+		m.content = string(plain)
+	}
+	if m.err != nil {
+		m.viewport.SetContent(fmt.Sprintf("Erreur : %v", m.err))
+		return
+	}
+
+	if m.rendered {
+		out, err := renderMarkdownWithLinks(m.content, m.rootDir, m.viewport.Width, markdownTheme)
+		if err != nil {
+			out = m.content
+		}
+		m.viewport.SetContent(out)
+	} else {
+		m.viewport.SetContent(renderDiff(m.content, loadMarkdownRaw(m.path)))
+	}
+	m.viewport.GotoTop()
+}
+
+func (m *historyModal) move(delta int) {
+	if n := len(m.versions); n > 0 {
+		m.selected = (m.selected + delta + n) % n
+		m.load()
+	}
+}
+
+// selectedVersion returns the version under the cursor once loaded
+func (m historyModal) selectedVersion() (noteVersion, bool) {
+	if len(m.versions) == 0 || m.err != nil {
+		return noteVersion{}, false
+	}
+	return m.versions[m.selected], true
+}
+
+func (m historyModal) Update(msg tea.Msg) (historyModal, tea.Cmd) {
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m historyModal) View() string {
+	title := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("81")).
+		Bold(true).
+		Render("🕘 Historique : " + filepath.Base(m.path))
+
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("0")).
+		Background(lipgloss.Color("81")).
+		Bold(true)
+
+	var rows []string
+	for i, v := range m.versions {
+		row := v.time.Local().Format("02/01/2006 15:04") + " " + v.summary
+		if v.author != "" {
+			row += " (" + v.author + ")"
+		}
+		if r := []rune(row); len(r) > m.list-2 {
+			row = string(r[:m.list-3]) + "…"
+		}
+		if i == m.selected {
+			row = selectedStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+
+	// Keep the selection visible
+	height := m.viewport.Height
+	start := max(0, min(m.selected-height/2, len(rows)-height))
+	rows = rows[start:min(start+height, len(rows))]
+
+	listView := lipgloss.NewStyle().
+		Width(m.list).
+		Height(height).
+		Render(strings.Join(rows, "\n"))
+
+	mode := "diff avec la version actuelle"
+	if m.rendered {
+		mode = "aperçu de la version"
+	}
+	preview := lipgloss.JoinVertical(lipgloss.Left, helpStyle.Render(mode), m.viewport.View())
+
+	helpText := helpStyle.Render("↑/↓: version • Tab: diff/aperçu • PgUp/PgDn: défiler • R: restaurer • Esc: fermer")
+
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		"",
+		lipgloss.JoinHorizontal(lipgloss.Top, listView, "  ", preview),
+		"",
+		helpText,
+	)
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("81")).
+		Padding(1, 2)
+
+	return modalStyle.Render(content)
+}
+
+// ========== Passphrase Modal ==========
+
+// passphraseAction is what happens once the passphrase is entered
+type passphraseAction int
+
+const (
+	unlockToView passphraseAction = iota
+	unlockToEdit
+	unlockToSave
+	unlockToDecrypt
+	unlockToHistory
+	newPassphraseToEncrypt
+)
+
+type passphraseModal struct {
+	input   textinput.Model
+	confirm textinput.Model // second entry of a new passphrase
+	path    string
+	action  passphraseAction
+	err     string
+}
+
+func newPassphraseModal(path string, action passphraseAction) passphraseModal {
+	newInput := func(placeholder string) textinput.Model {
+		ti := textinput.New()
+		ti.Placeholder = placeholder
+		ti.EchoMode = textinput.EchoPassword
+		ti.EchoCharacter = '•'
+		ti.CharLimit = 256
+		ti.Width = 50
+		return ti
+	}
+
+	m := passphraseModal{
+		input:   newInput("phrase secrète"),
+		confirm: newInput("confirmer la phrase secrète"),
+		path:    path,
+		action:  action,
+	}
+	m.input.Focus()
+	return m
+}
+
+// choosing reports whether the modal asks for a new passphrase
+func (m passphraseModal) choosing() bool {
+	return m.action == newPassphraseToEncrypt
+}
+
+func (m passphraseModal) Update(msg tea.Msg) (passphraseModal, tea.Cmd) {
+	var cmd tea.Cmd
+	if m.confirm.Focused() {
+		m.confirm, cmd = m.confirm.Update(msg)
+	} else {
+		m.input, cmd = m.input.Update(msg)
+	}
+	return m, cmd
+}
+
+func (m passphraseModal) View() string {
+	heading := "🔒 Déverrouiller"
+	if m.choosing() {
+		heading = "🔒 Chiffrer la note"
+	}
+	title := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("213")).
+		Bold(true).
+		Render(heading)
+
+	lines := []string{title, helpStyle.Render(filepath.Base(m.path)), "", m.input.View()}
+	if m.choosing() {
+		lines = append(lines, m.confirm.View())
+	}
+	if m.err != "" {
+		lines = append(lines, "", lipgloss.NewStyle().Foreground(lipgloss.Color("196")).Render(m.err))
+	}
+
+	help := "Enter: valider • Esc: annuler"
+	if m.choosing() {
+		help = "Tab: champ suivant • Enter: chiffrer • Esc: annuler"
+	}
+	lines = append(lines, "", helpStyle.Render(help))
+
+	modalStyle := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(lipgloss.Color("213")).
+		Padding(1, 2).
+		Width(64)
+
+	return modalStyle.Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+}
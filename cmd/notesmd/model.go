@@ -25,25 +25,37 @@ type fileItem struct {
 	path    string
 	isDir   bool
 	size    int64
-	modTime int64 // Unix timestamp
+	modTime int64    // Unix timestamp
+	git     gitState // git status, set when the vault is a repository
 }
 
 func (f fileItem) Title() string {
-	if f.isDir {
-		return "📁 " + f.name + "/"
+	var title string
+	switch {
+	case f.isDir:
+		title = "📁 " + f.name + "/"
+	case isEncryptedNote(f.name):
+		title = "🔒 " + f.name
+	case filepath.Ext(f.name) == ".md":
+		title = "📝 " + f.name
+	default:
+		title = "📄 " + f.name
 	}
 
-	ext := filepath.Ext(f.name)
-	if ext == ".md" {
-		return "📝 " + f.name
+	if marker := f.git.marker(); marker != "" {
+		title += " " + marker
 	}
-
-	return "📄 " + f.name
+	return title
 }
 
 func (f fileItem) Description() string {
+	gitLabel := ""
+	if f.git != gitClean {
+		gitLabel = f.git.label() + " • "
+	}
+
 	if f.isDir {
-		return "Dossier"
+		return gitLabel + "Dossier"
 	}
 
 	// Format size
@@ -77,7 +89,7 @@ func (f fileItem) Description() string {
 		timeStr = modTime.Format("02/01/2006")
 	}
 
-	return fmt.Sprintf("%s • %s", sizeStr, timeStr)
+	return fmt.Sprintf("%s%s • %s", gitLabel, sizeStr, timeStr)
 }
 
 func (f fileItem) FilterValue() string {
@@ -95,8 +107,12 @@ type model struct {
 	allFiles          []fileItem
 	viewport          bviewport.Model
 	showPreview       bool
-	autoPreview       bool // Auto-preview on selection change
-	lastSelectedIndex int  // Track selection changes
+	autoPreview       bool       // Auto-preview on selection change
+	lastSelectedIndex int        // Track selection changes
+	openOnStart       string     // note opened once the window size is known
+	git               *gitStatus // nil outside a git repository
+	autoCommitSeq     int        // last scheduled auto-commit
+	lockSeq           int        // last scheduled passphrase expiry check
 
 	searchActive bool
 	searchQuery  string
@@ -114,24 +130,52 @@ type model struct {
 	themeIndex int
 
 	// modals
-	showNoteModal      bool
-	noteModal          noteModal
-	showConfirmModal   bool
-	confirmModal       confirmModal
-	showRenameModal    bool
-	renameModal        renameModal
-	showHelpModal      bool
-	helpModal          helpModal
-	showCreateDirModal bool
-	createDirModal     createDirModal
-	showRecentModal    bool
-	recentModal        recentFilesModal
-	showBookmarksModal bool
-	bookmarksModal     bookmarksModal
-	showLinksModal     bool
-	linksModal         linksModal
-	showEditModal      bool
-	editModal          editModal
+	showNoteModal       bool
+	noteModal           noteModal
+	showConfirmModal    bool
+	confirmModal        confirmModal
+	showRenameModal     bool
+	renameModal         renameModal
+	showHelpModal       bool
+	helpModal           helpModal
+	showCreateDirModal  bool
+	createDirModal      createDirModal
+	showRecentModal     bool
+	recentModal         recentFilesModal
+	showBookmarksModal  bool
+	bookmarksModal      bookmarksModal
+	showLinksModal      bool
+	linksModal          linksModal
+	showSymbolModal     bool
+	symbolModal         symbolSearchModal
+	showExportModal     bool
+	exportModal         exportModal
+	showCopyModal       bool
+	copyModal           copyModal
+	showPaletteModal    bool
+	paletteModal        paletteModal
+	showConfigModal     bool
+	configModal         configModal
+	showEditModal       bool
+	editModal           editModal
+	showGitModal        bool
+	gitModal            gitModal
+	showConflictModal   bool
+	conflictModal       conflictModal
+	showHistoryModal    bool
+	historyModal        historyModal
+	showPassphraseModal bool
+	passphraseModal     passphraseModal
+	showVaultModal      bool
+	vaultModal          vaultModal
+	showConfigWarnModal bool
+	configWarnModal     configWarnModal
+	showSettingsModal   bool
+	settingsModal       settingsModal
+
+	// outline side panel
+	showOutline bool
+	outline     outlinePanel
 
 	// configuration & persistence
 	config      *Config
@@ -141,6 +185,7 @@ type model struct {
 	// file operations
 	clipboard     *FileClipboard
 	clipboardMode string
+	osc52         string // clipboard escape sequence written with the frames
 
 	// status bar
 	statusBar StatusBar
@@ -151,8 +196,19 @@ type model struct {
 	currentNoteRaw     string // Raw markdown content of current note
 	currentNotePath    string
 
+	globalConfig    *Config           // configuration before the vault layer
+	state           *SessionState     // session, saved on exit
+	vault           string            // name of the open vault, empty when unnamed
+	resumeNote      string            // note selected when the browser opens
+	ignore          *ignoreMatcher    // .gitignore, .notesmdignore and filters.ignore
+	configOrigins   map[string]string // setting → layer it comes from
+	configErr       error             // invalid settings of the vault configuration
+	globalConfigErr error             // invalid settings of config.json
+	configStamp     [2]fileStamp      // config files as last loaded, to spot edits
+
 	// vim-style navigation
-	lastKey       string
+	keys          *keymap
+	keyPrefix     string   // chord in progress, like "g" before "g g"
 	pendingDelete bool     // for 'dd' double-tap
 	navHistory    []string // Navigation history stack
 	navIndex      int      // Current position in history
@@ -214,11 +270,16 @@ func (m *model) applyFilters() {
 			}
 
 			// Filter .md only
-			if m.mdOnly && !fi.isDir && filepath.Ext(fi.name) != ".md" {
+			if m.mdOnly && !fi.isDir && !isMarkdownNote(fi.name) {
 				continue
 			}
 
-			filtered = append(filtered, item)
+			if m.ignore.Ignored(fi.path, fi.isDir) {
+				continue
+			}
+
+			fi.git = m.git.state(fi.path)
+			filtered = append(filtered, fi)
 		}
 	}
 
@@ -263,10 +324,7 @@ func (m *model) ensureAllFilesScanned() {
 	}
 
 	var files []fileItem
-	filepath.WalkDir(m.rootDir, func(path string, d os.DirEntry, err error) error {
-		if err != nil {
-			return nil
-		}
+	walkVault(m.rootDir, func(path string, d os.DirEntry) error {
 		// Skip root directory itself
 		if path == m.rootDir {
 			return nil
@@ -292,11 +350,25 @@ func (m *model) ensureAllFilesScanned() {
 	m.allFiles = files
 }
 
+// vaultNotes returns the paths of all Markdown notes in the vault
+func (m *model) vaultNotes() []string {
+	m.ensureAllFilesScanned()
+
+	var notes []string
+	for _, f := range m.allFiles {
+		if !f.isDir && filepath.Ext(f.name) == ".md" {
+			notes = append(notes, f.path)
+		}
+	}
+	return notes
+}
+
 // buildSearchResults filters files based on search query
 func (m *model) buildSearchResults() {
 	if m.searchQuery == "" {
 		items := make([]blist.Item, 0, len(m.allFiles))
 		for _, f := range m.allFiles {
+			f.git = m.git.state(f.path)
 			items = append(items, f)
 		}
 		m.list.SetItems(items)
@@ -320,8 +392,11 @@ func (m *model) buildSearchResults() {
 
 	var filtered []blist.Item
 	for _, match := range matches {
-		filtered = append(filtered, m.allFiles[match.Index])
+		f := m.allFiles[match.Index]
+		f.git = m.git.state(f.path)
+		filtered = append(filtered, f)
 	}
+
 	m.list.SetItems(filtered)
 }
 
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gast "github.com/yuin/goldmark/ast"
+)
+
+// Maximum nesting of ![[embeds]] when flattening a note
+const maxEmbedDepth = 5
+
+// noteExportFormat is a format of the single-note export
+type noteExportFormat struct {
+	label  string
+	suffix string // appended to the note name for the exported file
+	// export converts the note, relative links pointing from outDir
+	export func(path, rootDir, outDir string) (string, error)
+}
+
+var noteExportFormats = []noteExportFormat{
+	{label: "HTML autonome (CSS et images intégrés)", suffix: ".html", export: exportNoteHTML},
+	{label: "Texte brut", suffix: ".txt", export: exportNoteText},
+	{label: "Markdown aplati (embeds intégrés, liens standards)", suffix: ".flat.md", export: flattenNote},
+}
+
+// defaultExportPath suggests where to export the note at path in format f:
+// the downloads folder, outside the vault so exports don't show up as notes
+func defaultExportPath(path string, f noteExportFormat) string {
+	dir, _ := os.UserHomeDir()
+	if downloads := filepath.Join(dir, "Downloads"); isDir(downloads) {
+		dir = downloads
+	}
+	return filepath.Join(dir, noteLinkName(path)+f.suffix)
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// exportNote writes the note at path in format f to dst, which must not
+// exist yet
+func exportNote(path, rootDir string, f noteExportFormat, dst string) error {
+	content, err := f.export(path, rootDir, filepath.Dir(dst))
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s existe déjà", dst)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := out.WriteString(content); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// flattenNote returns the note with its embeds inlined and its wiki links
+// converted to standard Markdown links relative to outDir
+func flattenNote(path, rootDir, outDir string) (string, error) {
+	data, err := readNote(path)
+	if err != nil {
+		return "", err
+	}
+	seen := map[string]bool{path: true}
+	return flattenMarkdown(string(data), outDir, rootDir, seen, 0, false), nil
+}
+
+// flattenMarkdown rewrites the wiki links of content. Links and images are
+// made relative to baseDir, the folder of the exported file, or replaced by
+// their label when plain is set.
+func flattenMarkdown(content, baseDir, rootDir string, seen map[string]bool, depth int, plain bool) string {
+	return replaceWikiLinks(content, func(link string, embed bool) string {
+		name := wikiLinkTarget(link)
+		label := markdownEscape(wikiLinkLabel(link))
+		target := findNoteByName(name, rootDir)
+		if target == "" || plain && !embed {
+			return wikiLinkLabel(link)
+		}
+
+		rel, err := filepath.Rel(baseDir, target)
+		if err != nil {
+			rel = target
+		}
+		href := filepath.ToSlash(rel)
+
+		if embed && isImageFile(target) {
+			return fmt.Sprintf("![%s](<%s>)", label, href)
+		}
+
+		linkPart, _, _ := strings.Cut(link, "|")
+		_, anchor, hasAnchor := strings.Cut(linkPart, "#")
+
+		// Encrypted notes are only linked, their content stays sealed
+		if embed && !seen[target] && depth < maxEmbedDepth && !isEncryptedNote(target) {
+			_, body := parseFrontmatter(loadMarkdownRaw(target))
+			if hasAnchor {
+				body = noteSection(body, anchor)
+			}
+			seen[target] = true
+			inner := flattenMarkdown(body, baseDir, rootDir, seen, depth+1, plain)
+			delete(seen, target)
+			return strings.TrimSpace(inner)
+		}
+
+		if plain {
+			return wikiLinkLabel(link)
+		}
+		if hasAnchor {
+			href += "#" + headingSlug(anchor)
+		}
+		return fmt.Sprintf("[%s](<%s>)", label, href)
+	})
+}
+
+// noteSection returns the section of body under heading, heading included
+func noteSection(body, heading string) string {
+	lines := strings.Split(body, "\n")
+	headings := parseHeadings(body)
+	for i, h := range headings {
+		if !strings.EqualFold(h.text, strings.TrimSpace(heading)) {
+			continue
+		}
+		end := len(lines)
+		for _, next := range headings[i+1:] {
+			if next.level <= h.level {
+				end = next.line
+				break
+			}
+		}
+		return strings.Join(lines[h.line:end], "\n")
+	}
+	return body
+}
+
+// exportNoteText renders the flattened note as plain text
+func exportNoteText(path, rootDir, outDir string) (string, error) {
+	data, err := readNote(path)
+	if err != nil {
+		return "", err
+	}
+	_, body := parseFrontmatter(string(data))
+	body = flattenMarkdown(body, outDir, rootDir, map[string]bool{path: true}, 0, true)
+
+	out, err := renderMarkdownWithLinks(body, rootDir, 80, "notty")
+	if err != nil {
+		return "", err
+	}
+
+	// Drop the renderer margins
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(strings.TrimRight(line, " "), "  ")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")) + "\n", nil
+}
+
+// exportNoteHTML renders the flattened note as a standalone HTML document
+// with inline CSS and images embedded as data URIs
+func exportNoteHTML(path, rootDir, _ string) (string, error) {
+	// Images are embedded, relative to the note they come from
+	flat, err := flattenNote(path, rootDir, filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	fm, body := parseFrontmatter(flat)
+	noteDir := filepath.Dir(path)
+
+	// The note is exported for its owner, its own HTML is kept
+	md := newGoldmark(true)
+	source := []byte(body)
+	doc := parseGoldmark(md, source)
+
+	// Embed images and unwrap links to notes the reader won't have
+	var noteLinks []*gast.Link
+	gast.Walk(doc, func(node gast.Node, entering bool) (gast.WalkStatus, error) {
+		if !entering {
+			return gast.WalkContinue, nil
+		}
+		switch node := node.(type) {
+		case *gast.Image:
+			if uri, ok := imageDataURI(noteDir, string(node.Destination)); ok {
+				node.Destination = []byte(uri)
+			}
+		case *gast.Link:
+			target, _, _ := strings.Cut(string(node.Destination), "#")
+			if !strings.Contains(target, "://") && strings.EqualFold(filepath.Ext(target), ".md") {
+				noteLinks = append(noteLinks, node)
+			}
+		}
+		return gast.WalkContinue, nil
+	})
+	for _, link := range noteLinks {
+		parent := link.Parent()
+		for child := link.FirstChild(); child != nil; child = link.FirstChild() {
+			parent.InsertBefore(parent, link, child)
+		}
+		parent.RemoveChild(parent, link)
+	}
+
+	var content bytes.Buffer
+	if err := md.Renderer().Render(&content, source, doc); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	err = standaloneTemplate.Execute(&buf, struct {
+		Title string
+		CSS   template.CSS
+		Body  template.HTML
+	}{
+		Title: noteTitle(fm, body, path),
+		CSS:   template.CSS(siteCSS),
+		Body:  template.HTML(content.String()),
+	})
+	return buf.String(), err
+}
+
+// imageDataURI reads a local image referenced from noteDir as a data URI
+func imageDataURI(noteDir, dest string) (string, bool) {
+	if dest == "" || strings.Contains(dest, "://") || strings.HasPrefix(dest, "data:") {
+		return "", false
+	}
+	if unescaped, err := url.PathUnescape(dest); err == nil {
+		dest = unescaped
+	}
+
+	imgPath := filepath.FromSlash(dest)
+	if !filepath.IsAbs(imgPath) {
+		imgPath = filepath.Join(noteDir, imgPath)
+	}
+	data, err := os.ReadFile(imgPath)
+	if err != nil {
+		return "", false
+	}
+
+	mimeType := mime.TypeByExtension(strings.ToLower(filepath.Ext(imgPath)))
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), true
+}
+
+var standaloneTemplate = template.Must(template.New("note").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+{{.CSS}}
+body { display: block; }
+main { margin: 0 auto; }
+</style>
+</head>
+<body>
+<main>
+{{.Body}}
+</main>
+</body>
+</html>
+`))
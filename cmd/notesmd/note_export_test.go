@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func noteExportVault(t *testing.T) (root, note string) {
+	t.Helper()
+	root = t.TempDir()
+	note = filepath.Join(root, "projets", "Plan.md")
+	writeTestFile(t, note, "---\ntitle: Le plan\n---\n# Plan\n\nVoir [[Idées|les idées]].\n\n![[Idées#Une]]\n\n![[schéma.png]]\n")
+	writeTestFile(t, filepath.Join(root, "Idées.md"), "# Idées\n\n## Une\n\nPremière idée.\n\n## Deux\n\nSeconde.\n")
+	writeTestFile(t, filepath.Join(root, "projets", "schéma.png"), "\x89PNG")
+	return root, note
+}
+
+func exportFormat(t *testing.T, suffix string) noteExportFormat {
+	t.Helper()
+	for _, f := range noteExportFormats {
+		if f.suffix == suffix {
+			return f
+		}
+	}
+	t.Fatalf("no %s export", suffix)
+	return noteExportFormat{}
+}
+
+func TestExportNoteFormats(t *testing.T) {
+	root, note := noteExportVault(t)
+	// Links of the flat export point from its own folder
+	out := filepath.Join(root, "exports")
+	os.Mkdir(out, 0755)
+
+	tests := []struct {
+		suffix   string
+		want     []string
+		unwanted []string
+	}{
+		{".flat.md", []string{"[les idées](<../Idées.md>)", "## Une\n\nPremière idée.", "![schéma.png](<../projets/schéma.png>)"}, []string{"[[", "Seconde"}},
+		{".txt", []string{"Voir les idées.", "Première idée."}, []string{"[[", "](", "Seconde", "title:"}},
+		{".html", []string{"<title>Le plan</title>", "<style>", "data:image/png;base64,", "Voir les idées.", "Première idée."}, []string{"Idées.md", "Seconde"}},
+	}
+	for _, tt := range tests {
+		dst := filepath.Join(out, "Plan"+tt.suffix)
+		if err := exportNote(note, root, exportFormat(t, tt.suffix), dst); err != nil {
+			t.Fatalf("%s: %v", tt.suffix, err)
+		}
+		data, _ := os.ReadFile(dst)
+		for _, want := range tt.want {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("%s export lacks %q:\n%s", tt.suffix, want, data)
+			}
+		}
+		for _, unwanted := range tt.unwanted {
+			if strings.Contains(string(data), unwanted) {
+				t.Errorf("%s export contains %q", tt.suffix, unwanted)
+			}
+		}
+	}
+}
+
+func TestExportNoteKeepsExistingFiles(t *testing.T) {
+	root, note := noteExportVault(t)
+	dst := filepath.Join(root, "projets", "Plan.txt")
+	writeTestFile(t, dst, "à garder")
+
+	if err := exportNote(note, root, exportFormat(t, ".txt"), dst); err == nil {
+		t.Error("export replaced an existing file")
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "à garder" {
+		t.Errorf("existing file = %q", data)
+	}
+
+	// Exports are suggested outside the vault
+	t.Setenv("HOME", t.TempDir())
+	if got := defaultExportPath(note, exportFormat(t, ".flat.md")); isWithin(root, got) || filepath.Base(got) != "Plan.flat.md" {
+		t.Errorf("defaultExportPath = %q", got)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -16,6 +17,10 @@ func (m *model) handleNoteModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
 
 	switch s {
 	case "esc":
+		// Esc closes the link suggestions first
+		if m.noteModal.contentInput.Completing() {
+			break
+		}
 		// Close modal without saving
 		m.showNoteModal = false
 		m.noteModal = newNoteModal()
@@ -23,7 +28,9 @@ func (m *model) handleNoteModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
 
 	case "ctrl+s", "ctrl+enter":
 		// Save note
-		path, err := m.noteModal.CreateNote(m.currentDir)
+		dir := m.config.Notes.newNoteDir(m.rootDir, m.currentDir)
+		os.MkdirAll(dir, 0755)
+		path, err := m.noteModal.CreateNote(dir)
 		if err != nil {
 			// Could show error in status bar, for now just close
 			m.showNoteModal = false
@@ -32,6 +39,9 @@ func (m *model) handleNoteModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
 		}
 
 		// Refresh list and select the new note
+		if dir != m.currentDir {
+			m.setDir(dir)
+		}
 		m.baseItems = readDir(m.currentDir)
 		m.list.SetItems(m.baseItems)
 		for i, item := range m.baseItems {
@@ -44,7 +54,7 @@ func (m *model) handleNoteModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
 		// Close modal
 		m.showNoteModal = false
 		m.noteModal = newNoteModal()
-		return true, nil
+		return true, m.notesChanged()
 	}
 
 	// Let modal handle the key
@@ -67,7 +77,7 @@ func (m *model) handleConfirmModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd
 			m.list.SetItems(m.baseItems)
 		}
 		m.showConfirmModal = false
-		return true, nil
+		return true, m.notesChanged()
 
 	case "n", "esc":
 		// Cancel deletion
@@ -99,7 +109,7 @@ func (m *model) handleRenameModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd)
 		}
 		m.showRenameModal = false
 		m.renameModal = renameModal{}
-		return true, nil
+		return true, m.notesChanged()
 	}
 
 	// Let modal handle the key
@@ -198,32 +208,77 @@ func (m *model) handleBookmarksModalKey(msg tea.KeyMsg) (handled bool, cmd tea.C
 	return true, modalCmd
 }
 
+// saveNote writes a note, snapshotting its previous content when the vault
+// has no git history, and refreshes the preview
+func (m *model) saveNote(path, content string) (tea.Cmd, error) {
+	if isEncryptedNote(path) && !sessionKeys.unlocked() {
+		return nil, errNoteLocked
+	}
+	if m.git == nil {
+		if err := writeSnapshot(m.rootDir, path); err != nil {
+			return nil, fmt.Errorf("historique : %w", err)
+		}
+	}
+
+	// Encrypted notes are sealed in memory, never written in clear
+	var err error
+	if isEncryptedNote(path) {
+		err = writeEncryptedNote(path, []byte(content))
+	} else {
+		err = os.WriteFile(path, []byte(content), 0644)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The cache can be rebuilt, failing to update it doesn't fail the save
+	forgetHeadings(m.rootDir, path)
+
+	m.currentNotePath = path
+	m.currentNoteRaw = content
+	m.viewport.SetContent(loadMarkdownWithLinks(path, m.rootDir, m.viewport.Width))
+	return m.notesChanged(), nil
+}
+
+// saveEditModal saves the inline editor. An encrypted note is only
+// overwritten by a buffer loaded from its decrypted content.
+func (m *model) saveEditModal() (tea.Cmd, error) {
+	path := m.editModal.notePath
+	if isEncryptedNote(path) && !m.editModal.loaded {
+		return nil, errors.New("contenu non déchiffré, la note n'est pas modifiée")
+	}
+	return m.saveNote(path, m.editModal.GetContent())
+}
+
 func (m *model) handleEditModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
 	s := msg.String()
 
 	switch s {
 	case "esc":
+		// Esc closes the link suggestions first
+		if m.editModal.editor.Completing() {
+			break
+		}
 		m.showEditModal = false
 		return true, nil
 
 	case "ctrl+s":
 		// Save the edited content
-		newContent := m.editModal.GetContent()
-		err := os.WriteFile(m.editModal.notePath, []byte(newContent), 0644)
+		cmd, err := m.saveEditModal()
+		if err == errNoteLocked || err == errWrongPassphrase {
+			// The passphrase expired while editing, or another note changed
+			// it: the save resumes once the note's own one is entered
+			m.showPassphraseModal = true
+			m.passphraseModal = newPassphraseModal(m.editModal.notePath, unlockToSave)
+			return true, nil
+		}
 		if err != nil {
-			cmd := m.statusBar.SetMessage(fmt.Sprintf("Erreur: %v", err), 3*time.Second)
-			return true, cmd
+			return true, m.statusBar.SetMessage(fmt.Sprintf("Erreur: %v", err), 3*time.Second)
 		}
 
-		// Refresh the preview
-		m.currentNoteRaw = newContent
-		content := loadMarkdownWithLinks(m.editModal.notePath, m.rootDir, m.viewport.Width)
-		m.viewport.SetContent(content)
-
 		// Close modal and show success message
 		m.showEditModal = false
-		cmd = m.statusBar.SetMessage("✓ Note sauvegardée", 2*time.Second)
-		return true, cmd
+		return true, tea.Batch(cmd, m.statusBar.SetMessage("✓ Note sauvegardée", 2*time.Second))
 	}
 
 	var modalCmd tea.Cmd
@@ -263,7 +318,7 @@ func (m *model) handleLinksModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd)
 				}
 			} else {
 				// Create new note
-				noteName := it.name
+				noteName := wikiLinkTarget(it.name)
 				if filepath.Ext(noteName) == "" {
 					noteName += ".md"
 				}
@@ -295,7 +350,7 @@ func (m *model) handleLinksModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd)
 			}
 
 			m.showLinksModal = false
-			return true, nil
+			return true, m.notesChanged()
 		}
 	}
 
@@ -303,3 +358,661 @@ func (m *model) handleLinksModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd)
 	m.linksModal, modalCmd = m.linksModal.Update(msg)
 	return true, modalCmd
 }
+
+func (m *model) handleSymbolModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.showSymbolModal = false
+		return true, nil
+
+	case "enter":
+		if it, ok := m.symbolModal.list.SelectedItem().(headingSymbol); ok {
+			m.openNoteAtHeading(it.path, it.index)
+			m.showSymbolModal = false
+		}
+		return true, nil
+	}
+
+	var modalCmd tea.Cmd
+	m.symbolModal, modalCmd = m.symbolModal.Update(msg)
+	return true, modalCmd
+}
+
+// handleExportModalKey handles keyboard input for the note export modal
+func (m *model) handleExportModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	if m.exportModal.choosing {
+		return m.handleExportDestinationKey(msg)
+	}
+
+	switch key := msg.String(); key {
+	case "esc", "q":
+		m.showExportModal = false
+
+	case "up", "k":
+		m.exportModal.move(-1)
+
+	case "down", "j":
+		m.exportModal.move(1)
+
+	case "1", "2", "3", "enter":
+		if key != "enter" {
+			m.exportModal.selected = int(key[0] - '1')
+		}
+		return true, m.exportModal.chooseDestination()
+	}
+
+	return true, nil
+}
+
+// handleExportDestinationKey edits the path the note is exported to
+func (m *model) handleExportDestinationKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	em := &m.exportModal
+
+	switch msg.String() {
+	case "esc":
+		em.choosing = false
+		em.err = ""
+		em.input.Blur()
+		return true, nil
+
+	case "enter":
+		dst := strings.TrimSpace(em.input.Value())
+		if dst == "" {
+			return true, nil
+		}
+		if strings.HasPrefix(dst, "~/") {
+			home, _ := os.UserHomeDir()
+			dst = filepath.Join(home, dst[2:])
+		}
+		if !filepath.IsAbs(dst) {
+			dst = filepath.Join(filepath.Dir(em.path), dst)
+		}
+
+		if err := exportNote(em.path, m.rootDir, noteExportFormats[em.selected], dst); err != nil {
+			em.err = err.Error()
+			return true, nil
+		}
+		m.showExportModal = false
+		status := m.statusBar.SetMessage("Exporté : "+dst, 3*time.Second)
+		if !isWithin(m.rootDir, dst) {
+			return true, status
+		}
+		m.setDir(m.currentDir)
+		return true, tea.Batch(status, m.notesChanged())
+	}
+
+	var inputCmd tea.Cmd
+	em.input, inputCmd = em.input.Update(msg)
+	return true, inputCmd
+}
+
+// handleCopyModalKey handles keyboard input for the copy modal
+func (m *model) handleCopyModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	formats := m.copyModal.formats()
+
+	switch key := msg.String(); key {
+	case "esc", "q":
+		m.showCopyModal = false
+
+	case "up", "k":
+		m.copyModal.move(-1)
+
+	case "down", "j":
+		m.copyModal.move(1)
+
+	case "enter":
+		m.showCopyModal = false
+		return true, copyNote(m.copyModal.path, m.rootDir, formats[m.copyModal.selected])
+
+	default:
+		if len(key) == 1 && key[0] >= '1' && int(key[0]-'1') < len(formats) {
+			m.showCopyModal = false
+			return true, copyNote(m.copyModal.path, m.rootDir, formats[key[0]-'1'])
+		}
+	}
+
+	return true, nil
+}
+
+func (m *model) handleSettingsModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	sm := &m.settingsModal
+	field := settingFields[sm.selected]
+
+	if sm.editing {
+		switch msg.String() {
+		case "esc":
+			sm.editing = false
+			sm.input.Blur()
+		case "enter":
+			if err := field.set(sm.config, strings.TrimSpace(sm.input.Value())); err != nil {
+				sm.err = err.Error()
+				return true, nil
+			}
+			sm.editing = false
+			sm.input.Blur()
+		default:
+			var inputCmd tea.Cmd
+			sm.input, inputCmd = sm.input.Update(msg)
+			return true, inputCmd
+		}
+		return true, nil
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		m.showSettingsModal = false
+	case "up", "k":
+		sm.move(-1)
+	case "down", "j":
+		sm.move(1)
+	case " ", "right", "l":
+		field.cycle(sm.config, 1)
+	case "left", "h":
+		field.cycle(sm.config, -1)
+	case "enter":
+		if field.editable() {
+			return true, sm.startEdit()
+		}
+		field.cycle(sm.config, 1)
+	case "ctrl+s":
+		return true, m.saveSettings()
+	}
+	return true, nil
+}
+
+func (m *model) handleConfigWarnModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	switch msg.String() {
+	case "esc", "enter", "q":
+		m.showConfigWarnModal = false
+	}
+	return true, nil
+}
+
+func (m *model) handleVaultModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	vaults := m.vaultModal.vaults
+
+	switch key := msg.String(); key {
+	case "esc", "q":
+		m.showVaultModal = false
+
+	case "up", "k":
+		m.vaultModal.move(-1)
+
+	case "down", "j":
+		m.vaultModal.move(1)
+
+	case "enter":
+		m.showVaultModal = false
+		return true, m.switchVault(vaults[m.vaultModal.selected])
+
+	default:
+		if len(key) == 1 && key[0] >= '1' && int(key[0]-'1') < len(vaults) {
+			m.showVaultModal = false
+			return true, m.switchVault(vaults[key[0]-'1'])
+		}
+	}
+
+	return true, nil
+}
+
+func (m *model) handleGitModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	s := msg.String()
+
+	// Commit message input
+	if m.gitModal.editing {
+		switch s {
+		case "esc":
+			m.gitModal.editing = false
+			m.gitModal.message.Blur()
+			return true, nil
+		case "enter":
+			message := strings.TrimSpace(m.gitModal.message.Value())
+			if message == "" || defaultCommitMessage(m.gitModal.entries()) == "" {
+				m.gitModal.output, m.gitModal.failed = "Rien à committer : indexez des fichiers avec Espace.", true
+				return true, nil
+			}
+			m.gitModal.editing = false
+			m.gitModal.message.Blur()
+			return true, m.startGitOp("commit", []string{"commit", "-m", message})
+		}
+		var inputCmd tea.Cmd
+		m.gitModal.message, inputCmd = m.gitModal.message.Update(msg)
+		return true, inputCmd
+	}
+
+	switch s {
+	case "esc", "q", "ctrl+g":
+		m.showGitModal = false
+		return true, nil
+	case "up", "k":
+		m.gitModal.move(-1)
+		return true, nil
+	case "down", "j":
+		m.gitModal.move(1)
+		return true, nil
+	}
+
+	// Operations need a repository and wait for the running one
+	if m.git == nil || m.gitModal.busy != "" {
+		return true, nil
+	}
+
+	switch s {
+	case " ":
+		if e, ok := m.gitModal.selectedEntry(); ok && e.state != gitConflicted {
+			if e.staged() {
+				return true, m.startGitOp("désindexation", []string{"reset", "-q", "--", e.rel})
+			}
+			return true, m.startGitOp("indexation", []string{"add", "--", e.rel})
+		}
+
+	case "a":
+		return true, m.startGitOp("indexation", []string{"add", "-A", "--", "."})
+
+	case "c":
+		if m.gitModal.message.Value() == "" {
+			m.gitModal.message.SetValue(defaultCommitMessage(m.gitModal.entries()))
+		}
+		m.gitModal.message.CursorEnd()
+		m.gitModal.editing = true
+		return true, m.gitModal.message.Focus()
+
+	case "p":
+		return true, m.startGitOp("pull", []string{"pull", "--rebase", "--autostash"})
+
+	case "P":
+		return true, m.startGitOp("push", []string{"push"})
+
+	case "r":
+		return true, m.refreshGit()
+
+	case "enter":
+		if e, ok := m.gitModal.selectedEntry(); ok && e.state == gitConflicted {
+			m.showConflictModal = true
+			m.conflictModal = newConflictModal(e, m.git.rebasing, m.width, m.height)
+		}
+
+	case "C":
+		if m.git.rebasing {
+			return true, m.startGitOp("rebase", []string{"rebase", "--continue"})
+		}
+
+	case "A":
+		if m.git.rebasing {
+			return true, m.startGitOp("rebase", []string{"rebase", "--abort"})
+		}
+	}
+
+	return true, nil
+}
+
+func (m *model) handleConflictModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	rel := m.conflictModal.entry.rel
+
+	switch msg.String() {
+	case "esc", "q":
+		m.showConflictModal = false
+		return true, nil
+
+	case "o", "t":
+		side := "--ours"
+		if msg.String() == "t" {
+			side = "--theirs"
+		}
+		m.showConflictModal = false
+		return true, m.startGitOp("résolution",
+			[]string{"checkout", side, "--", rel},
+			[]string{"add", "--", rel})
+
+	case "e":
+		m.showConflictModal = false
+		return true, openInEditor(m.config.Editor, m.conflictModal.entry.path)
+
+	case "a":
+		if !m.conflictModal.resolved() {
+			return true, m.statusBar.SetMessage("Des marqueurs de conflit restent dans le fichier", 3*time.Second)
+		}
+		m.showConflictModal = false
+		return true, m.startGitOp("résolution", []string{"add", "--", rel})
+	}
+
+	var modalCmd tea.Cmd
+	m.conflictModal, modalCmd = m.conflictModal.Update(msg)
+	return true, modalCmd
+}
+
+// startGitOp runs git commands in the background, the panel showing progress
+func (m *model) startGitOp(op string, steps ...[]string) tea.Cmd {
+	m.gitModal.busy = op
+	m.gitModal.output = ""
+	m.gitModal.failed = false
+	return gitOperation(m.rootDir, op, steps...)
+}
+
+// handleGitOp reports the result of a git operation and reloads the status
+func (m *model) handleGitOp(msg gitOpMsg) tea.Cmd {
+	m.gitModal.busy = ""
+	m.gitModal.failed = msg.err != nil
+	m.gitModal.output = msg.output
+	if msg.err != nil {
+		m.gitModal.output = msg.err.Error()
+	}
+
+	var status tea.Cmd
+	switch {
+	case msg.err != nil:
+		status = m.statusBar.SetMessage("Erreur "+msg.err.Error(), 3*time.Second)
+	case msg.op == "commit" && msg.output == "":
+		status = m.statusBar.SetMessage("Rien à committer", 2*time.Second)
+	case msg.op == "commit":
+		m.gitModal.message.SetValue("")
+		status = m.statusBar.SetMessage("✓ Commit créé", 2*time.Second)
+	case msg.op == "auto-commit" && msg.output != "":
+		status = m.statusBar.SetMessage("✓ Commit automatique", 2*time.Second)
+	case msg.op == "pull" || msg.op == "push":
+		status = m.statusBar.SetMessage("✓ "+msg.op+" terminé", 2*time.Second)
+	}
+
+	// A pull may change notes on disk
+	if msg.op == "pull" || msg.op == "rebase" || msg.op == "résolution" {
+		m.baseItems = readDir(m.currentDir)
+		m.allFiles = nil
+		if m.showPreview && m.currentNotePath != "" {
+			m.currentNoteRaw = loadMarkdownRaw(m.currentNotePath)
+			m.viewport.SetContent(loadMarkdownWithLinks(m.currentNotePath, m.rootDir, m.viewport.Width))
+		}
+	}
+	return tea.Batch(status, m.refreshGit())
+}
+
+func (m *model) handleHistoryModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "H":
+		m.showHistoryModal = false
+		return true, nil
+
+	case "up", "k":
+		m.historyModal.move(-1)
+		return true, nil
+
+	case "down", "j":
+		m.historyModal.move(1)
+		return true, nil
+
+	case "tab":
+		m.historyModal.rendered = !m.historyModal.rendered
+		m.historyModal.load()
+		return true, nil
+
+	case "R":
+		// Restoring is a regular save, so it can be undone from the history
+		v, ok := m.historyModal.selectedVersion()
+		if !ok {
+			return true, nil
+		}
+		saveCmd, err := m.saveNote(m.historyModal.path, m.historyModal.content)
+		if err != nil {
+			return true, m.statusBar.SetMessage(fmt.Sprintf("Erreur: %v", err), 3*time.Second)
+		}
+		m.showHistoryModal = false
+		m.showPreview = true
+		message := "✓ Version du " + v.time.Local().Format("02/01/2006 15:04") + " restaurée"
+		return true, tea.Batch(saveCmd, m.statusBar.SetMessage(message, 3*time.Second))
+	}
+
+	var modalCmd tea.Cmd
+	m.historyModal, modalCmd = m.historyModal.Update(msg)
+	return true, modalCmd
+}
+
+func (m *model) handlePassphraseModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	pm := &m.passphraseModal
+
+	switch msg.String() {
+	case "esc":
+		m.showPassphraseModal = false
+		return true, nil
+
+	case "tab", "shift+tab":
+		if pm.choosing() {
+			if pm.input.Focused() {
+				pm.input.Blur()
+				return true, pm.confirm.Focus()
+			}
+			pm.confirm.Blur()
+			return true, pm.input.Focus()
+		}
+		return true, nil
+
+	case "enter":
+		passphrase := pm.input.Value()
+		if passphrase == "" {
+			return true, nil
+		}
+		if pm.choosing() {
+			if pm.confirm.Value() != passphrase {
+				pm.err = "Les deux saisies diffèrent"
+				return true, nil
+			}
+			sessionKeys.set(passphrase)
+		} else {
+			data, err := os.ReadFile(pm.path)
+			if err == nil {
+				err = sessionKeys.unlock(passphrase, data)
+			}
+			if err != nil {
+				pm.err = err.Error()
+				pm.input.SetValue("")
+				return true, nil
+			}
+		}
+
+		m.showPassphraseModal = false
+		actionCmd := m.runPassphraseAction(pm.path, pm.action)
+		return true, tea.Batch(actionCmd, m.scheduleLockCheck())
+	}
+
+	var modalCmd tea.Cmd
+	m.passphraseModal, modalCmd = m.passphraseModal.Update(msg)
+	return true, modalCmd
+}
+
+// runPassphraseAction resumes what required the passphrase
+func (m *model) runPassphraseAction(path string, action passphraseAction) tea.Cmd {
+	switch action {
+	case unlockToView:
+		m.currentNotePath = path
+		m.currentNoteRaw = loadMarkdownRaw(path)
+		m.viewport.SetContent(loadMarkdownWithLinks(path, m.rootDir, m.viewport.Width))
+		m.showPreview = true
+	case unlockToEdit:
+		return m.openEditModal(path)
+	case unlockToSave:
+		cmd, err := m.saveEditModal()
+		if err != nil {
+			return m.statusBar.SetMessage(fmt.Sprintf("Erreur: %v", err), 3*time.Second)
+		}
+		m.showEditModal = false
+		return tea.Batch(cmd, m.statusBar.SetMessage("✓ Note sauvegardée", 2*time.Second))
+	case unlockToHistory:
+		return m.openHistory(path)
+	case unlockToDecrypt, newPassphraseToEncrypt:
+		return m.toggleEncryption(path)
+	}
+	return nil
+}
+
+// openEditModal opens the inline editor, decrypting encrypted notes in memory
+// The editor only opens on content actually read: the cached passphrase may
+// belong to another note, and saving an empty buffer would erase this one.
+func (m *model) openEditModal(path string) tea.Cmd {
+	data, err := readNote(path)
+	if err == errNoteLocked || err == errWrongPassphrase {
+		m.showPassphraseModal = true
+		m.passphraseModal = newPassphraseModal(path, unlockToEdit)
+		return nil
+	}
+	if err != nil && isEncryptedNote(path) {
+		return m.statusBar.SetMessage(fmt.Sprintf("Erreur: %v", err), 3*time.Second)
+	}
+	m.showEditModal = true
+	m.editModal = newEditModal(path, string(data), m.width, m.height)
+	m.editModal.loaded = err == nil
+	m.editModal.editor.SetLinkTargets(m.rootDir, m.vaultNotes())
+	return nil
+}
+
+// openHistory opens the history modal of a note
+func (m *model) openHistory(path string) tea.Cmd {
+	if isEncryptedNote(path) && !sessionKeys.unlocked() {
+		m.showPassphraseModal = true
+		m.passphraseModal = newPassphraseModal(path, unlockToHistory)
+		return nil
+	}
+	versions, err := noteHistory(m.rootDir, path, m.git != nil)
+	if err != nil {
+		return m.statusBar.SetMessage("Historique : "+err.Error(), 3*time.Second)
+	}
+	m.showHistoryModal = true
+	m.historyModal = newHistoryModal(m.rootDir, path, versions, m.width, m.height)
+	return nil
+}
+
+// toggleEncryption encrypts a plain note or decrypts an encrypted one,
+// asking for the passphrase when none is cached
+func (m *model) toggleEncryption(path string) tea.Cmd {
+	if !sessionKeys.unlocked() {
+		action := unlockToDecrypt
+		if !isEncryptedNote(path) {
+			action = newPassphraseToEncrypt
+		}
+		m.showPassphraseModal = true
+		m.passphraseModal = newPassphraseModal(path, action)
+		return nil
+	}
+
+	var dst, message string
+	var err error
+	if isEncryptedNote(path) {
+		dst, err = decryptNoteFile(path)
+		message = "🔓 Note déchiffrée : "
+	} else {
+		dst, err = encryptNoteFile(m.rootDir, path)
+		message = "🔒 Note chiffrée : "
+	}
+	if err != nil {
+		return m.statusBar.SetMessage(fmt.Sprintf("Erreur: %v", err), 3*time.Second)
+	}
+
+	m.setDir(m.currentDir)
+	for i, item := range m.list.Items() {
+		if fi, ok := item.(fileItem); ok && fi.path == dst {
+			m.list.Select(i)
+			m.lastSelectedIndex = i
+			break
+		}
+	}
+	m.currentNotePath = dst
+	m.currentNoteRaw = loadMarkdownRaw(dst)
+	m.viewport.SetContent(loadMarkdownWithLinks(dst, m.rootDir, m.viewport.Width))
+	return tea.Batch(m.statusBar.SetMessage(message+filepath.Base(dst), 3*time.Second), m.notesChanged())
+}
+
+type lockCheckMsg struct {
+	seq int
+}
+
+// scheduleLockCheck wakes up when the cached passphrase may have expired
+func (m *model) scheduleLockCheck() tea.Cmd {
+	m.lockSeq++
+	seq := m.lockSeq
+	return tea.Tick(sessionKeys.expiresIn()+time.Second, func(time.Time) tea.Msg {
+		return lockCheckMsg{seq: seq}
+	})
+}
+
+// handleLockCheck hides decrypted content once the passphrase expired
+func (m *model) handleLockCheck(msg lockCheckMsg) tea.Cmd {
+	if msg.seq != m.lockSeq {
+		return nil
+	}
+	if sessionKeys.unlocked() {
+		return m.scheduleLockCheck()
+	}
+	if isEncryptedNote(m.currentNotePath) {
+		m.currentNoteRaw = ""
+		m.viewport.SetContent(lockedNoteMessage)
+	}
+	return m.statusBar.SetMessage("🔒 Phrase secrète oubliée", 3*time.Second)
+}
+
+// handlePaletteModalKey handles keyboard input for the command palette
+func (m *model) handlePaletteModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	pm := &m.paletteModal
+
+	switch msg.String() {
+	case "esc":
+		m.showPaletteModal = false
+		return true, nil
+
+	case "up", "ctrl+p":
+		pm.move(-1)
+		return true, nil
+
+	case "down", "ctrl+n":
+		pm.move(1)
+		return true, nil
+
+	case "tab":
+		// Complete the argument with the selected suggestion
+		if option, ok := pm.selectedOption(); ok {
+			pm.input.SetValue(option)
+			pm.input.CursorEnd()
+			pm.refresh()
+		}
+		return true, nil
+
+	case "enter":
+		if pm.prompt != "" {
+			arg := pm.input.Value()
+			if pm.options != nil {
+				option, ok := pm.selectedOption()
+				if !ok {
+					return true, nil
+				}
+				arg = option
+			}
+			m.showPaletteModal = false
+			return true, m.runPromptAction(pm.prompt, arg, pm.target)
+		}
+
+		entry, ok := pm.selectedEntry()
+		if !ok {
+			return true, nil
+		}
+		if _, ok := actionPrompts[entry.action]; ok {
+			return true, m.openPrompt(entry.action)
+		}
+		m.showPaletteModal = false
+		cmd := m.runAction(entry.action)
+		m.previewSelection()
+		return true, cmd
+	}
+
+	var modalCmd tea.Cmd
+	m.paletteModal, modalCmd = m.paletteModal.Update(msg)
+	return true, modalCmd
+}
+
+// handleConfigModalKey handles keyboard input for the effective config view
+func (m *model) handleConfigModalKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.showConfigModal = false
+		return true, nil
+	}
+
+	var modalCmd tea.Cmd
+	m.configModal, modalCmd = m.configModal.Update(msg)
+	return true, modalCmd
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Zero-width runes tagging headings in a render used to locate them: the
+// index is written in binary between two word joiners, so wrapping and
+// layout stay those of the preview
+const (
+	headingTagEdge = '\u2060'
+	headingTagZero = '\u200c'
+	headingTagOne  = '\u200d'
+)
+
+func headingTag(i int) string {
+	var b strings.Builder
+	b.WriteRune(headingTagEdge)
+	for _, bit := range strconv.FormatInt(int64(i), 2) {
+		if bit == '1' {
+			b.WriteRune(headingTagOne)
+		} else {
+			b.WriteRune(headingTagZero)
+		}
+	}
+	b.WriteRune(headingTagEdge)
+	return b.String()
+}
+
+// tagHeadings prefixes the text of each heading of raw with its tag
+func tagHeadings(raw string, headings []noteHeading) string {
+	lines := strings.Split(raw, "\n")
+	for i, h := range headings {
+		line := lines[h.line]
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		rest := line[indent+h.level:]
+		text := strings.TrimLeft(rest, " \t")
+		lines[h.line] = line[:indent+h.level+len(rest)-len(text)] + headingTag(i) + text
+	}
+	return strings.Join(lines, "\n")
+}
+
+// headingOffsets returns the rendered line of each heading of raw, found by
+// rendering the note again with tagged headings. Repeated titles and inline
+// markup don't matter since nothing is matched by text.
+func headingOffsets(raw, rootDir string, width int) []int {
+	headings := parseHeadings(raw)
+	offsets := make([]int, len(headings))
+	tagged, err := renderMarkdownWithLinks(tagHeadings(raw, headings), rootDir, width, markdownTheme)
+	if err != nil {
+		return offsets
+	}
+
+	found := 0
+	for j, line := range strings.Split(ansi.Strip(tagged), "\n") {
+		for found < len(headings) && strings.Contains(line, headingTag(found)) {
+			offsets[found] = j
+			found++
+		}
+	}
+	// Headings the renderer dropped keep the line of the previous one
+	for i := max(found, 1); i < len(offsets); i++ {
+		offsets[i] = offsets[i-1]
+	}
+	return offsets
+}
+
+// outlinePanel is a collapsible table of contents for the current note
+type outlinePanel struct {
+	headings  []noteHeading
+	offsets   []int        // rendered line of each heading
+	collapsed map[int]bool // collapsed heading indexes
+	cursor    int          // heading index under the cursor
+	raw       string       // note the outline was built from
+	width     int          // preview width the offsets were computed for
+}
+
+// newOutlinePanel builds the outline of raw, mapped onto its preview
+// rendered at width
+func newOutlinePanel(raw, rootDir string, width int) outlinePanel {
+	return outlinePanel{
+		headings:  parseHeadings(raw),
+		offsets:   headingOffsets(raw, rootDir, width),
+		collapsed: make(map[int]bool),
+		raw:       raw,
+		width:     width,
+	}
+}
+
+// hasChildren reports whether heading i has nested headings
+func (p outlinePanel) hasChildren(i int) bool {
+	return i+1 < len(p.headings) && p.headings[i+1].level > p.headings[i].level
+}
+
+// visible returns the indexes of headings not hidden by a collapsed parent
+func (p outlinePanel) visible() []int {
+	var res []int
+	hiddenBelow := 0 // level of the collapsed ancestor, 0 if none
+	for i, h := range p.headings {
+		if hiddenBelow > 0 && h.level > hiddenBelow {
+			continue
+		}
+		hiddenBelow = 0
+		res = append(res, i)
+		if p.collapsed[i] {
+			hiddenBelow = h.level
+		}
+	}
+	return res
+}
+
+// parent returns the index of the enclosing heading of i, or -1
+func (p outlinePanel) parent(i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if p.headings[j].level < p.headings[i].level {
+			return j
+		}
+	}
+	return -1
+}
+
+// move moves the cursor by delta visible entries
+func (p *outlinePanel) move(delta int) {
+	vis := p.visible()
+	if len(vis) == 0 {
+		return
+	}
+	pos := 0
+	for i, idx := range vis {
+		if idx == p.cursor {
+			pos = i
+		}
+	}
+	pos = min(max(pos+delta, 0), len(vis)-1)
+	p.cursor = vis[pos]
+}
+
+// setCollapsed collapses or expands the heading under the cursor
+func (p *outlinePanel) setCollapsed(collapsed bool) {
+	if p.hasChildren(p.cursor) {
+		p.collapsed[p.cursor] = collapsed
+	}
+}
+
+// toggle flips the collapsed state of the heading under the cursor
+func (p *outlinePanel) toggle() {
+	p.setCollapsed(!p.collapsed[p.cursor])
+}
+
+// selectedOffset returns the rendered line of the heading under the cursor
+func (p outlinePanel) selectedOffset() (int, bool) {
+	if p.cursor >= len(p.offsets) {
+		return 0, false
+	}
+	return p.offsets[p.cursor], true
+}
+
+// current returns the heading whose section contains the given line
+func (p outlinePanel) current(yOffset int) int {
+	active := -1
+	for i, off := range p.offsets {
+		if off > yOffset {
+			break
+		}
+		active = i
+	}
+	return active
+}
+
+// View renders the outline, highlighting the section at yOffset, above the
+// key help
+func (p outlinePanel) View(width, height, yOffset int, accent lipgloss.Color, help string) string {
+	title := lipgloss.NewStyle().Foreground(accent).Bold(true).Render("📑 Sommaire")
+	if len(p.headings) == 0 {
+		return title + "\n\n" + helpStyle.Render("Aucun titre dans cette note")
+	}
+
+	// The active section is its nearest visible ancestor when collapsed
+	active := p.current(yOffset)
+	vis := p.visible()
+	for active >= 0 && !slices.Contains(vis, active) {
+		active = p.parent(active)
+	}
+
+	cursorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("0")).Background(accent).Bold(true)
+	activeStyle := lipgloss.NewStyle().Foreground(accent).Bold(true)
+
+	var rows []string
+	for _, i := range vis {
+		h := p.headings[i]
+		icon := "  "
+		if p.hasChildren(i) {
+			icon = "▾ "
+			if p.collapsed[i] {
+				icon = "▸ "
+			}
+		}
+		row := strings.Repeat("  ", h.level-1) + icon + h.text
+		row = ansi.Truncate(row, max(width-2, 1), "…")
+
+		switch {
+		case i == p.cursor:
+			row = cursorStyle.Render(row)
+		case i == active:
+			row = activeStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+
+	// Keep the cursor in view
+	listHeight := max(height-3, 1)
+	start := 0
+	for k, i := range vis {
+		if i == p.cursor && k >= listHeight {
+			start = k - listHeight + 1
+		}
+	}
+	rows = rows[start:min(start+listHeight, len(rows))]
+
+	return title + "\n" + strings.Join(rows, "\n") + "\n" + helpStyle.Render(help)
+}
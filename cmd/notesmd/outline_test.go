@@ -0,0 +1,108 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestHeadingOffsets(t *testing.T) {
+	raw := strings.Join([]string{
+		"# Projet",
+		"",
+		"Voir la section Notes plus bas, et Notes encore.",
+		"",
+		"## Notes",
+		"",
+		"Premier paragraphe.",
+		"",
+		"## **Plan** avec `code` et [[lien]]",
+		"",
+		"```",
+		"## pas un titre",
+		"```",
+		"",
+		"## Notes",
+		"",
+		"### Un titre assez long pour être replié sur plusieurs lignes dans un aperçu étroit",
+		"",
+		"Fin.",
+	}, "\n")
+
+	for _, width := range []int{80, 30} {
+		rendered, err := renderMarkdownWithLinks(raw, "", width, markdownTheme)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(ansi.Strip(rendered), "\n")
+
+		offsets := headingOffsets(raw, "", width)
+		want := []string{"Projet", "Notes", "Plan", "Notes", "Un titre"}
+		if len(offsets) != len(want) {
+			t.Fatalf("width %d: %d offsets, want %d", width, len(offsets), len(want))
+		}
+		for i, off := range offsets {
+			if off >= len(lines) || !strings.Contains(lines[off], want[i]) {
+				t.Errorf("width %d: heading %d at line %d %q, want %q", width, i, off, lines[min(off, len(lines)-1)], want[i])
+			}
+			if i > 0 && off <= offsets[i-1] {
+				t.Errorf("width %d: heading %d at line %d, not after heading %d", width, i, off, i-1)
+			}
+		}
+	}
+}
+
+func TestOutlineFollowsKeymapAndNote(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "premier.md")
+	second := filepath.Join(dir, "second.md")
+	writeTestFile(t, first, "# Un\n\n## Deux\n\n# Trois\n")
+	writeTestFile(t, second, "# Autre\n")
+
+	config := DefaultConfig()
+	config.Keys = map[string][]string{"outline": {"o"}, "down": {"J"}}
+	m := initialModel(dir, config, &SessionState{})
+	update := func(msg tea.Msg) {
+		t.Helper()
+		updated, _ := m.Update(msg)
+		m = updated.(model)
+	}
+	press := func(key string) { update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}) }
+
+	update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	m.enterBrowser()
+	m.openNoteAtHeading(first, -1)
+
+	if press("O"); m.showOutline {
+		t.Fatal("unbound O opens the outline")
+	}
+	if press("o"); !m.showOutline {
+		t.Fatal("rebound outline key ignored")
+	}
+	if press("j"); m.outline.cursor != 0 {
+		t.Error("unbound j moves the outline cursor")
+	}
+	if press("J"); m.outline.cursor != 1 {
+		t.Errorf("cursor = %d after the rebound down key", m.outline.cursor)
+	}
+
+	// A resize maps the same headings onto the new preview width
+	update(tea.WindowSizeMsg{Width: 60, Height: 40})
+	if m.outline.width != m.viewport.Width || m.outline.cursor != 1 {
+		t.Errorf("outline width %d, cursor %d after resize, want %d, 1", m.outline.width, m.outline.cursor, m.viewport.Width)
+	}
+
+	// Another note replaces the headings
+	m.openNoteAtHeading(second, -1)
+	update(osc52SentMsg{})
+	if len(m.outline.headings) != 1 || m.outline.headings[0].text != "Autre" {
+		t.Errorf("outline still shows %+v", m.outline.headings)
+	}
+
+	if press("o"); m.showOutline {
+		t.Error("rebound outline key doesn't close the outline")
+	}
+}
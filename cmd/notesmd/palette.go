@@ -0,0 +1,170 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	fuzzy "github.com/sahilm/fuzzy"
+)
+
+// paletteEntry is an action listed by the command palette
+type paletteEntry struct {
+	action action
+	title  string
+	group  string
+	keys   string // current bindings, as displayed
+}
+
+// paletteEntries lists every action with its current keys
+func (k *keymap) paletteEntries() []paletteEntry {
+	entries := make([]paletteEntry, 0, len(k.bindings))
+	for _, b := range k.bindings {
+		if b.action == actPalette {
+			continue
+		}
+		keys := make([]string, len(b.keys))
+		for i, seq := range b.keys {
+			keys[i] = displaySequence(seq)
+		}
+		entries = append(entries, paletteEntry{
+			action: b.action,
+			title:  b.help,
+			group:  b.group,
+			keys:   strings.Join(keys, " "),
+		})
+	}
+	return entries
+}
+
+// actionPrompt is the argument an action asks for before running
+type actionPrompt struct {
+	label   string
+	folders bool // complete with the folders of the vault
+}
+
+var actionPrompts = map[action]actionPrompt{
+	actGoToFolder:   {label: "Aller au dossier", folders: true},
+	actMoveToFolder: {label: "Déplacer vers", folders: true},
+	actGitCommit:    {label: "Message de commit (vide : message généré)"},
+}
+
+// accentFolder maps accented letters to their base letter so "depl"
+// matches "déplacer"
+var accentFolder = strings.NewReplacer(
+	"à", "a", "â", "a", "ä", "a", "ç", "c", "é", "e", "è", "e", "ê", "e", "ë", "e",
+	"î", "i", "ï", "i", "ô", "o", "ö", "o", "ù", "u", "û", "u", "ü", "u", "ÿ", "y",
+	"œ", "oe", "æ", "ae",
+)
+
+// fuzzyFilter returns the indexes of the candidates matching query, ignoring
+// accents, all of them when query is empty
+func fuzzyFilter(query string, candidates []string) []int {
+	query = accentFolder.Replace(strings.TrimSpace(query))
+	var indexes []int
+	if query == "" {
+		for i := range candidates {
+			indexes = append(indexes, i)
+		}
+		return indexes
+	}
+	folded := make([]string, len(candidates))
+	for i, c := range candidates {
+		folded[i] = accentFolder.Replace(c)
+	}
+	for _, match := range fuzzy.Find(query, folded) {
+		indexes = append(indexes, match.Index)
+	}
+	return indexes
+}
+
+// vaultFolders lists the folders of the vault relative to its root, hidden
+// ones excluded. The root itself is ".".
+func vaultFolders(rootDir string) []string {
+	folders := []string{"."}
+	walkVault(rootDir, func(path string, d fs.DirEntry) error {
+		if !d.IsDir() || path == rootDir {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if rel, err := filepath.Rel(rootDir, path); err == nil {
+			folders = append(folders, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return folders
+}
+
+// openPalette opens the command palette
+func (m *model) openPalette() tea.Cmd {
+	m.showPaletteModal = true
+	m.paletteModal = newPaletteModal(m.keys.paletteEntries())
+	return m.paletteModal.input.Focus()
+}
+
+// openPrompt asks in the palette for the argument of act
+func (m *model) openPrompt(act action) tea.Cmd {
+	p := actionPrompts[act]
+	label := p.label
+
+	var target string
+	switch act {
+	case actMoveToFolder:
+		it, ok := m.list.SelectedItem().(fileItem)
+		if !ok {
+			m.showPaletteModal = false
+			return m.statusBar.SetMessage("Aucun fichier sélectionné", 2*time.Second)
+		}
+		target = it.path
+		label = "Déplacer « " + it.name + " » vers"
+	case actGitCommit:
+		if m.git == nil {
+			m.showPaletteModal = false
+			return m.statusBar.SetMessage("Le vault n'est pas un dépôt git", 2*time.Second)
+		}
+	}
+
+	var options []string
+	if p.folders {
+		options = vaultFolders(m.rootDir)
+	}
+	m.showPaletteModal = true
+	return m.paletteModal.startPrompt(act, label, target, options)
+}
+
+// runPromptAction runs an action with the argument typed in the palette
+func (m *model) runPromptAction(act action, arg, target string) tea.Cmd {
+	switch act {
+	case actGoToFolder:
+		dir := filepath.Join(m.rootDir, filepath.FromSlash(arg))
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return m.statusBar.SetMessage("Dossier introuvable : "+arg, 3*time.Second)
+		}
+		m.setDir(dir)
+
+	case actMoveToFolder:
+		dst, err := moveToFolder(target, filepath.Join(m.rootDir, filepath.FromSlash(arg)))
+		if err != nil {
+			return m.statusBar.SetMessage("Erreur: "+err.Error(), 3*time.Second)
+		}
+		if m.currentNotePath == target {
+			m.currentNotePath = dst
+		}
+		m.setDir(m.currentDir)
+		m.allFiles = nil
+		return tea.Batch(m.statusBar.SetMessage("Déplacé vers "+arg, 2*time.Second), m.notesChanged())
+
+	case actGitCommit:
+		m.gitModal.busy = "commit"
+		rootDir := m.rootDir
+		return func() tea.Msg {
+			return commitAll(rootDir, "commit", strings.TrimSpace(arg))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyFilterIgnoresAccents(t *testing.T) {
+	candidates := []string{"nouvelle note", "déplacer vers le dossier…", "thème"}
+	if got := fuzzyFilter("depl", candidates); !reflect.DeepEqual(got, []int{1}) {
+		t.Errorf("fuzzyFilter(depl) = %v", got)
+	}
+	if got := fuzzyFilter("", candidates); len(got) != 3 {
+		t.Errorf("fuzzyFilter(\"\") = %v", got)
+	}
+}
+
+func TestMoveToFolder(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"projets/2024", ".git"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	note := filepath.Join(root, "a.md")
+	if err := os.WriteFile(note, []byte("# A\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := vaultFolders(root), []string{".", "projets", "projets/2024"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("vaultFolders = %q, want %q", got, want)
+	}
+
+	dst, err := moveToFolder(note, filepath.Join(root, "projets", "2024"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dst); err != nil {
+		t.Error(err)
+	}
+	if _, err := moveToFolder(filepath.Join(root, "projets"), filepath.Join(root, "projets", "2024")); err == nil {
+		t.Error("moving a folder into itself should fail")
+	}
+}
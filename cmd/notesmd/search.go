@@ -48,12 +48,8 @@ func searchFiles(rootDir, query string) []SearchResult {
 	}
 
 	go func() {
-		filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil
-			}
-
-			if !info.IsDir() && filepath.Ext(path) == ".md" {
+		walkVault(rootDir, func(path string, d os.DirEntry) error {
+			if !d.IsDir() && filepath.Ext(path) == ".md" {
 				jobs <- path
 			}
 			return nil
@@ -141,3 +137,60 @@ func (s searchResultItem) Description() string {
 func (s searchResultItem) FilterValue() string {
 	return s.result.Line
 }
+
+// headingSymbol is a Markdown heading indexed for the vault-wide switcher
+type headingSymbol struct {
+	path  string // note containing the heading
+	label string // "note › Heading › Subheading"
+	rel   string // note path relative to the vault
+	index int    // position of the heading within its note
+}
+
+func (s headingSymbol) Title() string {
+	return "§ " + s.label
+}
+
+func (s headingSymbol) Description() string {
+	return s.rel
+}
+
+func (s headingSymbol) FilterValue() string {
+	return s.label
+}
+
+// buildHeadingIndex indexes every heading of the given notes with its ancestors
+func buildHeadingIndex(notes []string, rootDir string) []headingSymbol {
+	var symbols []headingSymbol
+	cache := loadHeadingCache(rootDir)
+
+	for _, path := range notes {
+		rel, err := filepath.Rel(rootDir, path)
+		if err != nil {
+			rel = filepath.Base(path)
+		}
+		name := noteLinkName(path)
+
+		var trail []noteHeading
+		for i, h := range cache.headings(path) {
+			for len(trail) > 0 && trail[len(trail)-1].level >= h.level {
+				trail = trail[:len(trail)-1]
+			}
+			trail = append(trail, h)
+
+			parts := []string{name}
+			for _, t := range trail {
+				parts = append(parts, t.text)
+			}
+
+			symbols = append(symbols, headingSymbol{
+				path:  path,
+				label: strings.Join(parts, " › "),
+				rel:   rel,
+				index: i,
+			})
+		}
+	}
+	cache.save(notes)
+
+	return symbols
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseHeadings(t *testing.T) {
+	content := "---\ntitle: x\n---\n# Titre #\n\ntexte #tag\n  ## Partie\n```md\n# pas un titre\n```\n####### trop\n#collé\n### Fin ###\n#\n"
+	want := []noteHeading{
+		{level: 1, text: "Titre", line: 3},
+		{level: 2, text: "Partie", line: 6},
+		{level: 3, text: "Fin", line: 12},
+	}
+	got := parseHeadings(content)
+	if len(got) != len(want) {
+		t.Fatalf("parseHeadings = %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("heading %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBuildHeadingIndex(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	note := filepath.Join(root, "projets", "plan.md")
+	writeTestFile(t, note, "# Plan\n## Étapes\n### Une\n## Suite\n")
+
+	var labels []string
+	for _, s := range buildHeadingIndex([]string{note}, root) {
+		labels = append(labels, s.label)
+		if s.rel != filepath.Join("projets", "plan.md") {
+			t.Errorf("rel = %q", s.rel)
+		}
+	}
+	want := []string{"plan › Plan", "plan › Plan › Étapes", "plan › Plan › Étapes › Une", "plan › Plan › Suite"}
+	if len(labels) != len(want) {
+		t.Fatalf("labels = %q", labels)
+	}
+	for i := range want {
+		if labels[i] != want[i] {
+			t.Errorf("label %d = %q, want %q", i, labels[i], want[i])
+		}
+	}
+}
+
+func TestSaveNoteRefreshesHeadingCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	root := t.TempDir()
+	note := filepath.Join(root, "a.md")
+	writeTestFile(t, note, "# Alpha\n")
+	stamp := time.Now().Add(-time.Hour).Truncate(time.Second)
+	os.Chtimes(note, stamp, stamp)
+	buildHeadingIndex([]string{note}, root)
+
+	m := initialModel(root, DefaultConfig(), &SessionState{})
+	m.git = nil
+	if _, err := m.saveNote(note, "# Omega\n"); err != nil {
+		t.Fatal(err)
+	}
+	// Same size and modification time: only the save can tell the cache
+	os.Chtimes(note, stamp, stamp)
+
+	if got := buildHeadingIndex([]string{note}, root); len(got) != 1 || got[0].label != "a › Omega" {
+		t.Errorf("index after save = %+v", got)
+	}
+}
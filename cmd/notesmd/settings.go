@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Interval between two checks of the configuration files
+const configPollInterval = 2 * time.Second
+
+// configWatchMsg wakes up the configuration watcher
+type configWatchMsg struct{}
+
+// fileStamp identifies a version of a file, zero when it doesn't exist
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func stampOf(path string) fileStamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+// configStamps identifies the global and vault configuration files as loaded
+func (m *model) configStamps() [2]fileStamp {
+	return [2]fileStamp{stampOf(getConfigPath()), stampOf(vaultConfigPath(m.rootDir))}
+}
+
+// watchConfig schedules the next check of the configuration files
+func watchConfig() tea.Cmd {
+	return tea.Tick(configPollInterval, func(time.Time) tea.Msg {
+		return configWatchMsg{}
+	})
+}
+
+// handleConfigWatch reloads the configuration when a file changed
+func (m *model) handleConfigWatch() tea.Cmd {
+	if m.configStamps() == m.configStamp {
+		return watchConfig()
+	}
+	return tea.Batch(m.reloadConfig(), watchConfig())
+}
+
+// reloadConfig rereads the configuration files and applies them live.
+// Filters and theme toggled in the session are kept unless their setting
+// changed.
+func (m *model) reloadConfig() tea.Cmd {
+	old := m.config
+	mdOnly, showHidden, sortMode, theme := m.mdOnly, m.showHidden, m.sortMode, m.themeIndex
+
+	m.globalConfig, m.globalConfigErr = LoadConfig()
+	m.applyVaultConfig()
+
+	if m.config.Filters.MdOnly == old.Filters.MdOnly {
+		m.mdOnly = mdOnly
+	}
+	if m.config.Filters.ShowHidden == old.Filters.ShowHidden {
+		m.showHidden = showHidden
+	}
+	if m.config.Filters.SortMode == old.Filters.SortMode {
+		m.sortMode = sortMode
+	}
+	m.themeIndex = theme
+	if m.config.Theme != old.Theme && m.config.Theme >= 0 && m.config.Theme < len(titlePalette) {
+		m.themeIndex = m.config.Theme
+	}
+
+	m.allFiles = nil
+	if m.mode == modeBrowser && !m.searchActive {
+		m.applyFilters()
+	}
+	if m.showPreview && m.currentNotePath != "" {
+		m.viewport.SetContent(loadMarkdownWithLinks(m.currentNotePath, m.rootDir, m.viewport.Width))
+	}
+
+	m.openConfigWarnings(true)
+	return m.statusBar.SetMessage("Configuration rechargée", 2*time.Second)
+}
+
+// cloneConfig returns a deep copy of c
+func cloneConfig(c *Config) (*Config, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	clone := &Config{}
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// settingField is an option of the settings modal. Exactly one of the
+// pointers is set; choices turn an int or string into a list to cycle.
+type settingField struct {
+	key     string // dotted key, as in configOrigins
+	label   string
+	choices []string
+	boolPtr func(c *Config) *bool
+	intPtr  func(c *Config) *int
+	strPtr  func(c *Config) *string
+}
+
+// Glamour styles offered by the settings modal
+var markdownThemes = []string{"dark", "light", "dracula", "tokyo-night", "pink", "notty", "ascii"}
+
+var settingFields = []settingField{
+	{key: "editor", label: "Éditeur", strPtr: func(c *Config) *string { return &c.Editor }},
+	{key: "default_dir", label: "Dossier par défaut", strPtr: func(c *Config) *string { return &c.DefaultDir }},
+	{key: "theme", label: "Thème", choices: themeChoices(), intPtr: func(c *Config) *int { return &c.Theme }},
+	{key: "markdown_theme", label: "Style Markdown", choices: markdownThemes, strPtr: func(c *Config) *string { return &c.MarkdownTheme }},
+	{key: "filters.md_only", label: "Seulement les .md", boolPtr: func(c *Config) *bool { return &c.Filters.MdOnly }},
+	{key: "filters.show_hidden", label: "Fichiers cachés", boolPtr: func(c *Config) *bool { return &c.Filters.ShowHidden }},
+	{key: "filters.sort_mode", label: "Tri", choices: []string{"nom", "date", "taille"}, intPtr: func(c *Config) *int { return &c.Filters.SortMode }},
+	{key: "search.include_content", label: "Recherche dans le contenu", boolPtr: func(c *Config) *bool { return &c.Search.IncludeContent }},
+	{key: "search.respect_gitignore", label: "Respecter .gitignore", boolPtr: func(c *Config) *bool { return &c.Search.RespectGitignore }},
+	{key: "git.auto_commit", label: "Commit automatique", boolPtr: func(c *Config) *bool { return &c.Git.AutoCommit }},
+	{key: "git.auto_commit_delay", label: "Délai du commit (s)", intPtr: func(c *Config) *int { return &c.Git.AutoCommitDelay }},
+	{key: "encryption.passphrase_timeout", label: "Oubli de la phrase secrète (min)", intPtr: func(c *Config) *int { return &c.Encryption.PassphraseTimeout }},
+}
+
+func themeChoices() []string {
+	choices := make([]string, len(titlePalette))
+	for i := range titlePalette {
+		choices[i] = strconv.Itoa(i)
+	}
+	return choices
+}
+
+// value returns the value of the field in c, as displayed
+func (f settingField) value(c *Config) string {
+	switch {
+	case f.boolPtr != nil:
+		if *f.boolPtr(c) {
+			return "oui"
+		}
+		return "non"
+	case f.intPtr != nil:
+		n := *f.intPtr(c)
+		if f.choices != nil && n >= 0 && n < len(f.choices) {
+			return f.choices[n]
+		}
+		return strconv.Itoa(n)
+	}
+	return *f.strPtr(c)
+}
+
+// editable reports whether the field is typed in rather than cycled
+func (f settingField) editable() bool {
+	return f.choices == nil && f.boolPtr == nil
+}
+
+// cycle moves a bool or choice field to its next or previous value
+func (f settingField) cycle(c *Config, delta int) {
+	switch {
+	case f.boolPtr != nil:
+		p := f.boolPtr(c)
+		*p = !*p
+	case f.intPtr != nil && f.choices != nil:
+		p := f.intPtr(c)
+		*p = (*p + delta + len(f.choices)) % len(f.choices)
+	case f.strPtr != nil && f.choices != nil:
+		p := f.strPtr(c)
+		i := 0
+		for j, choice := range f.choices {
+			if choice == *p {
+				i = j
+			}
+		}
+		*p = f.choices[(i+delta+len(f.choices))%len(f.choices)]
+	}
+}
+
+// set parses a typed value into the field
+func (f settingField) set(c *Config, text string) error {
+	if f.intPtr != nil {
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return fmt.Errorf("%s : nombre entier attendu", f.label)
+		}
+		*f.intPtr(c) = n
+		return nil
+	}
+	*f.strPtr(c) = text
+	return nil
+}
+
+// validateConfig checks c with the rules applied when loading files
+func validateConfig(c *Config) error {
+	for _, rule := range configRules {
+		if msg := rule.check(c); msg != "" {
+			return fmt.Errorf("%s : %s", rule.key, msg)
+		}
+	}
+	return nil
+}
+
+// openSettings opens the settings modal on a copy of the global configuration
+func (m *model) openSettings() tea.Cmd {
+	config, err := cloneConfig(m.globalConfig)
+	if err != nil {
+		return m.statusBar.SetMessage("Erreur: "+err.Error(), 3*time.Second)
+	}
+	m.showSettingsModal = true
+	m.settingsModal = newSettingsModal(config, m.configOrigins)
+	return nil
+}
+
+// saveSettings writes the edited settings to config.json and applies them.
+// Only the changed keys are written, so settings skipped while loading the
+// file stay in it for the user to fix.
+func (m *model) saveSettings() tea.Cmd {
+	config := m.settingsModal.config
+	if err := validateConfig(config); err != nil {
+		m.settingsModal.err = err.Error()
+		return nil
+	}
+	if err := config.SaveChanges(m.globalConfig); err != nil {
+		m.settingsModal.err = err.Error()
+		return nil
+	}
+	m.showSettingsModal = false
+	m.reloadConfig()
+	return m.statusBar.SetMessage("Réglages enregistrés dans "+getConfigPath(), 2*time.Second)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestReloadConfigKeepsSessionToggles(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+
+	config := DefaultConfig()
+	if err := config.Save(); err != nil {
+		t.Fatal(err)
+	}
+	m := initialModel(root, config, &SessionState{})
+	m.globalConfig = config
+	m.applyVaultConfig()
+	m.enterBrowser()
+	if cmd := m.handleConfigWatch(); cmd == nil || m.config.Editor != config.Editor {
+		t.Fatal("unchanged file reloaded")
+	}
+
+	// Toggled in the session, then the editor and md_only change in the file
+	m.showHidden = !config.Filters.ShowHidden
+	edited, _ := cloneConfig(config)
+	edited.Editor = "nano"
+	edited.Filters.MdOnly = !config.Filters.MdOnly
+	edited.Keys = map[string][]string{"quit": {"Q"}}
+	if err := edited.Save(); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure the stamp differs even on coarse mtime filesystems
+	os.WriteFile(getConfigPath(), append(mustRead(t, getConfigPath()), '\n'), 0644)
+
+	m.handleConfigWatch()
+	if m.config.Editor != "nano" || m.mdOnly != edited.Filters.MdOnly {
+		t.Errorf("edited settings not applied: editor %q, md only %v", m.config.Editor, m.mdOnly)
+	}
+	if m.showHidden == config.Filters.ShowHidden {
+		t.Error("session toggle lost on reload")
+	}
+	if m.keys.hint(actQuit) != "Q" {
+		t.Error("keymap not reloaded")
+	}
+}
+
+func TestSettingFields(t *testing.T) {
+	config := DefaultConfig()
+	for _, f := range settingFields {
+		switch f.key {
+		case "filters.sort_mode":
+			f.cycle(config, -1)
+			if config.Filters.SortMode != 2 || f.value(config) != "taille" {
+				t.Errorf("sort mode cycled to %d", config.Filters.SortMode)
+			}
+		case "git.auto_commit_delay":
+			if err := f.set(config, "abc"); err == nil {
+				t.Error("non numeric delay accepted")
+			}
+			f.set(config, "-5")
+		}
+	}
+	if err := validateConfig(config); err == nil {
+		t.Error("negative commit delay passed validation")
+	}
+}
+
+func mustRead(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestSaveSettingsKeepsSkippedKeys(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	os.MkdirAll(getConfigDir(), 0755)
+	original := `{
+  "version": 1,
+  "editor": "vim",
+  "thme": 2,
+  "filters": {"md_only": true, "sort_mod": 1},
+  "git": {"auto_commit_delay": -4}
+}`
+	if err := os.WriteFile(getConfigPath(), []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadConfig()
+	if err == nil {
+		t.Fatal("invalid settings not reported")
+	}
+
+	m := initialModel(t.TempDir(), config, &SessionState{})
+	m.globalConfig, m.globalConfigErr = config, err
+	m.openSettings()
+	m.settingsModal.config.Editor = "nano"
+	m.settingsModal.config.Filters.ShowHidden = true
+	m.saveSettings()
+	if m.showSettingsModal {
+		t.Fatalf("settings not saved: %s", m.settingsModal.err)
+	}
+
+	var saved map[string]any
+	if err := json.Unmarshal(mustRead(t, getConfigPath()), &saved); err != nil {
+		t.Fatal(err)
+	}
+	filters := saved["filters"].(map[string]any)
+	if saved["editor"] != "nano" || filters["show_hidden"] != true || filters["md_only"] != true {
+		t.Errorf("edited settings not written: %v", saved)
+	}
+	if saved["thme"] != 2.0 || filters["sort_mod"] != 1.0 || saved["git"].(map[string]any)["auto_commit_delay"] != -4.0 {
+		t.Errorf("skipped settings lost: %v", saved)
+	}
+	if _, ok := saved["markdown_theme"]; ok {
+		t.Error("unchanged default written to the file")
+	}
+
+	// A file that isn't JSON is left alone
+	os.WriteFile(getConfigPath(), []byte("{editor: vim"), 0644)
+	config, err = LoadConfig()
+	m.globalConfig, m.globalConfigErr = config, err
+	m.openSettings()
+	m.settingsModal.config.Editor = "nano"
+	m.saveSettings()
+	if !m.showSettingsModal || m.settingsModal.err == "" {
+		t.Error("settings saved over invalid JSON")
+	}
+	if got := string(mustRead(t, getConfigPath())); got != "{editor: vim" {
+		t.Errorf("invalid file rewritten: %q", got)
+	}
+}
@@ -20,10 +20,24 @@ type StatusBar struct {
 	message     string
 	messageTime time.Time
 	filters     []string
+	git         *gitStatus
 }
 
 type clearMessageMsg struct{}
 
+// statusMessageMsg shows a message from a command that has no access to
+// the model
+type statusMessageMsg struct {
+	message  string
+	duration time.Duration
+}
+
+func statusMessage(message string, duration time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		return statusMessageMsg{message: message, duration: duration}
+	}
+}
+
 func NewStatusBar() StatusBar {
 	return StatusBar{
 		width:   0,
@@ -73,6 +87,17 @@ func (sb StatusBar) View() string {
 		parts = append(parts, fmt.Sprintf("%d files, %d dirs", sb.fileCount, sb.dirCount))
 	}
 
+	if sb.git != nil && sb.git.branch != "" {
+		branch := "⎇ " + sb.git.branch
+		if sb.git.ahead > 0 {
+			branch += fmt.Sprintf(" ↑%d", sb.git.ahead)
+		}
+		if sb.git.behind > 0 {
+			branch += fmt.Sprintf(" ↓%d", sb.git.behind)
+		}
+		parts = append(parts, branch)
+	}
+
 	parts = append(parts, sb.mode)
 
 	if len(sb.filters) > 0 {
@@ -105,6 +130,10 @@ func (sb *StatusBar) SetCounts(files, dirs int) {
 	sb.dirCount = dirs
 }
 
+func (sb *StatusBar) SetGit(git *gitStatus) {
+	sb.git = git
+}
+
 func (sb *StatusBar) SetMode(mode string) {
 	sb.mode = mode
 }
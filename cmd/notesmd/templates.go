@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// newNoteDir returns the folder receiving new notes
+func (c NotesConfig) newNoteDir(rootDir, currentDir string) string {
+	switch c.NewNoteLocation {
+	case "root":
+		return rootDir
+	case "folder":
+		if c.NewNoteFolder != "" {
+			return filepath.Join(rootDir, c.NewNoteFolder)
+		}
+		return rootDir
+	default:
+		return currentDir
+	}
+}
+
+// loadTemplate reads a note template from the templates folder and expands
+// the {{title}}, {{date}}, {{time}} and {{date:FORMAT}} variables
+func loadTemplate(rootDir string, c NotesConfig, name, title string, now time.Time) (string, error) {
+	if filepath.Ext(name) == "" {
+		name += ".md"
+	}
+	path := filepath.Join(rootDir, c.TemplatesFolder, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("modèle introuvable : %s", path)
+	}
+	return expandTemplate(string(data), title, now), nil
+}
+
+// expandTemplate replaces the template variables of content
+func expandTemplate(content, title string, now time.Time) string {
+	var sb strings.Builder
+	for {
+		start := strings.Index(content, "{{")
+		if start == -1 {
+			break
+		}
+		end := strings.Index(content[start:], "}}")
+		if end == -1 {
+			break
+		}
+		end += start
+
+		sb.WriteString(content[:start])
+		name, format, _ := strings.Cut(strings.TrimSpace(content[start+2:end]), ":")
+		layout, _ := momentToGoLayout(format)
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "title":
+			sb.WriteString(title)
+		case "date":
+			if format == "" {
+				layout = "2006-01-02"
+			}
+			sb.WriteString(now.Format(layout))
+		case "time":
+			if format == "" {
+				layout = "15:04"
+			}
+			sb.WriteString(now.Format(layout))
+		default:
+			// Unknown variables are kept as written
+			sb.WriteString(content[start : end+2])
+		}
+		content = content[end+2:]
+	}
+	sb.WriteString(content)
+	return sb.String()
+}
+
+// momentTokens maps Moment.js date tokens (used by Obsidian) to Go layouts,
+// longest tokens first
+var momentTokens = []struct{ moment, layout string }{
+	{"YYYY", "2006"}, {"YY", "06"},
+	{"MMMM", "January"}, {"MMM", "Jan"}, {"MM", "01"}, {"M", "1"},
+	{"dddd", "Monday"}, {"ddd", "Mon"},
+	{"DD", "02"}, {"D", "2"},
+	{"HH", "15"}, {"hh", "03"}, {"h", "3"},
+	{"mm", "04"}, {"m", "4"},
+	{"ss", "05"}, {"s", "5"},
+	{"A", "PM"}, {"a", "pm"},
+	{"ZZ", "-0700"}, {"Z", "-07:00"},
+}
+
+// momentToGoLayout converts a Moment.js format to a Go time layout.
+// It reports false when the format uses tokens Go cannot express
+// (week numbers, ordinals, day of year...).
+func momentToGoLayout(format string) (string, bool) {
+	var sb strings.Builder
+	ok := true
+
+	for i := 0; i < len(format); {
+		// Day of year has no Go equivalent
+		if strings.HasPrefix(format[i:], "DDD") {
+			ok = false
+		}
+
+		// [escaped text] is copied as is
+		if format[i] == '[' {
+			if end := strings.IndexByte(format[i:], ']'); end != -1 {
+				sb.WriteString(format[i+1 : i+end])
+				i += end + 1
+				continue
+			}
+		}
+
+		matched := false
+		for _, tok := range momentTokens {
+			if strings.HasPrefix(format[i:], tok.moment) {
+				sb.WriteString(tok.layout)
+				i += len(tok.moment)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		if strings.ContainsRune("dDEeGgWwQoXxSk", rune(format[i])) {
+			ok = false
+		}
+		sb.WriteByte(format[i])
+		i++
+	}
+	return sb.String(), ok
+}
@@ -22,6 +22,15 @@ var titlePalette = []string{"208", "196", "46", "51", "201"}
 // Markdown theme for glamour rendering
 var markdownTheme = "dark"
 
+// setMarkdownTheme selects the Glamour style (built-in name or JSON file),
+// "dark" when name is empty
+func setMarkdownTheme(name string) {
+	if name == "" {
+		name = "dark"
+	}
+	markdownTheme = name
+}
+
 // Lipgloss styles
 var (
 	logoStyle = lipgloss.NewStyle().
@@ -11,6 +11,15 @@ import (
 
 // Update handles all state updates
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	updated, cmd := m.update(msg)
+	if m, ok := updated.(model); ok {
+		m.syncOutline()
+		return m, cmd
+	}
+	return updated, cmd
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 
 	// Dynamic resize handling
@@ -30,6 +39,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.viewport.Width = rightWidth
 		m.viewport.Height = viewHeight
 
+		if m.openOnStart != "" {
+			m.openNoteAtHeading(m.openOnStart, -1)
+			m.openOnStart = ""
+		}
+
 		return m, nil
 
 	// Editor finished
@@ -39,7 +53,24 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.viewport.SetContent(loadMarkdown(it.path, m.viewport.Width))
 			}
 		}
-		return m, nil
+		cmd := m.notesChanged()
+		return m, cmd
+
+	// Git status loaded
+	case gitStatusMsg:
+		var cmd tea.Cmd
+		if msg.status != nil && msg.status.conflicts() && (m.git == nil || !m.git.conflicts()) {
+			cmd = m.statusBar.SetMessage("⚠ Conflits à résoudre : Ctrl+G sur un fichier marqué !", 5*time.Second)
+		}
+		m.git = msg.status
+		m.statusBar.SetGit(msg.status)
+		m.gitModal.setStatus(msg.status)
+		if !m.searchActive {
+			index := m.list.Index()
+			m.applyFilters()
+			m.list.Select(index)
+		}
+		return m, cmd
 
 	// Editor error
 	case editorErrorMsg:
@@ -47,17 +78,58 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.viewport.SetContent(fmt.Sprintf("Erreur lors de l'ouverture de l'éditeur :\n\n%s", string(msg)))
 		return m, nil
 
+	// Git operation finished
+	case gitOpMsg:
+		cmd := m.handleGitOp(msg)
+		return m, cmd
+
+	// Cached passphrase may have expired
+	case lockCheckMsg:
+		cmd := m.handleLockCheck(msg)
+		return m, cmd
+
+	case configWatchMsg:
+		cmd := m.handleConfigWatch()
+		return m, cmd
+
+	// Auto-commit debounce elapsed, only the latest save commits
+	case autoCommitMsg:
+		if msg.seq != m.autoCommitSeq || m.git == nil {
+			return m, nil
+		}
+		return m, autoCommit(m.rootDir)
+
+	case statusMessageMsg:
+		cmd := m.statusBar.SetMessage(msg.message, msg.duration)
+		return m, cmd
+
 	// Clipboard copied
 	case clipboardCopiedMsg:
 		cmd := m.statusBar.SetMessage(msg.message, 2*time.Second)
 		return m, cmd
 
+	// Only the renderer writes to the terminal, the sequence goes out in View
+	case clipboardOSC52Msg:
+		m.osc52 = msg.seq
+		seq := msg.seq
+		cmd := tea.Batch(
+			m.statusBar.SetMessage(msg.message, 2*time.Second),
+			tea.Tick(osc52Hold, func(time.Time) tea.Msg { return osc52SentMsg{seq: seq} }),
+		)
+		return m, cmd
+
+	case osc52SentMsg:
+		if m.osc52 == msg.seq {
+			m.osc52 = ""
+		}
+		return m, nil
+
 	// Paste completed
 	case pasteCompletedMsg:
 		if msg.success {
 			m.setDir(m.currentDir)
 		}
-		cmd := m.statusBar.SetMessage(msg.message, 2*time.Second)
+		cmd := tea.Batch(m.statusBar.SetMessage(msg.message, 2*time.Second), m.notesChanged())
 		return m, cmd
 
 	// Content search completed (deprecated - using in-note search now)
@@ -92,24 +164,38 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m model) updateHome(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// Handle help modal
 	if m.showHelpModal {
-		if msg.String() == "esc" || msg.String() == "?" {
+		if msg.String() == "esc" || m.keys.actions[normalizeKey(msg.String())] == actHelp {
 			m.showHelpModal = false
 			return m, nil
 		}
 		return m, nil
 	}
 
-	switch msg.String() {
-	case "?":
-		m.showHelpModal = true
-		m.helpModal = newHelpModal()
-	case "enter":
-		m.mode = modeBrowser
-		m.setDir(m.rootDir)
-	case "t":
-		m.toggleTheme()
-	case "q", "ctrl+c":
-		return m, tea.Quit
+	if m.showConfigWarnModal {
+		handled, cmd := m.handleConfigWarnModalKey(msg)
+		if handled {
+			return m, cmd
+		}
+	}
+
+	if m.showVaultModal {
+		handled, cmd := m.handleVaultModalKey(msg)
+		if handled {
+			return m, cmd
+		}
+	}
+
+	// Keys go through the keymap like in the browser, open enters it
+	result, act := m.keys.press(&m.keyPrefix, msg.String())
+	if result != keyAction {
+		return m, nil
+	}
+	switch act {
+	case actOpen:
+		m.enterBrowser()
+	case actHelp, actTheme, actSwitchVault, actQuit:
+		cmd := m.runAction(act)
+		return m, cmd
 	}
 	return m, nil
 }
@@ -117,6 +203,20 @@ func (m model) updateHome(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 // updateBrowser handles updates for browser mode
 func (m model) updateBrowser(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	// If any modal is open, handle that first
+	if m.showConfigWarnModal {
+		handled, cmd := m.handleConfigWarnModalKey(msg)
+		if handled {
+			return m, cmd
+		}
+	}
+
+	if m.showPassphraseModal {
+		handled, cmd := m.handlePassphraseModalKey(msg)
+		if handled {
+			return m, cmd
+		}
+	}
+
 	if m.showEditModal {
 		handled, cmd := m.handleEditModalKey(msg)
 		if handled {
@@ -131,6 +231,34 @@ func (m model) updateBrowser(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if m.showPaletteModal {
+		handled, cmd := m.handlePaletteModalKey(msg)
+		if handled {
+			return m, cmd
+		}
+	}
+
+	if m.showVaultModal {
+		handled, cmd := m.handleVaultModalKey(msg)
+		if handled {
+			return m, cmd
+		}
+	}
+
+	if m.showSettingsModal {
+		handled, cmd := m.handleSettingsModalKey(msg)
+		if handled {
+			return m, cmd
+		}
+	}
+
+	if m.showConfigModal {
+		handled, cmd := m.handleConfigModalKey(msg)
+		if handled {
+			return m, cmd
+		}
+	}
+
 	if m.showConfirmModal {
 		handled, cmd := m.handleConfirmModalKey(msg)
 		if handled {
@@ -147,7 +275,7 @@ func (m model) updateBrowser(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	if m.showHelpModal {
 		// Close help modal on Esc or ?
-		if msg.String() == "esc" || msg.String() == "?" {
+		if msg.String() == "esc" || m.keys.actions[normalizeKey(msg.String())] == actHelp {
 			m.showHelpModal = false
 			return m, nil
 		}
@@ -182,6 +310,48 @@ func (m model) updateBrowser(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	if m.showSymbolModal {
+		handled, cmd := m.handleSymbolModalKey(msg)
+		if handled {
+			return m, cmd
+		}
+	}
+
+	if m.showExportModal {
+		handled, cmd := m.handleExportModalKey(msg)
+		if handled {
+			return m, cmd
+		}
+	}
+
+	if m.showCopyModal {
+		handled, cmd := m.handleCopyModalKey(msg)
+		if handled {
+			return m, cmd
+		}
+	}
+
+	if m.showHistoryModal {
+		handled, cmd := m.handleHistoryModalKey(msg)
+		if handled {
+			return m, cmd
+		}
+	}
+
+	if m.showConflictModal {
+		handled, cmd := m.handleConflictModalKey(msg)
+		if handled {
+			return m, cmd
+		}
+	}
+
+	if m.showGitModal {
+		handled, cmd := m.handleGitModalKey(msg)
+		if handled {
+			return m, cmd
+		}
+	}
+
 	if m.searchActive {
 		handled, cmd := m.handleSearchKey(msg)
 		if handled {
@@ -196,21 +366,68 @@ func (m model) updateBrowser(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	// Reset lastKey for non-continuation keys
-	key := msg.String()
-	if key != "g" && m.lastKey == "g" {
-		m.lastKey = ""
+	if m.showOutline && m.keyPrefix == "" {
+		handled, cmd := m.handleOutlineKey(msg)
+		if handled {
+			return m, cmd
+		}
+	}
+
+	// Resolve the key through the keymap, chords wait for their next key
+	switch result, act := m.keys.press(&m.keyPrefix, msg.String()); result {
+	case keyPending:
+		return m, nil
+	case keyAction:
+		if m.showOutline && m.runOutlineAction(act) {
+			return m, nil
+		}
+		cmd := m.runAction(act)
+		m.previewSelection()
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+// previewSelection previews the selected note when the selection changed
+func (m *model) previewSelection() {
+	if !m.autoPreview {
+		return
 	}
-	if key != "d" && m.lastKey == "d" {
-		m.lastKey = ""
+	currentIndex := m.list.Index()
+	if currentIndex == m.lastSelectedIndex {
+		return
 	}
+	m.lastSelectedIndex = currentIndex
+	if it, ok := m.list.SelectedItem().(fileItem); ok && !it.isDir {
+		content := loadMarkdownWithLinks(it.path, m.rootDir, m.viewport.Width)
+		m.viewport.SetContent(content)
+		m.showPreview = true
+		m.currentNotePath = it.path
+		m.currentNoteRaw = loadMarkdownRaw(it.path)
+	}
+}
 
-	switch key {
+// runAction runs a browser action bound in the keymap
+func (m *model) runAction(act action) tea.Cmd {
+	switch act {
 
-	case "q", "ctrl+c":
-		return m, tea.Quit
+	case actQuit:
+		return tea.Quit
 
-	case "right", "l", "enter":
+	case actUp:
+		m.list.CursorUp()
+
+	case actDown:
+		m.list.CursorDown()
+
+	case actPageUp:
+		m.list.PrevPage()
+
+	case actPageDown:
+		m.list.NextPage()
+
+	case actOpen:
 		if m.searchActive {
 			break
 		}
@@ -220,10 +437,14 @@ func (m model) updateBrowser(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			} else {
 				m.trackRecentFile(it.path)
 				m.currentNotePath = it.path
+				if isEncryptedNote(it.path) && !sessionKeys.unlocked() {
+					m.showPassphraseModal = true
+					m.passphraseModal = newPassphraseModal(it.path, unlockToView)
+				}
 			}
 		}
 
-	case "left", "h":
+	case actParent:
 		if m.searchActive {
 			break
 		}
@@ -233,25 +454,17 @@ func (m model) updateBrowser(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	// Vim-style navigation
-	case "g":
-		// Handle 'gg' to go to top
-		if m.lastKey == "g" {
-			m.list.Select(0)
-			m.lastKey = ""
-			return m, nil
-		}
-		m.lastKey = "g"
-		return m, nil
+	case actTop:
+		m.list.Select(0)
 
-	case "G":
+	case actBottom:
 		// Go to bottom of list
 		itemCount := len(m.list.Items())
 		if itemCount > 0 {
 			m.list.Select(itemCount - 1)
 		}
-		m.lastKey = ""
 
-	case "ctrl+d":
+	case actHalfPageDown:
 		// Page down (half page)
 		current := m.list.Index()
 		pageSize := m.list.Height() / 2
@@ -263,9 +476,8 @@ func (m model) updateBrowser(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if newIndex >= 0 {
 			m.list.Select(newIndex)
 		}
-		m.lastKey = ""
 
-	case "ctrl+u":
+	case actHalfPageUp:
 		// Page up (half page)
 		current := m.list.Index()
 		pageSize := m.list.Height() / 2
@@ -274,114 +486,114 @@ func (m model) updateBrowser(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			newIndex = 0
 		}
 		m.list.Select(newIndex)
-		m.lastKey = ""
 
-	case "ctrl+o":
+	case actBack:
 		// Navigate back in history
 		m.navBack()
-		m.lastKey = ""
 
-	case "ctrl+i":
+	case actForward:
 		// Navigate forward in history
 		m.navForward()
-		m.lastKey = ""
 
 	// Preview scrolling
-	case "u":
-		// Scroll preview up
+	case actScrollUp:
 		if m.showPreview {
 			m.viewport.LineUp(3)
 		}
-		m.lastKey = ""
 
-	case "d":
-		// Scroll preview down
+	case actScrollDown:
 		if m.showPreview {
 			m.viewport.LineDown(3)
 		}
-		m.lastKey = ""
 
 	// File operations
-	case "D":
+	case actDelete:
 		// Delete file/folder with confirmation
 		if it, ok := m.list.SelectedItem().(fileItem); ok {
 			m.showConfirmModal = true
 			m.confirmModal = newConfirmDeleteModal(it.path, it.name)
 		}
-		m.lastKey = ""
 
-	case "r":
+	case actRename:
 		// Rename file/folder
 		if it, ok := m.list.SelectedItem().(fileItem); ok {
 			m.showRenameModal = true
 			m.renameModal = newRenameModal(it.path, it.name)
 		}
-		m.lastKey = ""
 
-	case "e":
+	case actEditExternal:
 		if it, ok := m.list.SelectedItem().(fileItem); ok && !it.isDir {
-			return m, openInEditor(it.path)
+			// An external editor would need the note in clear on disk
+			if isEncryptedNote(it.path) {
+				return m.statusBar.SetMessage("Note chiffrée : utilisez "+m.keys.hint(actEditInline)+" pour l'éditer en mémoire", 3*time.Second)
+			}
+			// Without git, keep the version the editor is about to change
+			if m.git == nil {
+				writeSnapshot(m.rootDir, it.path)
+			}
+			return openInEditor(m.config.Editor, it.path)
 		}
 
-	case "E":
+	case actEditInline:
 		// Quick inline edit
-		if m.currentNotePath != "" && filepath.Ext(m.currentNotePath) == ".md" {
-			rawContent := loadMarkdownRaw(m.currentNotePath)
-			m.showEditModal = true
-			m.editModal = newEditModal(m.currentNotePath, rawContent, m.width, m.height)
+		if m.currentNotePath != "" && isMarkdownNote(m.currentNotePath) {
+			return m.openEditModal(m.currentNotePath)
 		}
-		m.lastKey = ""
-		return m, nil
 
-	case "t":
+	case actTheme:
 		m.toggleTheme()
-		m.lastKey = ""
-		return m, nil
 
-	case "?":
-		// Open help modal
+	case actHelp:
 		m.showHelpModal = true
-		m.helpModal = newHelpModal()
-		m.lastKey = ""
-		return m, nil
+		m.helpModal = newHelpModal(m.keys)
 
-	case "n":
+	case actPalette:
+		return m.openPalette()
+
+	case actSwitchVault:
+		return m.openVaultModal()
+
+	case actSettings:
+		return m.openSettings()
+
+	case actShowConfig:
+		m.showConfigModal = true
+		m.configModal = newConfigModal(m.rootDir, m.config, m.configOrigins, m.width, m.height)
+
+	case actGoToFolder, actMoveToFolder, actGitCommit:
+		// Actions taking an argument ask for it in the palette
+		m.paletteModal = newPaletteModal(m.keys.paletteEntries())
+		return m.openPrompt(act)
+
+	case actGitPull, actGitPush:
+		if m.git == nil {
+			return m.statusBar.SetMessage("Le vault n'est pas un dépôt git", 2*time.Second)
+		}
+		if act == actGitPull {
+			return m.startGitOp("pull", []string{"pull", "--rebase", "--autostash"})
+		}
+		return m.startGitOp("push", []string{"push"})
+
+	case actNewNote:
 		// Open note creation modal
 		m.showNoteModal = true
 		m.noteModal = newNoteModal()
-		m.lastKey = ""
-		return m, nil
+		m.noteModal.SetLinkTargets(m.rootDir, m.vaultNotes())
 
-	case "m":
-		// Toggle .md filter
+	case actToggleMdOnly:
 		m.mdOnly = !m.mdOnly
 		m.applyFilters()
-		m.lastKey = ""
 
-	case ".":
-		// Toggle hidden files
+	case actToggleHidden:
 		m.showHidden = !m.showHidden
 		m.applyFilters()
-		m.lastKey = ""
 
-	case "s":
+	case actSort:
 		// Cycle through sort modes: name -> date -> size -> name
 		m.sortMode = (m.sortMode + 1) % 3
 		m.applyFilters()
-		m.lastKey = ""
 
-	case "-":
-		// Navigate to parent directory
-		if m.searchActive {
-			break
-		}
-		parent := filepath.Dir(m.currentDir)
-		if parent != m.currentDir {
-			m.setDir(parent)
-		}
-		m.lastKey = ""
-
-	case "~":
+	case actHome:
 		// Navigate to home directory
 		if m.searchActive {
 			break
@@ -391,50 +603,75 @@ func (m model) updateBrowser(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.setDir(home)
 			m.rootDir = home
 		}
-		m.lastKey = ""
 
-	case "y":
-		// Copy file path to clipboard
+	case actCopyPath:
 		if it, ok := m.list.SelectedItem().(fileItem); ok {
-			msg := copyFilePath(it.path)
-			cmd := m.statusBar.SetMessage(msg.message, 2*time.Second)
-			return m, cmd
+			return copyNote(it.path, m.rootDir, copyPath)
 		}
-		m.lastKey = ""
 
-	case "Y":
-		// Copy file content to clipboard
+	case actCopyContent:
 		if it, ok := m.list.SelectedItem().(fileItem); ok && !it.isDir {
-			msg := copyFileContent(it.path)
-			cmd := m.statusBar.SetMessage(msg.message, 2*time.Second)
-			return m, cmd
+			return copyNote(it.path, m.rootDir, copyContent)
 		}
-		m.lastKey = ""
 
-	case "N":
-		// Create new directory
+	case actCopyMenu:
+		// Choose what to copy to clipboard
+		if it, ok := m.list.SelectedItem().(fileItem); ok {
+			m.showCopyModal = true
+			m.copyModal = newCopyModal(it.path, it.isDir)
+		}
+
+	case actHistory:
+		// History of the selected note
+		if it, ok := m.list.SelectedItem().(fileItem); ok && !it.isDir && isMarkdownNote(it.path) {
+			return m.openHistory(it.path)
+		}
+
+	case actEncrypt:
+		// Encrypt or decrypt the selected note
+		if it, ok := m.list.SelectedItem().(fileItem); ok && !it.isDir && isMarkdownNote(it.path) {
+			return m.toggleEncryption(it.path)
+		}
+
+	case actExport:
+		// Export the selected note
+		if it, ok := m.list.SelectedItem().(fileItem); ok && !it.isDir && filepath.Ext(it.path) == ".md" {
+			m.showExportModal = true
+			m.exportModal = newExportModal(it.path)
+		}
+
+	case actGit:
+		// Git panel, or the resolution view of a conflicted file
+		if it, ok := m.list.SelectedItem().(fileItem); ok && it.git == gitConflicted && !it.isDir && m.git != nil {
+			for _, e := range m.git.entries {
+				if e.path == it.path {
+					m.showConflictModal = true
+					m.conflictModal = newConflictModal(e, m.git.rebasing, m.width, m.height)
+					return nil
+				}
+			}
+		}
+		m.showGitModal = true
+		m.gitModal = newGitModal(m.git)
+		return m.refreshGit()
+
+	case actNewFolder:
 		m.showCreateDirModal = true
 		m.createDirModal = newCreateDirModal(m.currentDir)
-		m.lastKey = ""
-		return m, nil
 
-	case "c":
+	case actCopyFile:
 		// Copy file (to internal clipboard)
 		if it, ok := m.list.SelectedItem().(fileItem); ok {
 			m.clipboard = &FileClipboard{path: it.path, mode: "copy"}
-			cmd := m.statusBar.SetMessage("Copied: "+it.name, 2*time.Second)
-			return m, cmd
+			return m.statusBar.SetMessage("Copied: "+it.name, 2*time.Second)
 		}
-		m.lastKey = ""
 
-	case "p":
-		// Paste file
+	case actPaste:
 		if m.clipboard != nil {
-			return m, pasteFile(m.clipboard, m.currentDir)
+			return pasteFile(m.clipboard, m.currentDir)
 		}
-		m.lastKey = ""
 
-	case "b":
+	case actBookmark:
 		// Toggle bookmark on current file
 		if it, ok := m.list.SelectedItem().(fileItem); ok && !it.isDir {
 			added := m.toggleBookmark(it.path)
@@ -442,79 +679,162 @@ func (m model) updateBrowser(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			if added {
 				message = "Bookmark added"
 			}
-			cmd := m.statusBar.SetMessage(message, 2*time.Second)
-			return m, cmd
+			return m.statusBar.SetMessage(message, 2*time.Second)
 		}
-		m.lastKey = ""
 
-	case "B":
-		// Show all bookmarks
+	case actBookmarks:
 		m.showBookmarksModal = true
 		m.bookmarksModal = newBookmarksModal(m.bookmarks, m.width, m.height)
-		m.lastKey = ""
-		return m, nil
 
-	case "L":
+	case actLinks:
 		// Show links in current note
 		if m.currentNotePath != "" {
 			// Parse links from raw content
 			rawContent := loadMarkdownRaw(m.currentNotePath)
 			links := parseWikiLinks(rawContent)
 
-			if len(links) > 0 {
-				m.showLinksModal = true
-				m.linksModal = newLinksModal(links, m.rootDir, m.width, m.height)
-			} else {
-				cmd := m.statusBar.SetMessage("Aucun lien trouvé dans cette note", 2*time.Second)
-				return m, cmd
+			if len(links) == 0 {
+				return m.statusBar.SetMessage("Aucun lien trouvé dans cette note", 2*time.Second)
 			}
+			m.showLinksModal = true
+			m.linksModal = newLinksModal(links, m.rootDir, m.width, m.height)
 		}
-		m.lastKey = ""
-		return m, nil
 
-	case "ctrl+r":
-		// Show recent files
+	case actRecent:
 		m.showRecentModal = true
 		m.recentModal = newRecentFilesModal(m.recentFiles, m.width, m.height)
-		m.lastKey = ""
-		return m, nil
 
-	case "F":
+	case actOutline:
+		// Show the outline of the current note
+		if m.showPreview && m.currentNotePath != "" {
+			m.openOutline()
+		}
+
+	case actSearchNote:
 		// Start in-note search (only if a note is open)
 		if m.showPreview && m.currentNotePath != "" {
 			m.searchInNoteActive = true
 			m.noteSearchQuery = ""
-			m.lastKey = ""
 		}
-		return m, nil
 
-	case "/":
+	case actSearchHeading:
+		// Go to any heading in the vault
+		m.showSymbolModal = true
+		m.symbolModal = newSymbolSearchModal(buildHeadingIndex(m.vaultNotes(), m.rootDir), m.width, m.height)
+
+	case actSearch:
 		m.searchActive = true
 		m.searchQuery = ""
 		m.ensureAllFilesScanned()
 		m.buildSearchResults()
-		m.lastKey = ""
-		return m, nil
 	}
 
-	var cmd tea.Cmd
-	m.list, cmd = m.list.Update(msg)
+	return nil
+}
 
-	// Auto-preview on selection change
-	if m.autoPreview {
-		currentIndex := m.list.Index()
-		if currentIndex != m.lastSelectedIndex {
-			m.lastSelectedIndex = currentIndex
-			if it, ok := m.list.SelectedItem().(fileItem); ok && !it.isDir {
-				content := loadMarkdownWithLinks(it.path, m.rootDir, m.viewport.Width)
-				m.viewport.SetContent(content)
-				m.showPreview = true
-				m.currentNotePath = it.path
-			}
+// openNoteAtHeading opens a note in the preview scrolled to its index-th heading
+func (m *model) openNoteAtHeading(path string, index int) {
+	m.setDir(filepath.Dir(path))
+	for i, item := range m.baseItems {
+		if fi, ok := item.(fileItem); ok && fi.path == path {
+			m.list.Select(i)
+			break
 		}
 	}
+	m.lastSelectedIndex = m.list.Index()
+
+	m.currentNotePath = path
+	m.currentNoteRaw = loadMarkdownRaw(path)
+	content := loadMarkdownWithLinks(path, m.rootDir, m.viewport.Width)
+	m.viewport.SetContent(content)
+	m.showPreview = true
+	m.trackRecentFile(path)
+
+	offsets := headingOffsets(m.currentNoteRaw, m.rootDir, m.viewport.Width)
+	if index >= 0 && index < len(offsets) {
+		m.viewport.SetYOffset(offsets[index])
+	}
+}
+
+// openOutline builds the outline panel for the current note
+func (m *model) openOutline() {
+	if m.currentNoteRaw == "" {
+		m.currentNoteRaw = loadMarkdownRaw(m.currentNotePath)
+	}
+	m.outline = newOutlinePanel(m.currentNoteRaw, m.rootDir, m.viewport.Width)
+	if active := m.outline.current(m.viewport.YOffset); active >= 0 {
+		m.outline.cursor = active
+	}
+	m.showOutline = true
+}
+
+// syncOutline rebuilds the outline when the note or the preview width
+// changed, and closes it when no note is previewed
+func (m *model) syncOutline() {
+	if !m.showOutline {
+		return
+	}
+	if !m.showPreview || m.currentNotePath == "" {
+		m.showOutline = false
+		return
+	}
+	if m.outline.raw == m.currentNoteRaw && m.outline.width == m.viewport.Width {
+		return
+	}
+	if m.outline.raw == m.currentNoteRaw {
+		// Same headings, only their rendered lines moved
+		m.outline.offsets = headingOffsets(m.currentNoteRaw, m.rootDir, m.viewport.Width)
+		m.outline.width = m.viewport.Width
+		return
+	}
+	m.outline = newOutlinePanel(m.currentNoteRaw, m.rootDir, m.viewport.Width)
+	if active := m.outline.current(m.viewport.YOffset); active >= 0 {
+		m.outline.cursor = active
+	}
+}
+
+// handleOutlineKey handles the keys of the outline panel outside the keymap
+func (m *model) handleOutlineKey(msg tea.KeyMsg) (handled bool, cmd tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.showOutline = false
+		return true, nil
 
-	return m, cmd
+	case " ":
+		m.outline.toggle()
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// runOutlineAction runs a keymap action on the outline panel, reporting
+// whether the panel used it
+func (m *model) runOutlineAction(act action) bool {
+	switch act {
+	case actOutline:
+		m.showOutline = false
+
+	case actUp:
+		m.outline.move(-1)
+
+	case actDown:
+		m.outline.move(1)
+
+	case actOpen:
+		// Scroll the preview to the selected heading and expand it
+		if offset, ok := m.outline.selectedOffset(); ok {
+			m.viewport.SetYOffset(offset)
+		}
+		m.outline.setCollapsed(false)
+
+	case actParent:
+		m.outline.setCollapsed(true)
+
+	default:
+		return false
+	}
+	return true
 }
 
 // handleSearchKey handles keyboard input during search
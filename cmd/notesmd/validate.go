@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// configIssue is a setting of a configuration file that was not applied
+type configIssue struct {
+	file string
+	line int    // 0 when unknown
+	key  string // dotted key, empty for the whole file
+	msg  string
+}
+
+func (i configIssue) String() string {
+	loc := i.file
+	if i.line > 0 {
+		loc = fmt.Sprintf("%s:%d", i.file, i.line)
+	}
+	if i.key == "" {
+		return loc + " : " + i.msg
+	}
+	return fmt.Sprintf("%s : %s : %s", loc, i.key, i.msg)
+}
+
+// configError lists the settings skipped while loading configuration files
+type configError []configIssue
+
+func (e configError) Error() string {
+	if len(e) == 1 {
+		return e[0].String()
+	}
+	return fmt.Sprintf("%s (et %d autre(s) problème(s))", e[0], len(e)-1)
+}
+
+// covers reports whether the setting key, or the object holding it, was
+// rejected
+func (e configError) covers(key string) bool {
+	for _, issue := range e {
+		// Issues without a key reject the whole file
+		if issue.key == "" || key == issue.key || strings.HasPrefix(key, issue.key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// configIssues returns the issues held by err, a single issue for errors
+// that are not a configError
+func configIssues(err error, file string) []configIssue {
+	if err == nil {
+		return nil
+	}
+	var ce configError
+	if errors.As(err, &ce) {
+		return ce
+	}
+	return []configIssue{{file: file, msg: err.Error()}}
+}
+
+// configDecoder applies a JSON configuration file key by key, so an invalid
+// setting doesn't prevent the others from applying
+type configDecoder struct {
+	data    []byte
+	file    string
+	dec     *json.Decoder
+	lines   map[string]int  // dotted key → line in the file
+	refused map[string]bool // keys this file may not set
+	issues  configError
+}
+
+// Settings only config.json holds: a vault doesn't declare the vaults, the
+// default folder or the key bindings
+var globalOnlyKeys = map[string]bool{"vaults": true, "default_dir": true, "keys": true}
+
+// decodeConfig decodes data over config, skipping and reporting unknown
+// keys, values of the wrong type and values out of range. Invalid JSON
+// applies nothing.
+func decodeConfig(data []byte, file string, config *Config) configError {
+	return decodeConfigExcept(data, file, config, nil)
+}
+
+// decodeVaultConfig decodes the configuration of a vault, which may not set
+// the global only keys
+func decodeVaultConfig(data []byte, file string, config *Config) configError {
+	return decodeConfigExcept(data, file, config, globalOnlyKeys)
+}
+
+// decodeConfigExcept decodes like decodeConfig, reporting the refused keys
+// without applying them
+func decodeConfigExcept(data []byte, file string, config *Config, refused map[string]bool) configError {
+	d := &configDecoder{data: data, file: file, lines: map[string]int{}, refused: refused}
+
+	var syntax *json.SyntaxError
+	var probe any
+	if err := json.Unmarshal(data, &probe); errors.As(err, &syntax) {
+		return configError{{file: file, line: d.lineAt(syntax.Offset), msg: "JSON invalide : " + syntax.Error()}}
+	} else if err != nil {
+		return configError{{file: file, msg: err.Error()}}
+	}
+	if _, ok := probe.(map[string]any); !ok {
+		return configError{{file: file, line: 1, msg: "la configuration doit être un objet JSON"}}
+	}
+
+	prev := *config
+	d.dec = json.NewDecoder(bytes.NewReader(data))
+	d.dec.Token() // {
+	d.object("", reflect.ValueOf(config).Elem())
+
+	for _, rule := range configRules {
+		line, set := d.lines[rule.key]
+		if !set {
+			continue
+		}
+		if msg := rule.check(config); msg != "" {
+			rule.reset(config, &prev)
+			d.issues = append(d.issues, configIssue{file: file, line: line, key: rule.key, msg: msg})
+		}
+	}
+	sort.SliceStable(d.issues, func(i, j int) bool { return d.issues[i].line < d.issues[j].line })
+	return d.issues
+}
+
+// lineAt returns the line of a byte offset of the file
+func (d *configDecoder) lineAt(offset int64) int {
+	if offset > int64(len(d.data)) {
+		offset = int64(len(d.data))
+	}
+	return bytes.Count(d.data[:offset], []byte("\n")) + 1
+}
+
+// object decodes the members of an object whose "{" was read into the
+// struct v, up to and including its "}"
+func (d *configDecoder) object(prefix string, v reflect.Value) {
+	for d.dec.More() {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return
+		}
+		name, _ := tok.(string)
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		line := d.lineAt(d.dec.InputOffset())
+
+		if d.refused[key] {
+			d.issues = append(d.issues, configIssue{file: d.file, line: line, key: key, msg: "réglage de config.json uniquement, ignoré dans un vault"})
+			d.skip()
+			continue
+		}
+
+		field, ok := fieldByTag(v, name)
+		if !ok {
+			msg := "clé inconnue"
+			if guess := closestTag(v.Type(), name); guess != "" {
+				msg += fmt.Sprintf(" (vouliez-vous dire « %s » ?)", guess)
+			}
+			d.issues = append(d.issues, configIssue{file: d.file, line: line, key: key, msg: msg})
+			d.skip()
+			continue
+		}
+		d.lines[key] = line
+
+		if field.Kind() == reflect.Struct {
+			tok, err := d.dec.Token()
+			if err != nil {
+				return
+			}
+			if tok != json.Delim('{') {
+				d.issues = append(d.issues, configIssue{file: d.file, line: line, key: key, msg: "type invalide : objet attendu"})
+				d.skipRest(tok)
+				continue
+			}
+			d.object(key, field)
+			continue
+		}
+
+		var raw json.RawMessage
+		if err := d.dec.Decode(&raw); err != nil {
+			return
+		}
+		if msg := decodeValue(raw, field); msg != "" {
+			d.issues = append(d.issues, configIssue{file: d.file, line: line, key: key, msg: msg})
+		}
+	}
+	d.dec.Token() // }
+}
+
+// skip reads and drops the next value
+func (d *configDecoder) skip() {
+	var raw json.RawMessage
+	d.dec.Decode(&raw)
+}
+
+// skipRest drops the rest of a value whose first token was tok
+func (d *configDecoder) skipRest(tok json.Token) {
+	if tok != json.Delim('{') && tok != json.Delim('[') {
+		return
+	}
+	for depth := 1; depth > 0; {
+		t, err := d.dec.Token()
+		if err != nil {
+			return
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+}
+
+// decodeValue sets field from raw, returning a message when raw doesn't fit
+// its type. Maps are merged into the current value as json.Unmarshal does.
+func decodeValue(raw json.RawMessage, field reflect.Value) string {
+	target := reflect.New(field.Type())
+	if field.Kind() == reflect.Map && !field.IsNil() {
+		merged := reflect.MakeMap(field.Type())
+		for _, k := range field.MapKeys() {
+			merged.SetMapIndex(k, field.MapIndex(k))
+		}
+		target.Elem().Set(merged)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(target.Interface()); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return "type invalide : " + typeLabel(field.Type()) + " attendu"
+		}
+		if strings.HasPrefix(err.Error(), "json: unknown field ") {
+			return "clé inconnue " + strings.TrimPrefix(err.Error(), "json: unknown field ")
+		}
+		return err.Error()
+	}
+	field.Set(target.Elem())
+	return ""
+}
+
+// typeLabel names a setting type for error messages
+func typeLabel(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "texte"
+	case reflect.Bool:
+		return "booléen (true ou false)"
+	case reflect.Int, reflect.Int64:
+		return "nombre entier"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Struct {
+			return "liste d'objets"
+		}
+		return "liste de " + typeLabel(t.Elem())
+	case reflect.Map:
+		return "objet"
+	}
+	return t.String()
+}
+
+// fieldByTag returns the field of the struct v with the JSON name name
+func fieldByTag(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) == name {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
+
+// closestTag suggests the key of t closest to a misspelled name
+func closestTag(t reflect.Type, name string) string {
+	best, bestDist := "", 3
+	for i := 0; i < t.NumField(); i++ {
+		tag := jsonName(t.Field(i))
+		if d := editDistance(strings.ToLower(name), tag); d < bestDist {
+			best, bestDist = tag, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cur := row[j]
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			row[j] = min(row[j]+1, row[j-1]+1, prev+cost)
+			prev = cur
+		}
+	}
+	return row[len(rb)]
+}
+
+// configRule checks the value of a setting once decoded; reset restores the
+// value of the lower layer when the check fails
+type configRule struct {
+	key   string
+	check func(c *Config) string
+	reset func(c, prev *Config)
+}
+
+var configRules = []configRule{
+	{
+		key: "theme",
+		check: func(c *Config) string {
+			return checkRange(c.Theme, 0, len(titlePalette)-1)
+		},
+		reset: func(c, prev *Config) { c.Theme = prev.Theme },
+	},
+	{
+		key: "filters.sort_mode",
+		check: func(c *Config) string {
+			return checkRange(c.Filters.SortMode, 0, 2)
+		},
+		reset: func(c, prev *Config) { c.Filters.SortMode = prev.Filters.SortMode },
+	},
+	{
+		key: "search.max_recent_files",
+		check: func(c *Config) string {
+			return checkRange(c.Search.MaxRecentFiles, 0, 1000)
+		},
+		reset: func(c, prev *Config) { c.Search.MaxRecentFiles = prev.Search.MaxRecentFiles },
+	},
+	{
+		key: "notes.new_note_location",
+		check: func(c *Config) string {
+			switch c.Notes.NewNoteLocation {
+			case "current", "root", "folder":
+				return ""
+			}
+			return `valeur invalide : "current", "root" ou "folder" attendu`
+		},
+		reset: func(c, prev *Config) { c.Notes.NewNoteLocation = prev.Notes.NewNoteLocation },
+	},
+	{
+		key: "git.auto_commit_delay",
+		check: func(c *Config) string {
+			return checkRange(c.Git.AutoCommitDelay, 0, 24*3600)
+		},
+		reset: func(c, prev *Config) { c.Git.AutoCommitDelay = prev.Git.AutoCommitDelay },
+	},
+	{
+		key: "encryption.passphrase_timeout",
+		check: func(c *Config) string {
+			return checkRange(c.Encryption.PassphraseTimeout, 0, 24*60)
+		},
+		reset: func(c, prev *Config) { c.Encryption.PassphraseTimeout = prev.Encryption.PassphraseTimeout },
+	},
+	{
+		key: "vaults",
+		check: func(c *Config) string {
+			seen := map[string]bool{}
+			for i, v := range c.Vaults {
+				if v.Name == "" || v.Path == "" {
+					return fmt.Sprintf("le vault n°%d doit avoir un nom (name) et un chemin (path)", i+1)
+				}
+				if seen[v.Name] {
+					return fmt.Sprintf("nom de vault en double : %q", v.Name)
+				}
+				seen[v.Name] = true
+			}
+			return ""
+		},
+		reset: func(c, prev *Config) { c.Vaults = prev.Vaults },
+	},
+}
+
+// checkRange describes why n is outside [lo, hi], empty when it is inside
+func checkRange(n, lo, hi int) string {
+	if n < lo || n > hi {
+		return fmt.Sprintf("valeur hors limites : %d (de %d à %d)", n, lo, hi)
+	}
+	return ""
+}
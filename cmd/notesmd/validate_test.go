@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDecodeConfigReportsIssues(t *testing.T) {
+	data := []byte(`{
+  "editor": "vim",
+  "theme": 42,
+  "fitlers": {"md_only": true},
+  "filters": {
+    "md_only": "oui",
+    "show_hidden": true,
+    "sort_mode": -1
+  },
+  "keys": {"quit": ["Q"]},
+  "vaults": [{"name": "a", "path": "/a"}, {"name": "a", "path": "/b"}]
+}`)
+	config := DefaultConfig()
+	issues := decodeConfig(data, "config.json", config)
+
+	want := map[string]int{
+		"theme":             3,
+		"fitlers":           4,
+		"filters.md_only":   6,
+		"filters.sort_mode": 8,
+		"vaults":            11,
+	}
+	if len(issues) != len(want) {
+		t.Fatalf("issues = %v", issues)
+	}
+	for _, issue := range issues {
+		if line, ok := want[issue.key]; !ok || issue.line != line {
+			t.Errorf("unexpected issue %s", issue)
+		}
+	}
+	if !strings.Contains(issues.Error(), "config.json:") {
+		t.Errorf("Error() = %q", issues.Error())
+	}
+
+	// Valid settings still apply, invalid ones keep their previous value
+	def := DefaultConfig()
+	if config.Editor != "vim" || !config.Filters.ShowHidden || len(config.Keys["quit"]) != 1 {
+		t.Errorf("valid settings not applied: %+v", config)
+	}
+	if config.Theme != def.Theme || config.Filters.MdOnly || config.Filters.SortMode != def.Filters.SortMode || config.Vaults != nil {
+		t.Errorf("invalid settings applied: %+v", config)
+	}
+}
+
+func TestDecodeVaultConfigRefusesGlobalKeys(t *testing.T) {
+	data := []byte(`{
+  "theme": 2,
+  "default_dir": "/ailleurs",
+  "keys": {"quit": ["Q"]},
+  "vaults": [{"name": "b", "path": "/b"}]
+}`)
+	config := DefaultConfig()
+	config.DefaultDir = "/notes"
+	issues := decodeVaultConfig(data, ".notesmd/config", config)
+
+	want := map[string]int{"default_dir": 3, "keys": 4, "vaults": 5}
+	if len(issues) != len(want) {
+		t.Fatalf("issues = %v", issues)
+	}
+	for _, issue := range issues {
+		if line, ok := want[issue.key]; !ok || issue.line != line {
+			t.Errorf("unexpected issue %s", issue)
+		}
+	}
+	if config.Theme != 2 || config.DefaultDir != "/notes" || config.Keys != nil || config.Vaults != nil {
+		t.Errorf("vault config applied %+v", config)
+	}
+}
+
+func TestDecodeConfigSyntaxError(t *testing.T) {
+	config := DefaultConfig()
+	issues := decodeConfig([]byte("{\n  \"editor\": \"vim\",\n  \"theme\": 2,\n}"), "config.json", config)
+	if len(issues) != 1 || issues[0].line != 4 || issues[0].key != "" {
+		t.Fatalf("issues = %v", issues)
+	}
+	if config.Editor == "vim" {
+		t.Error("settings of an invalid file applied")
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// VaultConfig is a named vault of the configuration
+type VaultConfig struct {
+	Name string `json:"name"`
+	Path string `json:"path"` // "~/" is expanded to the home directory
+}
+
+// dir returns the absolute path of the vault
+func (v VaultConfig) dir() string {
+	path := v.Path
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, path[1:])
+		}
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// VaultState is the session of a named vault
+type VaultState struct {
+	LastDirectory string   `json:"last_directory"`
+	LastNote      string   `json:"last_note"`
+	RecentFiles   []string `json:"recent_files"`
+	Bookmarks     []string `json:"bookmarks"`
+	NavHistory    []string `json:"nav_history"`
+	NavIndex      int      `json:"nav_index"`
+}
+
+// findVault returns the vault called name
+func (c *Config) findVault(name string) (VaultConfig, bool) {
+	for _, v := range c.Vaults {
+		if v.Name == name {
+			return v, true
+		}
+	}
+	return VaultConfig{}, false
+}
+
+// vaultForDir returns the vault containing dir, the deepest one when vaults
+// are nested
+func (c *Config) vaultForDir(dir string) (VaultConfig, bool) {
+	var found VaultConfig
+	ok := false
+	for _, v := range c.Vaults {
+		root := v.dir()
+		if !isWithin(root, dir) {
+			continue
+		}
+		if !ok || len(root) > len(found.dir()) {
+			found, ok = v, true
+		}
+	}
+	return found, ok
+}
+
+// isWithin reports whether path is root or one of its descendants
+func isWithin(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// vaultState returns the session of the vault called name, creating it
+func (s *SessionState) vaultState(name string) *VaultState {
+	if s.Vaults == nil {
+		s.Vaults = map[string]*VaultState{}
+	}
+	vs := s.Vaults[name]
+	if vs == nil {
+		vs = &VaultState{}
+		s.Vaults[name] = vs
+	}
+	return vs
+}
+
+// applyVaultConfig layers the configuration of the vault over the global one
+func (m *model) applyVaultConfig() {
+	config, origins, err := loadVaultConfig(m.globalConfig, m.rootDir)
+	setMarkdownTheme(config.MarkdownTheme)
+
+	m.config = config
+	m.configOrigins = origins
+	m.configErr = err
+	m.mdOnly = config.Filters.MdOnly
+	m.showHidden = config.Filters.ShowHidden
+	m.sortMode = config.Filters.SortMode
+	m.keys = newKeymap(config.Keys)
+	m.ignore = setVaultIgnore(m.rootDir, config)
+	if origins["theme"] == originVault && config.Theme >= 0 && config.Theme < len(titlePalette) {
+		// A vault theme wins over the last theme of the session
+		m.themeIndex = config.Theme
+	}
+	sessionKeys.timeout = config.Encryption.passphraseTimeout()
+	m.configStamp = m.configStamps()
+}
+
+// storeVaultState saves the session of the current vault into m.state, the
+// flat fields holding the session outside named vaults
+func (m *model) storeVaultState() {
+	if m.vault == "" {
+		m.state.LastDirectory = m.currentDir
+		m.state.RecentFiles = m.recentFiles
+		m.state.Bookmarks = m.bookmarks
+		return
+	}
+	vs := m.state.vaultState(m.vault)
+	vs.LastDirectory = m.currentDir
+	vs.LastNote = m.currentNotePath
+	vs.RecentFiles = m.recentFiles
+	vs.Bookmarks = m.bookmarks
+	vs.NavHistory = m.navHistory
+	vs.NavIndex = m.navIndex
+}
+
+// restoreVaultState loads the session of the vault v, rooted at m.rootDir
+func (m *model) restoreVaultState(v VaultConfig) {
+	vs := m.state.vaultState(v.Name)
+	m.vault = v.Name
+	m.recentFiles = append([]string{}, vs.RecentFiles...)
+	m.bookmarks = append([]string{}, vs.Bookmarks...)
+
+	m.navHistory, m.navIndex = nil, 0
+	for _, dir := range vs.NavHistory {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() && isWithin(m.rootDir, dir) {
+			m.navHistory = append(m.navHistory, dir)
+		}
+	}
+	if len(m.navHistory) == len(vs.NavHistory) && vs.NavIndex >= 0 && vs.NavIndex < len(m.navHistory) {
+		m.navIndex = vs.NavIndex
+	} else if len(m.navHistory) > 0 {
+		m.navIndex = len(m.navHistory) - 1
+	}
+
+	m.currentDir = m.rootDir
+	if info, err := os.Stat(vs.LastDirectory); err == nil && info.IsDir() && isWithin(m.rootDir, vs.LastDirectory) {
+		m.currentDir = vs.LastDirectory
+	}
+	m.resumeNote = ""
+	if _, err := os.Stat(vs.LastNote); err == nil && isWithin(m.rootDir, vs.LastNote) {
+		m.resumeNote = vs.LastNote
+	}
+}
+
+// enterBrowser shows the current directory, selecting the note the vault
+// was left on
+func (m *model) enterBrowser() {
+	m.mode = modeBrowser
+	m.setDir(m.currentDir)
+	if m.resumeNote != "" {
+		m.selectPath(m.resumeNote)
+		m.resumeNote = ""
+	}
+}
+
+// selectPath selects path in the list when it is shown
+func (m *model) selectPath(path string) {
+	for i, item := range m.list.Items() {
+		if fi, ok := item.(fileItem); ok && fi.path == path {
+			m.list.Select(i)
+			return
+		}
+	}
+}
+
+// switchVault saves the current vault and opens v in the browser
+func (m *model) switchVault(v VaultConfig) tea.Cmd {
+	root := v.dir()
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return m.statusBar.SetMessage("Vault introuvable : "+root, 3*time.Second)
+	}
+	m.storeVaultState()
+
+	// The passphrase of one vault never opens the notes of another
+	sessionKeys.lock()
+	m.lockSeq++
+
+	m.rootDir = root
+	m.restoreVaultState(v)
+	m.applyVaultConfig()
+
+	m.allFiles = nil
+	m.git = nil
+	m.clipboard = nil
+	m.currentNotePath = ""
+	m.currentNoteRaw = ""
+	m.showPreview = false
+	m.viewport.SetContent("")
+	m.lastSelectedIndex = -1
+	m.enterBrowser()
+
+	m.openConfigWarnings(false)
+	return tea.Batch(m.statusBar.SetMessage("Vault : "+v.Name, 2*time.Second), m.refreshGit())
+}
+
+// openConfigWarnings lists the settings that could not be applied, those of
+// config.json too when global is set
+func (m *model) openConfigWarnings(global bool) {
+	var issues []configIssue
+	if global {
+		issues = configIssues(m.globalConfigErr, getConfigPath())
+	}
+	issues = append(issues, configIssues(m.configErr, vaultConfigPath(m.rootDir))...)
+	if len(issues) > 0 {
+		m.showConfigWarnModal = true
+		m.configWarnModal = configWarnModal{issues: issues}
+	}
+}
+
+// openVaultModal opens the vault switcher
+func (m *model) openVaultModal() tea.Cmd {
+	if len(m.globalConfig.Vaults) == 0 {
+		return m.statusBar.SetMessage("Aucun vault nommé dans la configuration", 3*time.Second)
+	}
+	m.showVaultModal = true
+	m.vaultModal = newVaultModal(m.globalConfig.Vaults, m.vault)
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVaultForDir(t *testing.T) {
+	root := t.TempDir()
+	config := &Config{Vaults: []VaultConfig{
+		{Name: "notes", Path: root},
+		{Name: "work", Path: filepath.Join(root, "work")},
+	}}
+
+	cases := map[string]string{
+		root:                                  "notes",
+		filepath.Join(root, "perso"):          "notes",
+		filepath.Join(root, "work"):           "work",
+		filepath.Join(root, "work", "projet"): "work",
+		filepath.Join(root, "workshop"):       "notes",
+		filepath.Dir(root):                    "",
+	}
+	for dir, want := range cases {
+		v, ok := config.vaultForDir(dir)
+		if ok != (want != "") || v.Name != want {
+			t.Errorf("vaultForDir(%q) = %q, %v, want %q", dir, v.Name, ok, want)
+		}
+	}
+
+	home, _ := os.UserHomeDir()
+	if got := (VaultConfig{Path: "~/notes"}).dir(); got != filepath.Join(home, "notes") {
+		t.Errorf("dir() = %q, want the home directory expanded", got)
+	}
+}
+
+func TestSwitchVaultKeepsSessions(t *testing.T) {
+	a, b := t.TempDir(), t.TempDir()
+	writeTestFile(t, filepath.Join(a, "sub", "a.md"), "# A")
+	writeTestFile(t, filepath.Join(b, "b.md"), "# B")
+
+	config := DefaultConfig()
+	config.Vaults = []VaultConfig{{Name: "a", Path: a}, {Name: "b", Path: b}}
+	state := &SessionState{}
+
+	m := initialModel(a, config, state)
+	m.restoreVaultState(config.Vaults[0])
+	m.enterBrowser()
+	m.setDir(filepath.Join(a, "sub"))
+	m.currentNotePath = filepath.Join(a, "sub", "a.md")
+	m.bookmarks = []string{m.currentNotePath}
+	m.trackRecentFile(m.currentNotePath)
+	defer sessionKeys.lock()
+	sessionKeys.set("phrase secrète")
+
+	m.switchVault(config.Vaults[1])
+	if m.rootDir != b || m.currentDir != b || len(m.bookmarks) != 0 || len(m.recentFiles) != 0 {
+		t.Fatalf("vault b opened with root %q, dir %q, bookmarks %v, recents %v", m.rootDir, m.currentDir, m.bookmarks, m.recentFiles)
+	}
+	if sessionKeys.unlocked() {
+		t.Error("passphrase of vault a still cached in vault b")
+	}
+
+	m.switchVault(config.Vaults[0])
+	if m.currentDir != filepath.Join(a, "sub") {
+		t.Errorf("vault a reopened in %q, want its last folder", m.currentDir)
+	}
+	if len(m.bookmarks) != 1 || len(m.recentFiles) != 1 || len(m.navHistory) != 2 {
+		t.Errorf("vault a lost its session: bookmarks %v, recents %v, history %v", m.bookmarks, m.recentFiles, m.navHistory)
+	}
+	if it, ok := m.list.SelectedItem().(fileItem); !ok || it.name != "a.md" {
+		t.Errorf("selected %v, want the last note of the vault", m.list.SelectedItem())
+	}
+}
+
+func TestInitialModelUsesVaultSession(t *testing.T) {
+	root, outside := t.TempDir(), t.TempDir()
+	writeTestFile(t, filepath.Join(root, "sub", "a.md"), "# A")
+	note := filepath.Join(root, "sub", "a.md")
+	flat := filepath.Join(outside, "flat.md")
+
+	config := DefaultConfig()
+	config.Vaults = []VaultConfig{{Name: "notes", Path: root}}
+	state := &SessionState{RecentFiles: []string{flat}, Bookmarks: []string{flat}}
+	state.vaultState("notes").Bookmarks = []string{note}
+
+	m := initialModel(filepath.Join(root, "sub"), config, state)
+	if m.vault != "notes" || m.rootDir != root || m.currentDir != filepath.Join(root, "sub") {
+		t.Errorf("opened vault %q at root %q, dir %q", m.vault, m.rootDir, m.currentDir)
+	}
+	if len(m.bookmarks) != 1 || m.bookmarks[0] != note || len(m.recentFiles) != 0 {
+		t.Errorf("vault session seeded with bookmarks %v, recents %v", m.bookmarks, m.recentFiles)
+	}
+
+	m.bookmarks = append(m.bookmarks, note)
+	m.storeVaultState()
+	if len(state.Bookmarks) != 1 || state.Bookmarks[0] != flat {
+		t.Errorf("flat session changed to %v", state.Bookmarks)
+	}
+
+	m = initialModel(outside, config, state)
+	if m.vault != "" || len(m.bookmarks) != 1 || m.bookmarks[0] != flat {
+		t.Errorf("outside vaults, vault %q with bookmarks %v", m.vault, m.bookmarks)
+	}
+}
@@ -71,10 +71,16 @@ func (m model) viewBrowser() string {
 		rightWidth = 20
 	}
 
+	leftContent := m.list.View()
+	if m.showOutline {
+		help := m.keys.hint(actOpen) + ": aller • Espace: plier • " + m.keys.hint(actOutline) + "/Esc: fermer"
+		leftContent = m.outline.View(leftWidth, m.list.Height(), m.viewport.YOffset, accent, help)
+	}
+
 	left := borderStyle.
 		BorderForeground(accent).
 		Width(leftWidth).
-		Render(m.list.View())
+		Render(leftContent)
 
 	var rightContent string
 	if m.showPreview {
@@ -105,10 +111,11 @@ func (m model) viewBrowser() string {
 				searchQueryStyle.Render(m.searchQuery) +
 				"\nENTER pour ouvrir le résultat sélectionné — ESC pour annuler\n",
 		)
+	} else if m.keyPrefix != "" {
+		// Which-key hint for the chord in progress
+		footer = m.keys.whichKeyView(m.keyPrefix, m.width)
 	} else {
-		footer = helpStyle.Render(
-			"\n? aide • ↑/↓ naviguer • D supprimer • r renommer • m filtre .md • n nouvelle note • / rechercher • F recherche note • q quitter\n",
-		)
+		footer = helpStyle.Render("\n" + m.keys.footer() + "\n")
 	}
 
 	// Status bar using custom component
@@ -118,7 +125,19 @@ func (m model) viewBrowser() string {
 
 	// If any modal is open, overlay it on top
 	var modalView string
-	if m.showEditModal {
+	if m.showConfigWarnModal {
+		modalView = m.configWarnModal.View()
+	} else if m.showPassphraseModal {
+		modalView = m.passphraseModal.View()
+	} else if m.showPaletteModal {
+		modalView = m.paletteModal.View()
+	} else if m.showVaultModal {
+		modalView = m.vaultModal.View()
+	} else if m.showSettingsModal {
+		modalView = m.settingsModal.View()
+	} else if m.showConfigModal {
+		modalView = m.configModal.View()
+	} else if m.showEditModal {
 		modalView = m.editModal.View()
 	} else if m.showNoteModal {
 		modalView = m.noteModal.View()
@@ -134,6 +153,18 @@ func (m model) viewBrowser() string {
 		modalView = m.bookmarksModal.View()
 	} else if m.showLinksModal {
 		modalView = m.linksModal.View()
+	} else if m.showSymbolModal {
+		modalView = m.symbolModal.View()
+	} else if m.showExportModal {
+		modalView = m.exportModal.View()
+	} else if m.showCopyModal {
+		modalView = m.copyModal.View()
+	} else if m.showHistoryModal {
+		modalView = m.historyModal.View()
+	} else if m.showConflictModal {
+		modalView = m.conflictModal.View()
+	} else if m.showGitModal {
+		modalView = m.gitModal.View()
 	} else if m.showHelpModal {
 		modalView = m.helpModal.View()
 	}
@@ -25,13 +25,16 @@ func (m model) viewHome() string {
 	notesText := notesStyle.Render("NOTES.md")
 	helpText := helpStyle.Render("Press any key to continue")
 
-	content := lipgloss.JoinVertical(
-		lipgloss.Left,
-		title,
-		"",
-		notesText,
-		helpText,
-	)
+	lines := []string{title, "", notesText}
+	if m.vault != "" {
+		lines = append(lines, helpStyle.Render("Vault : "+m.vault))
+	}
+	if len(m.globalConfig.Vaults) > 0 {
+		helpText = helpStyle.Render(m.keys.hint(actOpen) + ": ouvrir • " + m.keys.hint(actSwitchVault) + ": changer de vault")
+	}
+	lines = append(lines, helpText)
+
+	content := lipgloss.JoinVertical(lipgloss.Left, lines...)
 
 	card := cardStyle.
 		BorderForeground(accent).
@@ -60,6 +63,10 @@ func (m model) viewHome() string {
 	var modalView string
 	if m.showHelpModal {
 		modalView = m.helpModal.View()
+	} else if m.showConfigWarnModal {
+		modalView = m.configWarnModal.View()
+	} else if m.showVaultModal {
+		modalView = m.vaultModal.View()
 	}
 
 	if modalView != "" {